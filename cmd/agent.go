@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/agent"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run a background agent that makes switches and PTZ moves instant",
+	Long: `Run a long-lived agent that keeps one connection to the console and a live
+copy of its viewports, liveviews and cameras, and listens on a Unix socket
+(by default $XDG_RUNTIME_DIR/protect/agent.sock).
+
+While the agent is running, --switch, --ptz, "viewport switch" and
+"ptz goto" are handed to it, so a switch is a single request with no name
+lookups. When no agent answers, or it is connected to a different console,
+commands run directly as usual. Dry runs always run directly.
+
+Set agent: false in the config file (or PROTECT_AGENT=false) to never use
+the agent.`,
+	Example: `  protect agent &
+  protect agent status`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		socket, _ := cmd.Flags().GetString("socket")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Agent listening on %s\n", socket)
+		return agent.New(c, config.Get()).Run(ctx, socket)
+	},
+}
+
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether an agent is running",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		socket, _ := cmd.Flags().GetString("socket")
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		conn, err := agent.Dial(socket)
+		if err != nil {
+			return fmt.Errorf("no agent running on %s", socket)
+		}
+		defer conn.Close()
+
+		status, err := conn.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get agent status: %w", err)
+		}
+
+		if opts.Structured() {
+			return output.Write(cmd.OutOrStdout(), opts, output.Listing{
+				Items:   status,
+				Headers: []string{"socket", "url", "pid", "started", "loaded"},
+				Rows:    [][]string{{socket, status.URL, strconv.Itoa(status.PID), status.Started.Format(time.RFC3339), strconv.FormatBool(status.Loaded)}},
+				Names:   []string{socket},
+			})
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Socket:\t%s\n", socket)
+		fmt.Fprintf(w, "Console:\t%s\n", status.URL)
+		fmt.Fprintf(w, "PID:\t%d\n", status.PID)
+		fmt.Fprintf(w, "Uptime:\t%s\n", time.Since(status.Started).Round(time.Second))
+		if status.Loaded {
+			fmt.Fprintf(w, "Inventory:\t%d viewports, %d liveviews, %d cameras\n", status.Viewports, status.Liveviews, status.Cameras)
+		} else {
+			fmt.Fprintln(w, "Inventory:\tnot loaded")
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	agentCmd.PersistentFlags().String("socket", agent.SocketPath(), "Unix socket path (use --socket=<path>)")
+
+	agentCmd.AddCommand(agentStatusCmd)
+	rootCmd.AddCommand(agentCmd)
+}
+
+// forwardActions runs acts through a running agent. It returns false when
+// the command should run directly instead: for dry runs, when the agent is
+// disabled, not running, not reading requests, or set up with a different
+// console or API token. An agent that took a request but did not answer
+// may still be running it, so that is reported rather than run again.
+func forwardActions(c *client.Client, acts []actions.Action) ([]actions.Result, bool, error) {
+	if c.DryRun || !config.Get().Agent {
+		return nil, false, nil
+	}
+
+	log := logger.Get()
+
+	conn, err := agent.Dial(agent.SocketPath())
+	if err != nil {
+		log.Debugw("No agent running, running directly", "error", err)
+		return nil, false, nil
+	}
+	defer conn.Close()
+
+	results, err := conn.Run(c.BaseURL, c.APIToken, acts)
+	switch {
+	case errors.Is(err, agent.ErrWrongConsole):
+		log.Debugw("Agent connected to a different console, running directly")
+		return nil, false, nil
+	case errors.Is(err, agent.ErrWrongToken):
+		log.Debugw("Agent uses a different API token, running directly")
+		return nil, false, nil
+	case errors.Is(err, agent.ErrNotDelivered):
+		log.Warnw("Agent not reading requests, running directly", "socket", agent.SocketPath())
+		return nil, false, nil
+	case errors.Is(err, agent.ErrNoResponse):
+		return nil, true, fmt.Errorf("%w; the actions may still be running (stop the agent or set agent: false to run directly)", err)
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	log.Infow("Forwarded to agent", "actions", len(acts))
+	return results, true, nil
+}
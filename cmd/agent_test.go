@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/agent"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestForwardToAgent(t *testing.T) {
+	var lookups, switches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPatch:
+			switches.Add(1)
+			w.Write([]byte(`{}`))
+		case r.URL.Path == "/proxy/protect/integration/v1/viewers":
+			lookups.Add(1)
+			w.Write([]byte(`[{"id":"vp1","name":"Tower"}]`))
+		case r.URL.Path == "/proxy/protect/integration/v1/liveviews":
+			lookups.Add(1)
+			w.Write([]byte(`[{"id":"lv1","name":"All Cameras"}]`))
+		default:
+			lookups.Add(1)
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	// Unix socket paths are limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "protect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	c := client.NewClient(server.URL, "test-token")
+	a := agent.New(c, &config.Config{})
+	if err := a.Inventory().Load(c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	l, err := agent.Listen(agent.SocketPath())
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, l) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	cfg := config.Get()
+	defer func(enabled bool) { cfg.Agent = enabled }(cfg.Agent)
+	cfg.Agent = true
+
+	lookups.Store(0)
+	buf := new(bytes.Buffer)
+	if err := handleViewportSwitch(client.NewClient(server.URL, "test-token"), buf, output.Options{Format: output.Table}, "Tower", "All Cameras"); err != nil {
+		t.Fatalf("handleViewportSwitch() error = %v", err)
+	}
+	if buf.String() != "Successfully switched viewport Tower to liveview All Cameras\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+	if lookups.Load() != 0 || switches.Load() != 1 {
+		t.Errorf("Expected a single PATCH through the agent, got %d lookups and %d switches", lookups.Load(), switches.Load())
+	}
+
+	if err := handleViewportSwitch(c, buf, output.Options{Format: output.Table}, "Lobby", "All Cameras"); err == nil || !strings.Contains(err.Error(), "viewport not found: Lobby") {
+		t.Errorf("Expected the agent's lookup error, got %v", err)
+	}
+
+	// Dry runs, a disabled agent and an agent for another console or token
+	// all run directly
+	dry := client.NewClient(server.URL, "test-token")
+	dry.DryRun = true
+	dry.DryRunOutput = new(bytes.Buffer)
+	if _, forwarded, _ := forwardActions(dry, nil); forwarded {
+		t.Error("Expected dry runs to run directly")
+	}
+
+	other := client.NewClient(server.URL+"/other", "test-token")
+	if _, forwarded, err := forwardActions(other, nil); forwarded || err != nil {
+		t.Errorf("Expected another console to run directly, got %v, %v", forwarded, err)
+	}
+
+	restricted := client.NewClient(server.URL, "restricted-token")
+	if _, forwarded, err := forwardActions(restricted, []actions.Action{actions.Switch("Tower", "All Cameras")}); forwarded || err != nil {
+		t.Errorf("Expected another API token to run directly, got %v, %v", forwarded, err)
+	}
+
+	cfg.Agent = false
+	if _, forwarded, _ := forwardActions(c, nil); forwarded {
+		t.Error("Expected a disabled agent to be skipped")
+	}
+}
+
+func TestForwardNoAgent(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	cfg := config.Get()
+	defer func(enabled bool) { cfg.Agent = enabled }(cfg.Agent)
+	cfg.Agent = true
+
+	c := client.NewClient("https://protect.example.com", "test-token")
+	if _, forwarded, err := forwardActions(c, nil); forwarded || err != nil {
+		t.Errorf("Expected to run directly without an agent, got %v, %v", forwarded, err)
+	}
+}
+
+func TestForwardAgentNotResponding(t *testing.T) {
+	defer func(timeout time.Duration) { agent.CallTimeout = timeout }(agent.CallTimeout)
+	agent.CallTimeout = 50 * time.Millisecond
+
+	dir, err := os.MkdirTemp("", "protect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	// A stuck agent accepts connections but never answers
+	l, err := agent.Listen(agent.SocketPath())
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	cfg := config.Get()
+	defer func(enabled bool) { cfg.Agent = enabled }(cfg.Agent)
+	cfg.Agent = true
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// The agent took the request and may still run it, so it is not run
+	// again
+	buf := new(bytes.Buffer)
+	err = handleViewportSwitch(client.NewClient(server.URL, "test-token"), buf, output.Options{Format: output.Table}, "Tower", "All Cameras")
+	if !errors.Is(err, agent.ErrNoResponse) || !strings.Contains(err.Error(), "may still be running") {
+		t.Errorf("Expected the timeout to be reported, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected no direct requests, got %d", requests.Load())
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/desired"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Bring viewers, PTZ patrols and floodlights to a desired state",
+	Long: `Compare a desired state file with the console, print a plan of the
+differences and apply only those.
+
+The file lists viewport liveviews, PTZ patrols and floodlight modes by name or
+ID; anything not listed is left alone:
+
+  viewports:
+    - viewport: Lobby
+      liveview: Entrance
+  patrols:
+    - camera: Driveway
+      patrol: 2          # slot 0-4, or "off"
+  lights:
+    - light: Garage
+      mode: motion       # always, motion or off
+      enable_at: dark    # optional: fulltime or dark
+
+The plan is applied after you type "yes", or straight away with
+--auto-approve. --detect-drift prints the plan without applying it and exits
+non-zero if anything differs, for CI checks.`,
+	Example: `  protect apply -f desired.yaml
+  protect apply -f desired.yaml --auto-approve
+  protect apply -f desired.yaml --detect-drift -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("file")
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		detectDrift, _ := cmd.Flags().GetBool("detect-drift")
+
+		if path == "" {
+			return fmt.Errorf("--file is required (use --file=<desired state file>)")
+		}
+
+		s, err := desired.Load(path)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if opts.Structured() && !detectDrift && !autoApprove && !c.DryRun {
+			return fmt.Errorf("structured output cannot prompt for confirmation: use --auto-approve or --detect-drift")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		run := applyRun{in: cmd.InOrStdin(), out: cmd.OutOrStdout(), opts: opts, autoApprove: autoApprove, detectDrift: detectDrift}
+		return run.apply(ctx, c, s)
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringP("file", "f", "", "Desired state file (use --file=<path>)")
+	applyCmd.Flags().Bool("auto-approve", false, "Apply the plan without asking for confirmation")
+	applyCmd.Flags().Bool("detect-drift", false, "Print the plan without applying it and exit non-zero if anything differs")
+	applyCmd.MarkFlagFilename("file", "yaml", "yml", "json")
+
+	rootCmd.AddCommand(applyCmd)
+}
+
+// applyRun holds the settings of one apply
+type applyRun struct {
+	in          io.Reader
+	out         io.Writer
+	opts        output.Options
+	autoApprove bool
+	detectDrift bool
+}
+
+// apply plans the changes to reach s and applies them once approved
+func (r applyRun) apply(ctx context.Context, c *client.Client, s *desired.State) error {
+	plan, err := desired.Compute(c, s)
+	if err != nil {
+		return fmt.Errorf("failed to plan changes: %w", err)
+	}
+
+	if r.detectDrift {
+		if err := writePlan(r.out, r.opts, plan); err != nil {
+			return err
+		}
+		if len(plan.Changes) > 0 {
+			err := fmt.Errorf("drift detected: %d of %d entries differ from the desired state", len(plan.Changes), len(plan.Changes)+plan.Unchanged)
+			if r.opts.Structured() {
+				return reportedError{err}
+			}
+			return err
+		}
+		return nil
+	}
+
+	if !r.opts.Structured() {
+		writePlan(r.out, r.opts, plan)
+	}
+
+	if len(plan.Changes) == 0 {
+		if r.opts.Structured() {
+			return writeResults(r.out, r.opts, []actions.Result{})
+		}
+		return nil
+	}
+
+	if !r.autoApprove && !c.DryRun && !confirm(r.in, r.out) {
+		return fmt.Errorf("apply cancelled")
+	}
+
+	results := actions.Execute(ctx, c, plan.Targets(), actions.DefaultWorkers)
+	if !r.opts.Structured() {
+		fmt.Fprintln(r.out)
+	}
+	if err := writeResults(r.out, r.opts, results); err != nil {
+		return err
+	}
+
+	if failed := actions.Failed(results); failed > 0 {
+		err := fmt.Errorf("%d of %d changes failed", failed, len(results))
+		if r.opts.Structured() {
+			return reportedError{err}
+		}
+		return err
+	}
+
+	if !r.opts.Structured() {
+		verb := "Apply complete"
+		if c.DryRun {
+			verb = "Dry run complete"
+		}
+		fmt.Fprintf(r.out, "\n%s: %d changed, %d unchanged.\n", verb, len(results), plan.Unchanged)
+	}
+	return nil
+}
+
+// planKinds names each kind of change in the plan
+var planKinds = map[actions.Kind]string{
+	actions.KindSwitch: "viewport",
+	actions.KindPatrol: "patrol",
+	actions.KindLight:  "light",
+}
+
+// writePlan prints the changes in a Terraform-like layout, or as structured
+// records
+func writePlan(out io.Writer, opts output.Options, plan *desired.Plan) error {
+	if opts.Structured() {
+		listing := output.Listing{
+			Items:   plan.Changes,
+			Headers: []string{"kind", "name", "id", "from", "to"},
+		}
+		for _, change := range plan.Changes {
+			listing.Rows = append(listing.Rows, []string{string(change.Kind), change.Name, change.ID, change.From, change.To})
+			listing.Names = append(listing.Names, change.Name)
+		}
+		return output.Write(out, opts, listing)
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Fprintln(out, "No changes. The console matches the desired state.")
+		return nil
+	}
+
+	fmt.Fprintln(out, "protect will make the following changes:")
+	fmt.Fprintln(out)
+	for _, change := range plan.Changes {
+		fmt.Fprintf(out, "  ~ %s %q: %s → %s\n", planKinds[change.Kind], change.Name, change.From, change.To)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Plan: %d to change, %d unchanged.\n", len(plan.Changes), plan.Unchanged)
+	return nil
+}
+
+// confirm asks whether to apply the plan; only "yes" is accepted
+func confirm(in io.Reader, out io.Writer) bool {
+	fmt.Fprint(out, "\nApply these changes? Only 'yes' will be accepted: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(out)
+		return false
+	}
+
+	return strings.TrimSpace(line) == "yes"
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/desired"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestApply(t *testing.T) {
+	liveview := "lv1"
+	server := newStateServer(t, &liveview)
+	c := client.NewClient(server.URL, "test-token")
+
+	s := &desired.State{Viewports: []desired.Viewport{{Viewport: "Tower", Liveview: "Driveway"}}}
+	table := output.Options{Format: output.Table}
+
+	t.Run("Detect drift", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := applyRun{out: buf, opts: table, detectDrift: true}.apply(context.Background(), c, s)
+		if err == nil || !strings.Contains(err.Error(), "drift detected") {
+			t.Errorf("Expected drift to be an error, got %v", err)
+		}
+		if !strings.Contains(buf.String(), `  ~ viewport "Tower": All Cameras → Driveway`) || !strings.Contains(buf.String(), "Plan: 1 to change, 0 unchanged.") {
+			t.Errorf("Unexpected plan:\n%s", buf.String())
+		}
+		changes := server.Requests()
+		if len(changes) != 0 {
+			t.Errorf("Expected no changes, got %v", changes)
+		}
+	})
+
+	t.Run("Detect drift as JSON", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := applyRun{out: buf, opts: output.Options{Format: output.JSON}, detectDrift: true}.apply(context.Background(), c, s)
+		if !errors.As(err, new(reportedError)) {
+			t.Errorf("Expected a reported error, got %v", err)
+		}
+		var plan []desired.Change
+		if err := json.Unmarshal(buf.Bytes(), &plan); err != nil || len(plan) != 1 || plan[0].From != "All Cameras" || plan[0].Kind != "switch" {
+			t.Errorf("Unexpected JSON plan %q: %v", buf.String(), err)
+		}
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := applyRun{in: strings.NewReader("no\n"), out: buf, opts: table}.apply(context.Background(), c, s)
+		if err == nil || err.Error() != "apply cancelled" {
+			t.Errorf("Expected apply to be cancelled, got %v", err)
+		}
+		changes := server.Requests()
+		if len(changes) != 0 {
+			t.Errorf("Expected no changes, got %v", changes)
+		}
+	})
+
+	t.Run("Confirmed", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := applyRun{in: strings.NewReader("yes\n"), out: buf, opts: table}.apply(context.Background(), c, s)
+		if err != nil {
+			t.Fatalf("apply() error = %v", err)
+		}
+		changes := server.Requests()
+		if strings.Join(changes, ",") != "PATCH /proxy/protect/integration/v1/viewers/vp1" {
+			t.Errorf("Unexpected changes: %v", changes)
+		}
+		if !strings.Contains(buf.String(), "Apply complete: 1 changed, 0 unchanged.") {
+			t.Errorf("Unexpected output:\n%s", buf.String())
+		}
+	})
+
+	t.Run("No drift", func(t *testing.T) {
+		liveview = "lv2"
+		buf := new(bytes.Buffer)
+		if err := (applyRun{out: buf, opts: table, detectDrift: true}).apply(context.Background(), c, s); err != nil {
+			t.Errorf("Expected no drift, got %v", err)
+		}
+		if buf.String() != "No changes. The console matches the desired state.\n" {
+			t.Errorf("Unexpected output: %q", buf.String())
+		}
+	})
+}
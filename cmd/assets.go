@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var assetsCmd = &cobra.Command{
+	Use:   "assets",
+	Short: "Manage doorbell animations and chime ringtones",
+	Long:  `List, upload and delete device asset files such as custom doorbell animations and chime ringtones.`,
+	Args:  cobra.NoArgs,
+}
+
+var assetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List asset files",
+	Example: `  protect assets list
+  protect assets list --type=ringtones`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeArg, _ := cmd.Flags().GetString("type")
+
+		types := []client.AssetType{client.AssetAnimations, client.AssetRingtones}
+		if typeArg != "" {
+			t, err := client.ParseAssetType(typeArg)
+			if err != nil {
+				return err
+			}
+			types = []client.AssetType{t}
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		return listAssets(c, cmd.OutOrStdout(), opts, types)
+	},
+}
+
+var assetsUploadCmd = &cobra.Command{
+	Use:   "upload <file>",
+	Short: "Upload an asset file",
+	Long: `Upload a doorbell animation (.gif, .png) or chime ringtone (.mp3, .wav).
+
+The file type and size are validated before anything is sent.`,
+	Example: `  protect assets upload holiday.gif
+  protect assets upload bells.mp3 --type=ringtones`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeArg, _ := cmd.Flags().GetString("type")
+
+		t, err := assetType(typeArg, args[0])
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		if err := client.ValidateAsset(t, args[0], data); err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		asset, err := c.UploadAsset(t, args[0], data)
+		if err != nil {
+			return err
+		}
+
+		if c.DryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Dry run: would upload '%s' as %s\n", args[0], t)
+			return nil
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Successfully uploaded '%s' as %s %s\n", args[0], t, asset.Name)
+		return nil
+	},
+}
+
+var assetsRmCmd = &cobra.Command{
+	Use:     "rm <name>",
+	Aliases: []string{"delete"},
+	Short:   "Delete an asset file",
+	Example: `  protect assets rm 6571f1a3 --type=ringtones
+  protect assets rm bells.mp3 --type=ringtones`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeArg, _ := cmd.Flags().GetString("type")
+		if typeArg == "" {
+			return fmt.Errorf("--type flag is required when deleting assets")
+		}
+
+		t, err := client.ParseAssetType(typeArg)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		assets, err := c.ListAssets(t)
+		if err != nil {
+			return err
+		}
+
+		asset, err := findAsset(assets, args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := c.DeleteAsset(t, asset.Name); err != nil {
+			return err
+		}
+
+		if c.DryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Dry run: would delete %s %s\n", t, args[0])
+			return nil
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Successfully deleted %s %s\n", t, args[0])
+		return nil
+	},
+}
+
+func init() {
+	assetsListCmd.Flags().String("type", "", "Asset type (use --type=<value>: 'animations' or 'ringtones')")
+	assetsUploadCmd.Flags().String("type", "", "Asset type (use --type=<value>; inferred from the file extension when omitted)")
+	assetsRmCmd.Flags().String("type", "", "Asset type (use --type=<value>: 'animations' or 'ringtones')")
+
+	assetsCmd.AddCommand(assetsListCmd, assetsUploadCmd, assetsRmCmd)
+	rootCmd.AddCommand(assetsCmd)
+}
+
+// assetType returns the asset type from the flag, or infers it from the file
+func assetType(typeArg, filename string) (client.AssetType, error) {
+	if typeArg != "" {
+		return client.ParseAssetType(typeArg)
+	}
+	return client.AssetTypeForFile(filename)
+}
+
+// findAsset finds an asset by name or original file name
+func findAsset(assets []client.Asset, nameOrFile string) (*client.Asset, error) {
+	var matches []client.Asset
+	for _, a := range assets {
+		if a.Name == nameOrFile {
+			return &a, nil
+		}
+		if a.OriginalName == nameOrFile {
+			matches = append(matches, a)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("asset not found: %s", nameOrFile)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("multiple assets named %s, delete by name instead", nameOrFile)
+	}
+}
+
+// assetRecord is the structured form of an asset listing entry
+type assetRecord struct {
+	Type         string `json:"type" yaml:"type"`
+	Name         string `json:"name" yaml:"name"`
+	OriginalName string `json:"originalName" yaml:"originalName"`
+}
+
+func listAssets(c *client.Client, out io.Writer, opts output.Options, types []client.AssetType) error {
+	log := logger.Get()
+
+	var all []client.Asset
+	for _, t := range types {
+		assets, err := c.ListAssets(t)
+		if err != nil {
+			return err
+		}
+		for _, a := range assets {
+			if a.Type == "" {
+				a.Type = t
+			}
+			all = append(all, a)
+		}
+	}
+
+	defer log.Infow("Listed assets", "count", len(all))
+
+	if opts.Structured() {
+		listing := output.Listing{
+			Headers: []string{"type", "name", "original_name"},
+		}
+		records := make([]assetRecord, 0, len(all))
+		for _, a := range all {
+			records = append(records, assetRecord{Type: string(a.Type), Name: a.Name, OriginalName: a.OriginalName})
+			listing.Rows = append(listing.Rows, []string{string(a.Type), a.Name, a.OriginalName})
+			listing.Names = append(listing.Names, a.Name)
+		}
+		listing.Items = records
+		return output.Write(out, opts, listing)
+	}
+
+	if len(all) == 0 {
+		fmt.Fprintln(out, "No assets found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		fmt.Fprintln(w, "TYPE\tNAME\tFILE")
+		fmt.Fprintln(w, "----\t----\t----")
+	}
+	for _, a := range all {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", a.Type, a.Name, a.OriginalName)
+	}
+	w.Flush()
+	return nil
+}
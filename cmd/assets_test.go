@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestAssetsSubcommands(t *testing.T) {
+	want := map[string]bool{"list": false, "upload": false, "rm": false}
+	for _, cmd := range assetsCmd.Commands() {
+		want[cmd.Name()] = true
+	}
+
+	for name, found := range want {
+		if !found {
+			t.Errorf("Expected 'assets %s' command to be registered", name)
+		}
+	}
+}
+
+func TestAssetType(t *testing.T) {
+	got, err := assetType("", "holiday.gif")
+	if err != nil || got != client.AssetAnimations {
+		t.Errorf("Expected inferred type 'animations', got '%s' (%v)", got, err)
+	}
+
+	got, err = assetType("ringtones", "bells.bin")
+	if err != nil || got != client.AssetRingtones {
+		t.Errorf("Expected explicit type 'ringtones', got '%s' (%v)", got, err)
+	}
+
+	if _, err := assetType("videos", "clip.mp4"); err == nil {
+		t.Error("Expected error for invalid type")
+	}
+}
+
+func TestFindAsset(t *testing.T) {
+	assets := []client.Asset{
+		{Name: "a1", OriginalName: "bells.mp3"},
+		{Name: "a2", OriginalName: "chimes.mp3"},
+		{Name: "a3", OriginalName: "chimes.mp3"},
+	}
+
+	if a, err := findAsset(assets, "a2"); err != nil || a.Name != "a2" {
+		t.Errorf("Expected to find a2 by name, got %+v (%v)", a, err)
+	}
+
+	if a, err := findAsset(assets, "bells.mp3"); err != nil || a.Name != "a1" {
+		t.Errorf("Expected to find a1 by file name, got %+v (%v)", a, err)
+	}
+
+	if _, err := findAsset(assets, "chimes.mp3"); err == nil {
+		t.Error("Expected error for ambiguous file name")
+	}
+
+	if _, err := findAsset(assets, "missing.mp3"); err == nil {
+		t.Error("Expected error for missing asset")
+	}
+}
+
+func TestListAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/proxy/protect/integration/v1/files/animations":
+			w.Write([]byte(`[{"name":"a1","type":"animations","originalName":"snow.gif"}]`))
+		case "/proxy/protect/integration/v1/files/ringtones":
+			w.Write([]byte(`[{"name":"r1","originalName":"bells.mp3"}]`))
+		default:
+			t.Errorf("Unexpected path '%s'", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	c := client.NewClient(server.URL, "test-token")
+	if err := listAssets(c, buf, output.Options{Format: output.Table}, []client.AssetType{client.AssetAnimations, client.AssetRingtones}); err != nil {
+		t.Fatalf("listAssets() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"snow.gif", "ringtones", "bells.mp3"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain '%s', got '%s'", want, output)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/automate"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var automateCmd = &cobra.Command{
+	Use:   "automate",
+	Short: "Run event-driven automation rules",
+	Long: `Run the rules defined under "rules:" in the configuration file until
+interrupted.
+
+Each rule matches Protect events by camera, event type ("ring", "motion",
+"smart", "sensor", ...), smart detection class and time window, then runs its
+steps in order: viewport switches, PTZ moves, scenes, shell commands and
+webhooks. A cooldown stops a rule firing again too soon, and a revert time
+switches viewports back to their previous liveview afterwards. Pending
+reverts are carried out when the daemon stops.
+
+Firings are printed as text, or as newline-delimited JSON (NDJSON) with
+--output=json.`,
+	Example: `  protect automate
+  protect automate --dry-run
+  protect automate --output=json >> automate.log`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if opts.Template != "" || (opts.Format != output.Table && opts.Format != output.JSON) {
+			return fmt.Errorf("automate supports only --output=table or --output=json")
+		}
+
+		cfg := config.Get()
+		if len(cfg.Rules) == 0 {
+			return fmt.Errorf("no rules configured")
+		}
+		if err := validateConfig(cfg.ValidateRules); err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		rules, err := automate.Compile(c, cfg)
+		if err != nil {
+			return err
+		}
+
+		cameras, err := c.ListPTZCameras()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runAutomation(ctx, c, cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, rules, c.SubscribeEvents(ctx), cameraNames(cameras))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(automateCmd)
+}
+
+// runAutomation feeds events to the rules engine, printing each firing
+func runAutomation(ctx context.Context, c *client.Client, out, errOut io.Writer, opts output.Options, rules []*automate.Rule, sub *client.EventSubscription, names map[string]string) error {
+	log := logger.Get()
+	enc := json.NewEncoder(out)
+
+	if opts.Format == output.Table {
+		fmt.Fprintf(out, "Running %d rules\n", len(rules))
+	}
+
+	// Firings are reported from several goroutines
+	var mu sync.Mutex
+	onFire := func(f automate.Firing) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if opts.Format == output.JSON {
+			enc.Encode(f)
+			return
+		}
+
+		trigger := "reverted"
+		if f.Event != nil {
+			device := names[f.Event.Device]
+			if device == "" {
+				device = f.Event.Device
+			}
+			trigger = fmt.Sprintf("%s %s", f.Event.Type, device)
+		}
+		fmt.Fprintf(out, "%s  %s  %s  %s\n", f.Time.Format("2006-01-02 15:04:05"), f.Rule, trigger, firingStatus(f.Results))
+	}
+
+	var lastErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range sub.Errors {
+			lastErr = err
+			log.Warnw("Event stream error", "error", err)
+			fmt.Fprintf(errOut, "event stream: %v\n", err)
+		}
+	}()
+
+	err := automate.NewEngine(c, rules, automate.Options{OnFire: onFire}).Run(ctx, sub.Events)
+	if err == context.Canceled {
+		// Interrupting the daemon is the normal way to stop it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The subscription ended on its own, so report why
+	<-done
+	if lastErr != nil {
+		return fmt.Errorf("event stream closed: %w", lastErr)
+	}
+	return fmt.Errorf("event stream closed")
+}
+
+// firingStatus summarises the results of a firing
+func firingStatus(results []actions.Result) string {
+	if failed := actions.Failed(results); failed > 0 {
+		for _, r := range results {
+			if !r.Success {
+				return fmt.Sprintf("failed (%d of %d steps): %s", failed, len(results), r.Error)
+			}
+		}
+	}
+	if len(results) > 0 && results[0].DryRun {
+		return fmt.Sprintf("dry run (%d steps)", len(results))
+	}
+	return fmt.Sprintf("ok (%d steps)", len(results))
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/automate"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestRunAutomation(t *testing.T) {
+	server := newListServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	cfg := &config.Config{Rules: []config.Rule{{
+		Name: "doorbell",
+		When: config.RuleCondition{Types: []string{"ring"}},
+		Then: []config.RuleAction{{Switch: &config.SceneSwitch{Viewport: "Tower", Liveview: "Driveway"}}},
+	}}}
+	rules, err := automate.Compile(c, cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	// newSub returns a finished subscription holding a ring and a motion
+	// event, and one stream error
+	newSub := func() *client.EventSubscription {
+		events := make(chan client.Event, 2)
+		events <- client.Event{Action: "add", ID: "e1", Type: client.EventRing, Device: "cam1"}
+		events <- client.Event{Action: "add", ID: "e2", Type: client.EventMotion, Device: "cam1"}
+		close(events)
+		errs := make(chan error, 1)
+		errs <- errors.New("connection reset")
+		close(errs)
+		return &client.EventSubscription{Events: events, Errors: errs}
+	}
+	names := map[string]string{"cam1": "Front Door"}
+
+	buf := new(bytes.Buffer)
+	errOut := new(bytes.Buffer)
+	err = runAutomation(context.Background(), c, buf, errOut, output.Options{Format: output.Table}, rules, newSub(), names)
+	if err == nil || !strings.Contains(err.Error(), "event stream closed: connection reset") {
+		t.Errorf("Expected the closed stream to be reported, got %v", err)
+	}
+	if errOut.String() != "event stream: connection reset\n" {
+		t.Errorf("Expected the stream error on the error writer, got %q", errOut.String())
+	}
+	if !strings.Contains(buf.String(), "Running 1 rules") || !strings.Contains(buf.String(), "doorbell  ring Front Door  ok (1 steps)") || strings.Contains(buf.String(), "motion") {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	runAutomation(context.Background(), c, buf, new(bytes.Buffer), output.Options{Format: output.JSON}, rules, newSub(), names)
+	var firing automate.Firing
+	if err := json.Unmarshal(buf.Bytes(), &firing); err != nil {
+		t.Fatalf("Expected one NDJSON record, got %q: %v", buf.String(), err)
+	}
+	if firing.Rule != "doorbell" || firing.Event.ID != "e1" || len(firing.Results) != 1 || !firing.Results[0].Success {
+		t.Errorf("Unexpected firing: %+v", firing)
+	}
+}
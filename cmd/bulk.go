@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+)
+
+// splitPairs splits repeated, comma-separated flag values into single
+// pairs. A value is only split when every piece is a <name>:<value> pair,
+// so a single pair whose names contain commas keeps working.
+func splitPairs(args []string) []string {
+	var pairs []string
+	for _, arg := range args {
+		var pieces []string
+		split := true
+		for _, piece := range strings.Split(arg, ",") {
+			if piece = strings.TrimSpace(piece); piece != "" {
+				pieces = append(pieces, piece)
+				split = split && isPair(piece)
+			}
+		}
+
+		if !split {
+			pieces = []string{strings.TrimSpace(arg)}
+		}
+		pairs = append(pairs, pieces...)
+	}
+	return pairs
+}
+
+// isPair reports whether s has exactly one colon with text on both sides
+func isPair(s string) bool {
+	name, value, ok := strings.Cut(s, ":")
+	return ok && !strings.Contains(value, ":") && strings.TrimSpace(name) != "" && strings.TrimSpace(value) != ""
+}
+
+// handleBulkCommand runs every --switch and --ptz pair. A single pair keeps
+// the single-action output; several are resolved together and run
+// concurrently, with one result per target.
+func handleBulkCommand(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, switchArgs, ptzArgs []string) error {
+	switches := splitPairs(switchArgs)
+	moves := splitPairs(ptzArgs)
+
+	switch {
+	case len(switches) == 0 && len(moves) == 0:
+		return fmt.Errorf("no switches or PTZ moves given (use --switch=<viewport>:<liveview> or --ptz=<camera>:<preset>)")
+	case len(switches) == 1 && len(moves) == 0:
+		return handleSwitchCommand(c, out, opts, switches[0])
+	case len(switches) == 0 && len(moves) == 1:
+		return handlePTZCommand(c, out, opts, moves[0])
+	}
+
+	acts := make([]actions.Action, 0, len(switches)+len(moves))
+	for _, pair := range switches {
+		viewport, liveview, err := parseSwitchPair(pair)
+		if err != nil {
+			return err
+		}
+		acts = append(acts, actions.Switch(viewport, liveview))
+	}
+	for _, pair := range moves {
+		camera, preset, err := parsePTZPair(pair)
+		if err != nil {
+			return err
+		}
+		acts = append(acts, actions.PTZ(camera, preset))
+	}
+
+	results, forwarded, err := forwardActions(c, acts)
+	if err != nil {
+		return err
+	}
+	if !forwarded {
+		targets, err := actions.NewResolver(c).Resolve(acts)
+		if err != nil {
+			return err
+		}
+		results = actions.Execute(ctx, c, targets, actions.DefaultWorkers)
+	}
+	if err := writeResults(out, opts, results); err != nil {
+		return err
+	}
+
+	if failed := actions.Failed(results); failed > 0 {
+		err := fmt.Errorf("%d of %d actions failed", failed, len(results))
+		if opts.Structured() {
+			return reportedError{err}
+		}
+		return err
+	}
+
+	if !opts.Structured() {
+		if c.DryRun {
+			fmt.Fprintf(out, "Dry run: would run %d actions\n", len(results))
+		} else {
+			fmt.Fprintf(out, "Successfully ran %d actions\n", len(results))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestSplitPairs(t *testing.T) {
+	got := splitPairs([]string{"Tower:Driveway, Lobby:Garage", "Office:All Cameras", ""})
+	want := []string{"Tower:Driveway", "Lobby:Garage", "Office:All Cameras"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPairs() = %q, want %q", got, want)
+	}
+
+	// A single pair whose liveview name contains a comma is not split
+	got = splitPairs([]string{"Tower:Lobby, North", "Office:Garage,Driveway:home"})
+	want = []string{"Tower:Lobby, North", "Office:Garage", "Driveway:home"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPairs() = %q, want %q", got, want)
+	}
+}
+
+func TestHandleBulkCommand(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	buf := new(bytes.Buffer)
+	err := handleBulkCommand(context.Background(), c, buf, output.Options{Format: output.JSON},
+		[]string{"Tower:All Cameras"}, []string{"Front Door:5,Driveway:home"})
+
+	var reported reportedError
+	if !errors.As(err, &reported) || !strings.Contains(err.Error(), "1 of 3 actions failed") {
+		t.Fatalf("Expected a reported partial failure, got %v", err)
+	}
+
+	var results []actions.Result
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("Expected JSON array, got %q: %v", buf.String(), err)
+	}
+	if len(results) != 3 || !results[0].Success || !results[1].Success || results[2].Success {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if results[2].Target != "Driveway" || results[2].Error == "" {
+		t.Errorf("Expected the Driveway move to fail with an error, got %+v", results[2])
+	}
+}
+
+func TestHandleBulkCommandResolvesFirst(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	buf := new(bytes.Buffer)
+	err := handleBulkCommand(context.Background(), c, buf, output.Options{Format: output.Table},
+		[]string{"Tower:All Cameras", "Lobby:All Cameras"}, []string{"Garage:1"})
+	if err == nil || !strings.Contains(err.Error(), "viewport not found: Lobby") || !strings.Contains(err.Error(), "camera not found: Garage") {
+		t.Errorf("Expected every unknown name to be reported, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to run, got %q", buf.String())
+	}
+
+	if err := handleBulkCommand(context.Background(), c, buf, output.Options{Format: output.Table}, []string{"Tower"}, []string{"Front Door:1"}); err == nil {
+		t.Error("Expected an error for a malformed pair")
+	}
+}
+
+func TestHandleBulkCommandSingle(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	buf := new(bytes.Buffer)
+	if err := handleBulkCommand(context.Background(), c, buf, output.Options{Format: output.Table}, []string{"Tower:All Cameras"}, nil); err != nil {
+		t.Fatalf("handleBulkCommand() error = %v", err)
+	}
+	if buf.String() != "Successfully switched viewport Tower to liveview All Cameras\n" {
+		t.Errorf("Expected the single switch output, got %q", buf.String())
+	}
+}
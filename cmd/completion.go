@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/methridge/protect/internal/cache"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// completionCacheTTL is how long cached names are used before the
+	// console is asked again
+	completionCacheTTL = 5 * time.Minute
+	// completionTimeout bounds how long a tab press may wait for the console
+	completionTimeout = 3 * time.Second
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish>",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for bash, zsh or fish.
+
+Completions include the real viewport, liveview and camera names from your
+console (and their IDs when --show-ids is set). Names are cached for a few
+minutes in the user cache directory, and the cache is used when the console
+cannot be reached.`,
+	Example: `  # bash (requires the bash-completion package)
+  source <(protect completion bash)
+  protect completion bash > /etc/bash_completion.d/protect
+
+  # zsh
+  protect completion zsh > "${fpath[1]}/_protect"
+
+  # fish
+  protect completion fish > ~/.config/fish/completions/protect.fish`,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeCompletion(cmd.Root(), cmd.OutOrStdout(), args[0])
+	},
+}
+
+func init() {
+	// Replace cobra's default completion command with one that also
+	// quotes names containing spaces in bash
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
+
+// bashQuoting overrides the cobra bash helper that inserts completions.
+// Cobra inserts them verbatim, which splits names such as "Front Door" into
+// two words, so quote them unless the user has already opened a quote.
+const bashQuoting = `
+__protect_handle_standard_completion_case() {
+    local tab=$'\t' comp quoted
+    while IFS='' read -r comp; do
+        [[ -z $comp ]] && continue
+        comp=${comp%%$tab*}
+        if [[ $cur == [\"\']* ]]; then
+            [[ $comp == "${cur:1}"* ]] && COMPREPLY+=("$comp")
+        else
+            printf -v quoted '%q' "$comp"
+            [[ $quoted == "$cur"* ]] && COMPREPLY+=("$quoted")
+        fi
+    done < <(printf "%s\n" "${completions[@]}")
+}
+`
+
+// writeCompletion writes the completion script for shell
+func writeCompletion(root *cobra.Command, w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		if err := root.GenBashCompletionV2(w, true); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, bashQuoting)
+		return err
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	default:
+		return fmt.Errorf("unsupported shell: %s (use 'bash', 'zsh' or 'fish')", shell)
+	}
+}
+
+// isCompletionCommand returns true for the completion command and cobra's
+// hidden completion request commands
+func isCompletionCommand(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case completionCmd.Name(), cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
+// completionNames returns resource names for completion, from the cache when
+// it is fresh and from the console otherwise
+func completionNames(cmd *cobra.Command) (*cache.Names, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := cache.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.NewClient(cfg.ProtectURL, cfg.APIToken)
+	c.HTTPClient.Timeout = completionTimeout
+
+	if cfg.Validate() != nil {
+		c = nil
+	}
+
+	return loadCompletionNames(c, path)
+}
+
+// loadCompletionNames returns cached names if they are fresh, otherwise
+// fetches them with c and updates the cache. A stale cache is still used
+// when the console cannot be reached (or c is nil).
+func loadCompletionNames(c *client.Client, path string) (*cache.Names, error) {
+	log := logger.Get()
+
+	cached, cacheErr := cache.Load(path)
+	if c == nil {
+		return cached, cacheErr
+	}
+
+	if cached != nil && cached.Fresh(c.BaseURL, completionCacheTTL) {
+		return cached, nil
+	}
+
+	names, err := fetchNames(c)
+	if err != nil {
+		if cached != nil {
+			log.Debugw("Using stale completion cache", "error", err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if err := cache.Save(path, names); err != nil {
+		log.Debugw("Failed to save completion cache", "error", err)
+	}
+
+	return names, nil
+}
+
+// fetchNames lists viewports, liveviews and cameras from the console
+func fetchNames(c *client.Client) (*cache.Names, error) {
+	viewports, err := c.ListViewports()
+	if err != nil {
+		return nil, err
+	}
+
+	liveviews, err := c.ListCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	cameras, err := c.ListPTZCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	names := &cache.Names{URL: c.BaseURL, UpdatedAt: time.Now()}
+	for _, vp := range viewports {
+		names.Viewports = append(names.Viewports, cache.Entry{ID: vp.ID, Name: vp.Name})
+	}
+	for _, lv := range liveviews {
+		names.Liveviews = append(names.Liveviews, cache.Entry{ID: lv.ID, Name: lv.Name})
+	}
+	for _, cam := range cameras {
+		names.Cameras = append(names.Cameras, cache.Entry{ID: cam.ID, Name: cam.Name})
+	}
+
+	return names, nil
+}
+
+// nameCandidates returns the names (and IDs when showIDs is set) that start
+// with toComplete. IDs carry the name as their description.
+func nameCandidates(entries []cache.Entry, toComplete string, showIDs bool) []string {
+	prefix := strings.TrimLeft(toComplete, `"'`)
+
+	var candidates []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, prefix) {
+			candidates = append(candidates, e.Name)
+		}
+		if showIDs && strings.HasPrefix(e.ID, prefix) {
+			candidates = append(candidates, e.ID+"\t"+e.Name)
+		}
+	}
+	return candidates
+}
+
+// presetCandidates returns the PTZ presets, each prefixed with prefix
+func presetCandidates(prefix string, includeHomeNumber bool) []string {
+	candidates := []string{prefix + "home\thome position"}
+	if includeHomeNumber {
+		candidates = append(candidates, prefix+"-1\thome position")
+	}
+	for i := 0; i <= 9; i++ {
+		candidates = append(candidates, prefix+strconv.Itoa(i)+"\tpreset "+strconv.Itoa(i))
+	}
+	return candidates
+}
+
+// pairCandidates completes the <first>:<second> form used by --switch and
+// --ptz. Before the colon it offers "<name>:"; after it, second(name).
+func pairCandidates(first []cache.Entry, toComplete string, showIDs bool, second func(prefix string) []string) ([]string, cobra.ShellCompDirective) {
+	prefix := strings.TrimLeft(toComplete, `"'`)
+
+	// Only the last of a comma-separated list of pairs is completed
+	var done string
+	if i := strings.LastIndex(prefix, ","); i >= 0 {
+		done, prefix = prefix[:i+1], prefix[i+1:]
+	}
+
+	if i := strings.Index(prefix, ":"); i >= 0 {
+		return second(done + prefix[:i+1]), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	for _, c := range nameCandidates(first, prefix, showIDs) {
+		name, _, _ := strings.Cut(c, "\t")
+		candidates = append(candidates, done+name+":")
+	}
+	return candidates, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// showIDsSet reports whether --show-ids was given on cmd (if it has the flag)
+func showIDsSet(cmd *cobra.Command) bool {
+	showIDs, _ := cmd.Flags().GetBool("show-ids")
+	return showIDs
+}
+
+// completeViewports completes viewport names
+func completeViewports(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := completionNames(cmd)
+	if err != nil || names == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nameCandidates(names.Viewports, toComplete, showIDsSet(cmd)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLiveviews completes liveview names
+func completeLiveviews(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := completionNames(cmd)
+	if err != nil || names == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nameCandidates(names.Liveviews, toComplete, showIDsSet(cmd)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCameras completes camera names
+func completeCameras(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := completionNames(cmd)
+	if err != nil || names == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nameCandidates(names.Cameras, toComplete, showIDsSet(cmd)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSwitch completes --switch=<viewport>:<liveview>
+func completeSwitch(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := completionNames(cmd)
+	if err != nil || names == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	showIDs := showIDsSet(cmd)
+	return pairCandidates(names.Viewports, toComplete, showIDs, func(prefix string) []string {
+		var candidates []string
+		for _, c := range nameCandidates(names.Liveviews, "", showIDs) {
+			candidates = append(candidates, prefix+c)
+		}
+		return candidates
+	})
+}
+
+// completePTZ completes --ptz=<camera>:<preset>
+func completePTZ(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := completionNames(cmd)
+	if err != nil || names == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return pairCandidates(names.Cameras, toComplete, showIDsSet(cmd), func(prefix string) []string {
+		return presetCandidates(prefix, true)
+	})
+}
+
+// completeViewportSwitchArgs completes "viewport switch <viewport> <liveview>"
+func completeViewportSwitchArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeViewports(cmd, args, toComplete)
+	case 1:
+		return completeLiveviews(cmd, args, toComplete)
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completePTZGotoArgs completes "ptz goto <camera> <preset>"
+func completePTZGotoArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeCameras(cmd, args, toComplete)
+	case 1:
+		// -1 would be parsed as a flag here, so only offer "home"
+		return presetCandidates("", false), cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeSingleCamera completes commands that take one camera argument
+func completeSingleCamera(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeCameras(cmd, args, toComplete)
+}
+
+// completePresetFlag completes --preset values
+func completePresetFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	candidates := []string{"-1\thome position"}
+	for i := 0; i <= 9; i++ {
+		candidates = append(candidates, strconv.Itoa(i)+"\tpreset "+strconv.Itoa(i))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/cache"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/protecttest"
+	"github.com/spf13/cobra"
+)
+
+// newNamesServer serves viewers, liveviews and cameras
+func newNamesServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Driveway"}]`)
+	return server
+}
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := writeCompletion(rootCmd, buf, shell); err != nil {
+				t.Fatalf("writeCompletion() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), "__complete") {
+				t.Errorf("Expected %s script to call __complete", shell)
+			}
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeCompletion(rootCmd, buf, "bash"); err != nil {
+		t.Fatalf("writeCompletion() error = %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), bashQuoting) {
+		t.Error("Expected bash script to end with the quoting override")
+	}
+
+	if err := writeCompletion(rootCmd, new(bytes.Buffer), "tcsh"); err == nil {
+		t.Error("Expected error for unsupported shell")
+	}
+}
+
+func TestCompletionCommandWithoutConfig(t *testing.T) {
+	t.Setenv("PROTECT_PROTECT_URL", "")
+	t.Setenv("PROTECT_API_TOKEN", "")
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"completion", "zsh"})
+	defer rootCmd.SetArgs([]string{})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected completion to work without configuration, got: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "#compdef protect") {
+		t.Error("Expected zsh completion script")
+	}
+}
+
+func TestLoadCompletionNames(t *testing.T) {
+	server := newNamesServer(t)
+	path := filepath.Join(t.TempDir(), "names.json")
+	c := client.NewClient(server.URL, "test-token")
+
+	names, err := loadCompletionNames(c, path)
+	if err != nil {
+		t.Fatalf("loadCompletionNames() error = %v", err)
+	}
+	if len(names.Cameras) != 2 || names.Viewports[0].Name != "Tower" {
+		t.Errorf("Unexpected names: %+v", names)
+	}
+
+	// A second lookup within the TTL is served from the cache
+	fetched := server.Lookups()
+	if _, err := loadCompletionNames(c, path); err != nil {
+		t.Fatalf("loadCompletionNames() error = %v", err)
+	}
+	if n := server.Lookups(); n != fetched {
+		t.Errorf("Expected cached names to be used, got %d extra requests", n-fetched)
+	}
+
+	// A stale cache is still used when the console is unreachable
+	stale, _ := cache.Load(path)
+	stale.UpdatedAt = time.Now().Add(-time.Hour)
+	cache.Save(path, stale)
+	server.Close()
+
+	names, err = loadCompletionNames(c, path)
+	if err != nil {
+		t.Fatalf("Expected stale cache fallback, got error: %v", err)
+	}
+	if len(names.Liveviews) != 2 {
+		t.Errorf("Unexpected names from stale cache: %+v", names)
+	}
+
+	// Without a client only the cache is consulted
+	if names, err := loadCompletionNames(nil, path); err != nil || names == nil {
+		t.Errorf("Expected cached names without a client, got %v (%v)", names, err)
+	}
+}
+
+func TestNameCandidates(t *testing.T) {
+	entries := []cache.Entry{{ID: "cam1", Name: "Front Door"}, {ID: "cam2", Name: "Driveway"}}
+
+	if got := nameCandidates(entries, "Fr", false); !reflect.DeepEqual(got, []string{"Front Door"}) {
+		t.Errorf("nameCandidates() = %v", got)
+	}
+
+	if got := nameCandidates(entries, `"Fr`, false); !reflect.DeepEqual(got, []string{"Front Door"}) {
+		t.Errorf("nameCandidates() with open quote = %v", got)
+	}
+
+	if got := nameCandidates(entries, "cam", true); !reflect.DeepEqual(got, []string{"cam1\tFront Door", "cam2\tDriveway"}) {
+		t.Errorf("nameCandidates() with IDs = %v", got)
+	}
+}
+
+func TestPairCandidates(t *testing.T) {
+	cameras := []cache.Entry{{ID: "cam1", Name: "Front Door"}}
+	presets := func(prefix string) []string { return presetCandidates(prefix, true) }
+
+	got, directive := pairCandidates(cameras, "Fro", false, presets)
+	if !reflect.DeepEqual(got, []string{"Front Door:"}) {
+		t.Errorf("pairCandidates() = %v", got)
+	}
+	if directive&cobra.ShellCompDirectiveNoSpace == 0 {
+		t.Error("Expected no space after the camera name")
+	}
+
+	got, _ = pairCandidates(cameras, "Front Door:", false, presets)
+	if len(got) != 12 || got[0] != "Front Door:home\thome position" || got[1] != "Front Door:-1\thome position" {
+		t.Errorf("pairCandidates() presets = %v", got)
+	}
+
+	// Only the last pair of a comma-separated list is completed
+	got, _ = pairCandidates(cameras, "Front Door:5,Fro", false, presets)
+	if !reflect.DeepEqual(got, []string{"Front Door:5,Front Door:"}) {
+		t.Errorf("pairCandidates() after a comma = %v", got)
+	}
+}
+
+func TestCompleteRequest(t *testing.T) {
+	server := newNamesServer(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "--url=" + server.URL, "--token=test-token", "ptz", "goto", "Fr"})
+	defer func() {
+		rootCmd.SetArgs([]string{})
+		rootCmd.PersistentFlags().Set("url", "")
+		rootCmd.PersistentFlags().Set("token", "")
+	}()
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Completion request error = %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "Front Door\n") {
+		t.Errorf("Expected 'Front Door' completion, got %q", buf.String())
+	}
+}
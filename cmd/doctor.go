@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/doctor"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration and the connection to the console",
+	Long: `Check the configuration and the connection to UniFi Protect step by step,
+printing pass or fail with a suggested fix for each:
+
+  Config file     Which config.yaml was read, and where it was searched for
+  Overrides       Settings overridden by PROTECT_ environment variables or flags
+  Settings        protect_url and api_token are set
+  URL             protect_url is an https:// address without a path
+  DNS, TCP        The console's name resolves and its port accepts connections
+  TLS             The certificate is trusted, matches the host and is not expiring
+  API key         The console accepts the API key
+  API version     The UniFi Protect version
+  Read            The API key can read viewers and cameras
+  Patch           The API key can change viewers and cameras (--check-writes)
+
+The patch checks write to the console: they re-apply a viewer's current
+liveview and a camera's current name, reading each again just before so a
+change made in the meantime is not reverted. They only run with
+--check-writes, and --dry-run skips them. The command exits non-zero if any
+check fails.`,
+	Example: `  protect doctor
+  protect doctor --check-writes
+  protect doctor --output=json`,
+	Args: cobra.NoArgs,
+	// A failed check is not a usage error
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		in := doctor.Options{
+			SearchPaths:  config.SearchPaths(),
+			EnvOverrides: config.EnvOverrides(),
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			in.ConfigError = err
+		} else {
+			in.URL = cfg.ProtectURL
+			in.Token = cfg.APIToken
+		}
+		in.ConfigFile = config.FileUsed()
+		for _, flag := range []string{"url", "token"} {
+			if cmd.Flags().Changed(flag) {
+				in.FlagOverrides = append(in.FlagOverrides, flag)
+			}
+		}
+		in.CheckWrites, _ = cmd.Flags().GetBool("check-writes")
+		in.DryRun, _ = cmd.Flags().GetBool("dry-run")
+		in.Client = client.NewClient(in.URL, in.Token)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runDoctor(ctx, cmd.OutOrStdout(), opts, in)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().Bool("check-writes", false, "Also check that the API key can change viewers and cameras (writes to the console)")
+
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctor runs the checks and prints them, failing if any check failed
+func runDoctor(ctx context.Context, out io.Writer, opts output.Options, in doctor.Options) error {
+	checks := doctor.Run(ctx, in)
+
+	if err := writeChecks(out, opts, checks); err != nil {
+		return err
+	}
+
+	if failed := doctor.Failed(checks); failed > 0 {
+		err := fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		if opts.Structured() {
+			return reportedError{err}
+		}
+		return err
+	}
+	return nil
+}
+
+// writeChecks prints checks as a table with fixes under failures, or in a
+// structured format
+func writeChecks(out io.Writer, opts output.Options, checks []doctor.Check) error {
+	if opts.Structured() {
+		listing := output.Listing{
+			Items:   checks,
+			Headers: []string{"name", "status", "detail", "fix"},
+		}
+		for _, c := range checks {
+			listing.Rows = append(listing.Rows, []string{c.Name, string(c.Status), c.Detail, c.Fix})
+			listing.Names = append(listing.Names, c.Name)
+		}
+		return output.Write(out, opts, listing)
+	}
+
+	width := 0
+	for _, c := range checks {
+		width = max(width, len(c.Name))
+	}
+
+	for _, c := range checks {
+		fmt.Fprintf(out, "%-4s  %-*s  %s\n", strings.ToUpper(string(c.Status)), width, c.Name, c.Detail)
+		if c.Fix != "" {
+			fmt.Fprintf(out, "%-4s  %-*s  Fix: %s\n", "", width, "", c.Fix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/doctor"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestRunDoctor(t *testing.T) {
+	in := doctor.Options{SearchPaths: []string{"/home/me/.config/protect"}, URL: "192.168.1.1", Token: "token"}
+
+	buf := new(bytes.Buffer)
+	err := runDoctor(context.Background(), buf, output.Options{Format: output.Table}, in)
+	if err == nil || errors.As(err, new(reportedError)) {
+		t.Fatalf("Expected a plain error for a failed check, got %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"WARN  Config file    no config.yaml found in /home/me/.config/protect\n",
+		"FAIL  URL            \"192.168.1.1\" has no scheme\n",
+		"                     Fix: set protect_url to https://192.168.1.1\n",
+		"SKIP  API key        skipped: protect_url is invalid\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	err = runDoctor(context.Background(), buf, output.Options{Format: output.JSON}, in)
+	if !errors.As(err, new(reportedError)) {
+		t.Errorf("Expected the failure to be reported in the JSON output, got %v", err)
+	}
+
+	var checks []doctor.Check
+	if err := json.Unmarshal(buf.Bytes(), &checks); err != nil {
+		t.Fatalf("Expected a JSON array, got %q: %v", buf.String(), err)
+	}
+	if len(checks) == 0 || checks[3].Name != "URL" || checks[3].Status != doctor.Fail {
+		t.Errorf("Unexpected checks: %+v", checks)
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Stream UniFi Protect events",
+	Long: `Stream motion, ring, smart detection and sensor events from UniFi Protect.
+
+Events are printed as a table, or as newline-delimited JSON (NDJSON) with
+--output=json.`,
+	Example: `  protect events --follow
+  protect events --follow --output=json
+  protect events --follow --camera="Front Door" --type=ring,smart`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		follow, _ := cmd.Flags().GetBool("follow")
+		cameraArgs, _ := cmd.Flags().GetStringSlice("camera")
+		typeArgs, _ := cmd.Flags().GetStringSlice("type")
+
+		if !follow {
+			return fmt.Errorf("--follow is required: the events API only supports live streaming")
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if opts.Template != "" || (opts.Format != output.Table && opts.Format != output.JSON) {
+			return fmt.Errorf("events support only --output=table or --output=json")
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		cameras, err := c.ListPTZCameras()
+		if err != nil {
+			return err
+		}
+
+		filter, err := newEventFilter(cameras, cameraArgs, typeArgs)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return followEvents(ctx, c, cmd.OutOrStdout(), cmd.ErrOrStderr(), filter, opts, cameraNames(cameras))
+	},
+}
+
+func init() {
+	eventsCmd.Flags().BoolP("follow", "f", false, "Keep the connection open and print events as they arrive")
+	eventsCmd.Flags().StringSlice("camera", nil, "Only show events from these cameras (use --camera=<name or ID>)")
+	eventsCmd.Flags().StringSlice("type", nil, "Only show these event types (use --type=<value>: e.g. 'motion', 'ring', 'smart', 'sensor')")
+	eventsCmd.RegisterFlagCompletionFunc("camera", completeCameras)
+
+	rootCmd.AddCommand(eventsCmd)
+}
+
+// eventFilter selects which events are printed
+type eventFilter struct {
+	devices map[string]bool
+	types   map[client.EventType]bool
+	smart   bool
+	sensor  bool
+}
+
+// newEventFilter resolves camera names and event type arguments
+func newEventFilter(cameras []client.PTZCamera, cameraArgs, typeArgs []string) (*eventFilter, error) {
+	f := &eventFilter{}
+
+	if len(cameraArgs) > 0 {
+		f.devices = make(map[string]bool)
+		for _, arg := range cameraArgs {
+			found := false
+			for _, cam := range cameras {
+				if cam.ID == arg || cam.Name == arg {
+					f.devices[cam.ID] = true
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("camera not found: %s", arg)
+			}
+		}
+	}
+
+	if len(typeArgs) > 0 {
+		f.types = make(map[client.EventType]bool)
+		for _, arg := range typeArgs {
+			switch strings.TrimSpace(arg) {
+			case "smart":
+				f.smart = true
+			case "sensor":
+				f.sensor = true
+			default:
+				t := client.EventType(strings.TrimSpace(arg))
+				if !slices.Contains(client.EventTypes, t) {
+					return nil, fmt.Errorf("unknown event type: %s (valid: %s)", arg, strings.Join(eventTypeNames(), ", "))
+				}
+				f.types[t] = true
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// eventTypeNames lists the values accepted by --type
+func eventTypeNames() []string {
+	names := []string{"smart", "sensor"}
+	for _, t := range client.EventTypes {
+		names = append(names, string(t))
+	}
+	return names
+}
+
+// Match returns true if the event passes the filter
+func (f *eventFilter) Match(e client.Event) bool {
+	if f.devices != nil && !f.devices[e.Device] {
+		return false
+	}
+
+	if f.types == nil {
+		return true
+	}
+
+	return f.types[e.Type] || (f.smart && e.Type.IsSmartDetect()) || (f.sensor && e.Type.IsSensor())
+}
+
+// cameraNames maps camera IDs to display names
+func cameraNames(cameras []client.PTZCamera) map[string]string {
+	names := make(map[string]string, len(cameras))
+	for _, cam := range cameras {
+		names[cam.ID] = cam.Name
+	}
+	return names
+}
+
+// followEvents prints matching events to w until ctx is cancelled or the
+// subscription ends. Stream errors are reported on errOut.
+func followEvents(ctx context.Context, c *client.Client, w, errOut io.Writer, filter *eventFilter, opts output.Options, names map[string]string) error {
+	log := logger.Get()
+
+	sub := c.SubscribeEvents(ctx)
+	errs := sub.Errors
+	enc := json.NewEncoder(w)
+
+	if opts.Format == output.Table && !opts.NoHeaders {
+		fmt.Fprintf(w, "%-19s  %-20s  %-24s  %s\n", "TIME", "TYPE", "DEVICE", "DETAILS")
+	}
+
+	var lastErr error
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if errs != nil {
+					for err := range errs {
+						lastErr = err
+					}
+				}
+				return lastErr
+			}
+
+			if !filter.Match(event) {
+				continue
+			}
+
+			if opts.Format == output.JSON {
+				if err := enc.Encode(event); err != nil {
+					return fmt.Errorf("failed to write event: %w", err)
+				}
+				continue
+			}
+
+			writeEventRow(w, event, names)
+
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			lastErr = err
+			log.Warnw("Event stream error", "error", err)
+			fmt.Fprintf(errOut, "event stream: %v\n", err)
+		}
+	}
+}
+
+// writeEventRow prints a single event as a table row
+func writeEventRow(w io.Writer, e client.Event, names map[string]string) {
+	timestamp := "-"
+	if t := e.StartTime(); !t.IsZero() {
+		timestamp = t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	device := names[e.Device]
+	if device == "" {
+		device = e.Device
+	}
+
+	details := e.Action
+	if len(e.SmartDetectTypes) > 0 {
+		details = strings.Join(e.SmartDetectTypes, ",")
+	}
+
+	fmt.Fprintf(w, "%-19s  %-20s  %-24s  %s\n", timestamp, e.Type, device, details)
+}
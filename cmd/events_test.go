@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestEventsCommandFlags(t *testing.T) {
+	flags := eventsCmd.Flags()
+
+	for _, name := range []string{"follow", "camera", "type"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected '%s' flag to be registered", name)
+		}
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	cameras := []client.PTZCamera{
+		{ID: "cam1", Name: "Front Door"},
+		{ID: "cam2", Name: "Driveway"},
+	}
+
+	tests := []struct {
+		name       string
+		cameraArgs []string
+		typeArgs   []string
+		event      client.Event
+		want       bool
+	}{
+		{
+			name:  "No filters match everything",
+			event: client.Event{Type: client.EventMotion, Device: "cam2"},
+			want:  true,
+		},
+		{
+			name:       "Camera name filter matches",
+			cameraArgs: []string{"Front Door"},
+			event:      client.Event{Type: client.EventRing, Device: "cam1"},
+			want:       true,
+		},
+		{
+			name:       "Camera ID filter rejects other cameras",
+			cameraArgs: []string{"cam1"},
+			event:      client.Event{Type: client.EventRing, Device: "cam2"},
+			want:       false,
+		},
+		{
+			name:     "Type filter matches",
+			typeArgs: []string{"ring"},
+			event:    client.Event{Type: client.EventRing, Device: "cam1"},
+			want:     true,
+		},
+		{
+			name:     "Type filter rejects other types",
+			typeArgs: []string{"ring"},
+			event:    client.Event{Type: client.EventMotion, Device: "cam1"},
+			want:     false,
+		},
+		{
+			name:     "Smart alias matches smart detections",
+			typeArgs: []string{"smart"},
+			event:    client.Event{Type: client.EventSmartDetectLine, Device: "cam1"},
+			want:     true,
+		},
+		{
+			name:     "Sensor alias matches sensor events",
+			typeArgs: []string{"sensor"},
+			event:    client.Event{Type: client.EventSensorOpened, Device: "sensor1"},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newEventFilter(cameras, tt.cameraArgs, tt.typeArgs)
+			if err != nil {
+				t.Fatalf("newEventFilter() error = %v", err)
+			}
+
+			if got := filter.Match(tt.event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newEventFilter(cameras, []string{"Missing"}, nil); err == nil {
+		t.Error("Expected error for unknown camera")
+	}
+
+	if _, err := newEventFilter(cameras, nil, []string{"ring", "rnig"}); err == nil || !strings.Contains(err.Error(), "unknown event type: rnig") || !strings.Contains(err.Error(), "smartDetectZone") {
+		t.Errorf("Expected an unknown event type error listing the valid types, got %v", err)
+	}
+}
+
+func TestWriteEventRow(t *testing.T) {
+	buf := new(bytes.Buffer)
+	names := map[string]string{"cam1": "Front Door"}
+
+	writeEventRow(buf, client.Event{
+		Action:           "add",
+		Type:             client.EventSmartDetectZone,
+		Device:           "cam1",
+		SmartDetectTypes: []string{"person", "vehicle"},
+	}, names)
+
+	output := buf.String()
+	if !strings.Contains(output, "Front Door") {
+		t.Errorf("Expected row to contain camera name, got '%s'", output)
+	}
+
+	if !strings.Contains(output, "person,vehicle") {
+		t.Errorf("Expected row to contain smart detect types, got '%s'", output)
+	}
+}
+
+// signalWriter collects writes and signals the first one
+type signalWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	written chan struct{}
+}
+
+func (w *signalWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		close(w.written)
+	}
+	return w.buf.Write(p)
+}
+
+func TestFollowEventsReportsStreamErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errOut := &signalWriter{written: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() {
+		done <- followEvents(ctx, client.NewClient(server.URL, "test-token"), new(bytes.Buffer), errOut, &eventFilter{}, output.Options{Format: output.Table}, nil)
+	}()
+
+	select {
+	case <-errOut.written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a stream error")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("followEvents() error = %v", err)
+	}
+
+	errOut.mu.Lock()
+	defer errOut.mu.Unlock()
+	if !strings.HasPrefix(errOut.buf.String(), "event stream: ") {
+		t.Errorf("Unexpected error output: %q", errOut.buf.String())
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/exporter"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve Prometheus metrics for viewers, cameras, sensors and lights",
+	Long: `Serve Prometheus metrics on /metrics until interrupted.
+
+Device state comes from the console's devices WebSocket, so scrapes never
+wait on the API. Metrics include each viewer's connection state and current
+liveview, camera and floodlight connection state, floodlight on/off, sensor
+battery, temperature, humidity and light readings, and the latency and error
+counts of the exporter's own API requests. protect_up drops to 0 while the
+devices stream or the console is unavailable.`,
+	Example: `  protect exporter --listen=:9787
+  curl -s localhost:9787/metrics | grep protect_viewer_online`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", listen, err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runExporter(ctx, c, cmd.OutOrStdout(), ln)
+	},
+}
+
+func init() {
+	exporterCmd.Flags().String("listen", ":9787", "Address to serve metrics on (use --listen=<host:port>)")
+
+	rootCmd.AddCommand(exporterCmd)
+}
+
+// runExporter serves metrics on ln while keeping the inventory current. If
+// the devices stream fails for good after the first load, the server stops
+// and the error is returned.
+func runExporter(ctx context.Context, c *client.Client, out io.Writer, ln net.Listener) error {
+	log := logger.Get()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	exp := exporter.New()
+	streamErr := make(chan error, 1)
+	go func() {
+		err := exp.Run(ctx, c, func(err error) {
+			log.Warnw("Device stream error", "error", err)
+		})
+		if ctx.Err() == nil {
+			streamErr <- err
+			cancel()
+		}
+	}()
+
+	if err := runServer(ctx, out, ln, exp.Handler(), config.Serve{}); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-streamErr:
+		return fmt.Errorf("device stream closed: %w", err)
+	default:
+		return nil
+	}
+}
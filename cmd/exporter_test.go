@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+)
+
+func TestRunExporterServesWhileConsoleDown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	c := client.NewClient(server.URL, "test-token")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runExporter(ctx, c, new(bytes.Buffer), ln) }()
+
+	// A failed first load is retried rather than stopping the exporter
+	time.Sleep(100 * time.Millisecond)
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "protect_up 0\n") {
+		t.Errorf("Expected protect_up 0, got:\n%s", body)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runExporter() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runExporter() did not return")
+	}
+}
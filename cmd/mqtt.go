@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/mqtt"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var mqttCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Bridge viewers and PTZ cameras to an MQTT broker",
+	Long: `Connect to an MQTT broker, publish each viewer's current liveview and
+carry out switch and PTZ commands, until interrupted.
+
+Topics (with the default --topic=protect):
+  protect/status                     online/offline (retained; offline is the last will)
+  protect/viewer/<id>/liveview       Current liveview name (retained)
+  protect/viewer/<id>/set            Switch to the liveview name or ID in the payload
+  protect/camera/<id>/ptz            Move to the preset in the payload (0-9 or "home")
+
+Home Assistant discovery messages create a select entity per viewer, with the
+liveviews as options, and a button per PTZ preset. The bridge reconnects to
+the broker automatically and republishes everything when it does.
+
+Commands are printed as text, or as newline-delimited JSON (NDJSON) with
+--output=json.`,
+	Example: `  protect mqtt --broker=tcp://localhost:1883
+  protect mqtt --broker=ssl://broker.example.com:8883 --username=protect --password="$MQTT_PASSWORD"
+  mosquitto_pub -t protect/viewer/<id>/set -m Driveway`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if opts.Template != "" || (opts.Format != output.Table && opts.Format != output.JSON) {
+			return fmt.Errorf("mqtt supports only --output=table or --output=json")
+		}
+
+		settings := mqttSettings(cmd, config.Get().MQTT)
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		bridge, err := mqtt.New(c, settings)
+		if err != nil {
+			return fmt.Errorf("%w (use --broker=<url> or mqtt.broker in the config file)", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runMQTT(ctx, cmd.OutOrStdout(), opts, bridge, settings.Broker)
+	},
+}
+
+func init() {
+	mqttCmd.Flags().String("broker", "", "Broker URL (use --broker=<tcp|ssl|ws|wss>://<host>:<port>)")
+	mqttCmd.Flags().String("username", "", "Broker username (use --username=<value>)")
+	mqttCmd.Flags().String("password", "", "Broker password (use --password=<value>)")
+	mqttCmd.Flags().String("client-id", "", "MQTT client ID (use --client-id=<value>; default protect-<hostname>)")
+	mqttCmd.Flags().String("topic", "", "Topic prefix (use --topic=<value>; default "+mqtt.DefaultTopic+")")
+	mqttCmd.Flags().String("discovery-prefix", "", "Home Assistant discovery prefix (use --discovery-prefix=<value>; default "+mqtt.DefaultDiscoveryPrefix+")")
+
+	rootCmd.AddCommand(mqttCmd)
+}
+
+// mqttSettings merges the command-line flags over the configuration file
+func mqttSettings(cmd *cobra.Command, settings config.MQTT) config.MQTT {
+	for flag, field := range map[string]*string{
+		"broker":           &settings.Broker,
+		"username":         &settings.Username,
+		"password":         &settings.Password,
+		"client-id":        &settings.ClientID,
+		"topic":            &settings.Topic,
+		"discovery-prefix": &settings.DiscoveryPrefix,
+	} {
+		if value, _ := cmd.Flags().GetString(flag); value != "" {
+			*field = value
+		}
+	}
+	return settings
+}
+
+// runMQTT runs the bridge, printing each command it carries out
+func runMQTT(ctx context.Context, out io.Writer, opts output.Options, bridge *mqtt.Bridge, broker string) error {
+	enc := json.NewEncoder(out)
+
+	if opts.Format == output.Table {
+		fmt.Fprintf(out, "Bridging to %s\n", broker)
+	}
+
+	// Commands are handled concurrently
+	var mu sync.Mutex
+	bridge.OnCommand = func(c mqtt.Command) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if opts.Format == output.JSON {
+			enc.Encode(c)
+			return
+		}
+
+		status := "ok"
+		switch {
+		case c.Error != "":
+			status = "failed: " + c.Error
+		case c.Result != nil && c.Result.DryRun:
+			status = "dry run"
+		}
+		fmt.Fprintf(out, "%s  %s  %s  %s\n", time.Now().Format("2006-01-02 15:04:05"), c.Topic, c.Payload, status)
+	}
+
+	return bridge.Run(ctx)
+}
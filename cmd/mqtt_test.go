@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/methridge/protect/internal/config"
+)
+
+func TestMQTTSettings(t *testing.T) {
+	t.Cleanup(func() {
+		mqttCmd.Flags().Set("broker", "")
+		mqttCmd.Flags().Set("topic", "")
+	})
+
+	fromConfig := config.MQTT{Broker: "tcp://config:1883", Username: "protect", Topic: "cctv", Presets: []string{"home"}}
+
+	got := mqttSettings(mqttCmd, fromConfig)
+	if got.Broker != "tcp://config:1883" || got.Username != "protect" || got.Topic != "cctv" || len(got.Presets) != 1 {
+		t.Errorf("Expected the config values, got %+v", got)
+	}
+
+	mqttCmd.Flags().Set("broker", "tcp://flag:1883")
+	mqttCmd.Flags().Set("topic", "home/protect")
+	got = mqttSettings(mqttCmd, fromConfig)
+	if got.Broker != "tcp://flag:1883" || got.Topic != "home/protect" || got.Username != "protect" {
+		t.Errorf("Expected the flags to win, got %+v", got)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"github.com/methridge/protect/internal/actions"
+	"github.com/spf13/cobra"
+)
+
+var ptzCmd = &cobra.Command{
+	Use:   "ptz",
+	Short: "List and move PTZ cameras",
+	Long:  `List PTZ cameras and move them to their home position or saved presets.`,
+	Args:  cobra.NoArgs,
+}
+
+var ptzListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List PTZ cameras",
+	Long: `List PTZ cameras.
+
+Equivalent to the legacy --list=cameras flag.`,
+	Example: `  protect ptz list
+  protect ptz list --show-ids
+  protect ptz list --watch --output=json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showIDs, _ := cmd.Flags().GetBool("show-ids")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchCommand(cmd, c, opts, "cameras", showIDs)
+		}
+
+		return listCameras(c, cmd.OutOrStdout(), opts, showIDs)
+	},
+}
+
+var ptzGotoCmd = &cobra.Command{
+	Use:   "goto <camera> <preset>",
+	Short: "Move a PTZ camera to a preset",
+	Long: `Move a PTZ camera to a preset. The camera may be given by name or ID.
+
+Presets are 0-9; "home" moves the camera to its home position (use "-- -1"
+to pass -1 as an argument). The single-argument <camera>:<preset> form is also
+accepted, matching the legacy --ptz flag.`,
+	Example: `  protect ptz goto "Front Door" 5
+  protect ptz goto Driveway home
+  protect ptz goto "Front Door:5"`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completePTZGotoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			return handlePTZCommand(c, cmd.OutOrStdout(), opts, args[0])
+		}
+
+		preset, err := actions.ParsePreset(args[1])
+		if err != nil {
+			return err
+		}
+
+		return handleCameraOperation(c, cmd.OutOrStdout(), opts, args[0], preset)
+	},
+}
+
+var ptzHomeCmd = &cobra.Command{
+	Use:   "home <camera>",
+	Short: "Move a PTZ camera to its home position",
+	Long: `Move a PTZ camera to its home position. The camera may be given by name or ID.
+
+Equivalent to "protect ptz goto <camera> -1".`,
+	Example:           `  protect ptz home "Front Door"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingleCamera,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		return handleCameraOperation(c, cmd.OutOrStdout(), opts, args[0], -1)
+	},
+}
+
+func init() {
+	ptzListCmd.Flags().Bool("show-ids", false, "Show IDs when listing")
+	addWatchFlags(ptzListCmd)
+
+	ptzCmd.AddCommand(ptzListCmd, ptzGotoCmd, ptzHomeCmd)
+	rootCmd.AddCommand(ptzCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestPTZCommands(t *testing.T) {
+	tests := []struct {
+		path    []string
+		args    []string
+		wantErr bool
+	}{
+		{path: []string{"ptz", "goto"}, args: []string{"Front Door", "5"}},
+		{path: []string{"ptz", "goto"}, args: []string{"Front Door:5"}},
+		{path: []string{"ptz", "goto"}, args: []string{"a", "b", "c"}, wantErr: true},
+		{path: []string{"ptz", "home"}, args: []string{"Front Door"}},
+		{path: []string{"ptz", "home"}, args: []string{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		cmd, _, err := rootCmd.Find(tt.path)
+		if err != nil {
+			t.Fatalf("Expected command %v to be registered: %v", tt.path, err)
+		}
+
+		if err := cmd.Args(cmd, tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("%v args %v: error = %v, wantErr %v", tt.path, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLegacyFlagsStillRegistered(t *testing.T) {
+	// Automation platforms depend on the single-argument flags
+	for _, name := range []string{"switch", "ptz", "list", "tui"} {
+		if rootCmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected legacy '%s' flag to remain registered", name)
+		}
+	}
+}
@@ -1,15 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
 
+	"github.com/methridge/protect/internal/actions"
 	"github.com/methridge/protect/internal/client"
 	"github.com/methridge/protect/internal/config"
 	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
 	"github.com/methridge/protect/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -24,8 +31,12 @@ var (
 var rootCmd = &cobra.Command{
 	Use:   "protect",
 	Short: "UniFi Protect View Switcher",
-	Long:  `A TUI tool for switching between different camera views and viewports in UniFi Protect.`,
-	Args:  cobra.NoArgs, // Disallow positional arguments
+	Long: `A TUI tool for switching between different camera views and viewports in UniFi Protect.
+
+Use the commands below (e.g. "protect viewport switch") or the single-argument
+flags (e.g. --switch=<viewport>:<liveview>), which remain supported for
+automation platforms.`,
+	Args: cobra.NoArgs, // Disallow positional arguments
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Check for version flag
 		showVersion, _ := cmd.Flags().GetBool("version")
@@ -40,16 +51,20 @@ var rootCmd = &cobra.Command{
 			return err
 		}
 
-		// Check for combined switch flag (single argument for automation)
-		switchArg, _ := cmd.Flags().GetString("switch")
-		if switchArg != "" {
-			return handleSwitchCommand(c, switchArg)
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
 		}
-
-		// Check for combined PTZ flag (single argument for automation)
-		ptzArg, _ := cmd.Flags().GetString("ptz")
-		if ptzArg != "" {
-			return handlePTZCommand(c, ptzArg)
+		out := cmd.OutOrStdout()
+
+		// Check for combined switch and PTZ flags (single arguments for
+		// automation, repeatable or comma-separated for bulk changes)
+		switchArgs, _ := cmd.Flags().GetStringArray("switch")
+		ptzArgs, _ := cmd.Flags().GetStringArray("ptz")
+		if len(switchArgs) > 0 || len(ptzArgs) > 0 {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return handleBulkCommand(ctx, c, out, opts, switchArgs, ptzArgs)
 		}
 
 		// Check for flag-based operations
@@ -63,67 +78,105 @@ var rootCmd = &cobra.Command{
 
 		// Handle TUI launch
 		if launchTUI {
-			return tui.Run(c)
+			return tui.Run(c, config.Get().Scenes)
 		}
 
 		// Handle list operations
 		if listMode != "" {
-			return handleListOperation(c, listMode, showIDs)
+			if watch, _ := cmd.Flags().GetBool("watch"); watch {
+				return watchCommand(cmd, c, opts, listMode, showIDs)
+			}
+			return handleListOperation(c, out, opts, listMode, showIDs)
 		}
 
 		// Handle viewport switching
 		if viewport != "" && liveview != "" {
-			return handleViewportSwitch(c, viewport, liveview)
+			return handleViewportSwitch(c, out, opts, viewport, liveview)
 		}
 
 		// Handle camera PTZ operations
 		if camera != "" {
-			return handleCameraOperation(c, camera, preset)
+			return handleCameraOperation(c, out, opts, camera, preset)
 		}
 
 		// If no flags specified, show help
 		return cmd.Help()
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Load configuration
-		cfg, err := config.Load()
+		cfg, err := loadConfig(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load configuration: %w", err)
+			// Doctor reports an unreadable config file as one of its checks
+			if cmd == doctorCmd {
+				return nil
+			}
+			return err
+		}
+
+		// Set log level
+		if err := logger.SetLevel(cfg.LogLevel); err != nil {
+			return fmt.Errorf("failed to set log level: %w", err)
 		}
 
-		// Override config with flags if provided
-		protectURL, _ := cmd.Flags().GetString("url")
-		if protectURL != "" {
-			cfg.ProtectURL = protectURL
+		// Completion scripts and completion requests must work before the
+		// tool is configured; completers fall back to the name cache instead
+		if isCompletionCommand(cmd) {
+			return nil
 		}
 
-		apiToken, _ := cmd.Flags().GetString("token")
-		if apiToken != "" {
-			cfg.APIToken = apiToken
+		// Doctor diagnoses invalid configuration itself
+		if cmd == doctorCmd {
+			return nil
 		}
 
-		logLevel, _ := cmd.Flags().GetString("log-level")
-		if logLevel != "" {
-			cfg.LogLevel = logLevel
+		// Validate the connection settings; other sections are checked by
+		// the commands that use them
+		if err := validateConfig(cfg.Validate); err != nil {
+			return err
 		}
 
-		// Set log level
-		if err := logger.SetLevel(cfg.LogLevel); err != nil {
-			return fmt.Errorf("failed to set log level: %w", err)
+		// Reject unknown output formats before doing any work
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
 		}
 
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			return fmt.Errorf("invalid configuration: %w", err)
+		// Dry-run requests go to stderr when stdout carries structured output
+		dryRun, _ = cmd.Flags().GetBool("dry-run")
+		dryRunOutput = cmd.OutOrStdout()
+		if opts.Structured() {
+			dryRunOutput = cmd.ErrOrStderr()
 		}
 
 		return nil
 	},
 }
 
+// dryRun and dryRunOutput are set from --dry-run for clients made by getClient
+var (
+	dryRun       bool
+	dryRunOutput io.Writer
+)
+
+// reportedError is returned when a failure has already been written in the
+// requested output format, so Execute does not write it again
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string { return e.err.Error() }
+
+func (e reportedError) Unwrap() error { return e.err }
+
 // Execute runs the root command
 func Execute() error {
-	return rootCmd.Execute()
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil && !errors.As(err, new(reportedError)) {
+		// Give automation a parseable error when structured output was requested
+		if opts, optsErr := getOutputOptions(cmd); optsErr == nil && opts.Structured() {
+			output.WriteError(cmd.OutOrStdout(), opts, err)
+		}
+	}
+	return err
 }
 
 func init() {
@@ -131,17 +184,22 @@ func init() {
 	rootCmd.PersistentFlags().StringP("url", "u", "", "UniFi Protect URL (use --url=<value>)")
 	rootCmd.PersistentFlags().StringP("token", "t", "", "API token for authentication (use --token=<value>)")
 	rootCmd.PersistentFlags().StringP("log-level", "l", "none", "Log level (use --log-level=<value>)")
+	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (use --output=<value>: 'table', 'json', 'yaml', 'csv', 'tsv' or 'name')")
+	rootCmd.PersistentFlags().String("template", "", "Render each item with a Go text/template (use --template=<value>, e.g. '{{.Name}}')")
+	rootCmd.PersistentFlags().Bool("no-headers", false, "Omit column headers from table, CSV and TSV output")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Look everything up but print changes instead of sending them")
 
 	// Flag-based options (use equal sign format: --flag=value)
 	rootCmd.Flags().BoolP("tui", "i", false, "Launch interactive TUI")
-	rootCmd.Flags().StringP("switch", "s", "", "Switch viewport to liveview (use --switch=<viewport>:<liveview>)")
-	rootCmd.Flags().String("ptz", "", "Move PTZ camera to preset (use --ptz=<camera>:<preset>)")
+	rootCmd.Flags().StringArrayP("switch", "s", nil, "Switch viewport to liveview; repeat or separate with commas for several (use --switch=<viewport>:<liveview>)")
+	rootCmd.Flags().StringArray("ptz", nil, "Move PTZ camera to preset; repeat or separate with commas for several (use --ptz=<camera>:<preset>)")
 	rootCmd.Flags().StringP("port", "p", "", "Viewport name or ID (use --port=<value> with --view)")
 	rootCmd.Flags().StringP("view", "v", "", "Liveview/camera name or ID (use --view=<value> with --port)")
 	rootCmd.Flags().StringP("camera", "c", "", "Camera name or ID for PTZ operations (use --camera=<value> with --preset)")
 	rootCmd.Flags().IntP("preset", "P", -2, "PTZ preset position (use --preset=<value>, -1 for home, 0-9 for presets)")
-	rootCmd.Flags().StringP("list", "L", "", "List items (use --list=<value>: 'viewports', 'liveviews', or 'cameras')")
+	rootCmd.Flags().StringP("list", "L", "", "List items (use --list=<value>: 'viewports', 'liveviews', 'cameras' or 'sensors')")
 	rootCmd.Flags().Bool("show-ids", false, "Show IDs when listing")
+	addWatchFlags(rootCmd)
 	rootCmd.Flags().BoolP("version", "V", false, "Show version information")
 
 	// Set all string/int flags to require explicit values
@@ -154,76 +212,223 @@ func init() {
 	rootCmd.Flags().Lookup("view").Annotations = map[string][]string{"required": {"true"}}
 	rootCmd.Flags().Lookup("camera").Annotations = map[string][]string{"required": {"true"}}
 	rootCmd.Flags().Lookup("list").Annotations = map[string][]string{"required": {"true"}}
+
+	// Shell completion for resource names
+	rootCmd.RegisterFlagCompletionFunc("port", completeViewports)
+	rootCmd.RegisterFlagCompletionFunc("view", completeLiveviews)
+	rootCmd.RegisterFlagCompletionFunc("camera", completeCameras)
+	rootCmd.RegisterFlagCompletionFunc("switch", completeSwitch)
+	rootCmd.RegisterFlagCompletionFunc("ptz", completePTZ)
+	rootCmd.RegisterFlagCompletionFunc("preset", completePresetFlag)
+	rootCmd.RegisterFlagCompletionFunc("list", cobra.FixedCompletions([]string{"viewports", "liveviews", "cameras", "sensors"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json", "yaml", "csv", "tsv", "name"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// loadConfig loads the configuration and applies any flag overrides
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Override config with flags if provided
+	protectURL, _ := cmd.Flags().GetString("url")
+	if protectURL != "" {
+		cfg.ProtectURL = protectURL
+	}
+
+	apiToken, _ := cmd.Flags().GetString("token")
+	if apiToken != "" {
+		cfg.APIToken = apiToken
+	}
+
+	logLevel, _ := cmd.Flags().GetString("log-level")
+	if logLevel != "" {
+		cfg.LogLevel = logLevel
+	}
+
+	return cfg, nil
+}
+
+// validateConfig runs one of the configuration checks
+func validateConfig(validate func() error) error {
+	if err := validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
 }
 
 func getClient() (*client.Client, error) {
 	cfg := config.Get()
-	return client.NewClient(cfg.ProtectURL, cfg.APIToken), nil
+	c := client.NewClient(cfg.ProtectURL, cfg.APIToken)
+	c.DryRun = dryRun
+	c.DryRunOutput = dryRunOutput
+	return c, nil
+}
+
+// getOutputOptions reads the global output flags
+func getOutputOptions(cmd *cobra.Command) (output.Options, error) {
+	formatArg, _ := cmd.Flags().GetString("output")
+	tmpl, _ := cmd.Flags().GetString("template")
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+
+	format, err := output.ParseFormat(formatArg)
+	if err != nil {
+		return output.Options{}, err
+	}
+
+	return output.Options{Format: format, Template: tmpl, NoHeaders: noHeaders}, nil
+}
+
+// viewportRecord is the structured form of a viewport listing entry
+type viewportRecord struct {
+	Name       string `json:"name" yaml:"name"`
+	ID         string `json:"id" yaml:"id"`
+	Liveview   string `json:"liveview" yaml:"liveview"`
+	LiveviewID string `json:"liveviewId" yaml:"liveviewId"`
+}
+
+// itemRecord is the structured form of a liveview or camera listing entry
+type itemRecord struct {
+	Name string `json:"name" yaml:"name"`
+	ID   string `json:"id" yaml:"id"`
+}
+
+// switchRecord is the structured result of a viewport switch
+type switchRecord struct {
+	Action     string `json:"action" yaml:"action"`
+	Viewport   string `json:"viewport" yaml:"viewport"`
+	ViewportID string `json:"viewportId" yaml:"viewportId"`
+	Liveview   string `json:"liveview" yaml:"liveview"`
+	LiveviewID string `json:"liveviewId" yaml:"liveviewId"`
+	Success    bool   `json:"success" yaml:"success"`
+	DryRun     bool   `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
 }
 
-func handleListOperation(c *client.Client, listType string, showIDs bool) error {
+// ptzRecord is the structured result of a PTZ move
+type ptzRecord struct {
+	Action   string `json:"action" yaml:"action"`
+	Camera   string `json:"camera" yaml:"camera"`
+	CameraID string `json:"cameraId" yaml:"cameraId"`
+	Preset   int    `json:"preset" yaml:"preset"`
+	Success  bool   `json:"success" yaml:"success"`
+	DryRun   bool   `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
+}
+
+func handleListOperation(c *client.Client, out io.Writer, opts output.Options, listType string, showIDs bool) error {
 	switch listType {
 	case "viewports":
-		return listViewports(c, showIDs)
+		return listViewports(c, out, opts, showIDs)
 	case "liveviews", "views":
-		return listLiveviews(c, showIDs)
+		return listLiveviews(c, out, opts, showIDs)
 	case "cameras":
-		return listCameras(c, showIDs)
+		return listCameras(c, out, opts, showIDs)
+	case "sensors":
+		return listSensors(c, out, opts, showIDs)
 	default:
-		return fmt.Errorf("invalid list type: %s (use 'viewports', 'liveviews', or 'cameras')", listType)
+		return fmt.Errorf("invalid list type: %s (use 'viewports', 'liveviews', 'cameras' or 'sensors')", listType)
 	}
 }
 
-func handleViewportSwitch(c *client.Client, viewportIdentifier, liveviewIdentifier string) error {
+func handleViewportSwitch(c *client.Client, out io.Writer, opts output.Options, viewportIdentifier, liveviewIdentifier string) error {
 	log := logger.Get()
 
+	var viewportID, viewportName, liveviewID, liveviewName string
+	results, forwarded, err := forwardActions(c, []actions.Action{actions.Switch(viewportIdentifier, liveviewIdentifier)})
+	switch {
+	case err != nil:
+		return err
+	case forwarded:
+		r := results[0]
+		if !r.Success {
+			return fmt.Errorf("failed to switch viewport: %s", r.Error)
+		}
+		viewportID, viewportName, liveviewID, liveviewName = r.TargetID, r.Target, r.ValueID, r.Value
+	default:
+		viewportID, viewportName, liveviewID, liveviewName, err = switchViewport(c, viewportIdentifier, liveviewIdentifier)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infow("Switched viewport", "viewportID", viewportID, "liveviewID", liveviewID, "agent", forwarded)
+
+	if opts.Structured() {
+		record := switchRecord{
+			Action:     "switch",
+			Viewport:   viewportName,
+			ViewportID: viewportID,
+			Liveview:   liveviewName,
+			LiveviewID: liveviewID,
+			Success:    true,
+			DryRun:     c.DryRun,
+		}
+		return output.Write(out, opts, output.Listing{
+			Items:   record,
+			Headers: []string{"action", "viewport", "viewport_id", "liveview", "liveview_id", "success", "dry_run"},
+			Rows:    [][]string{{record.Action, record.Viewport, record.ViewportID, record.Liveview, record.LiveviewID, "true", strconv.FormatBool(record.DryRun)}},
+			Names:   []string{record.Viewport},
+		})
+	}
+
+	if c.DryRun {
+		fmt.Fprintf(out, "Dry run: would switch viewport %s to liveview %s\n", viewportIdentifier, liveviewIdentifier)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Successfully switched viewport %s to liveview %s\n", viewportIdentifier, liveviewIdentifier)
+	return nil
+}
+
+// switchViewport looks a viewport and liveview up by name or ID and
+// switches the viewport, returning the IDs and names found
+func switchViewport(c *client.Client, viewportIdentifier, liveviewIdentifier string) (string, string, string, string, error) {
 	// Find viewport by name or ID
 	viewports, err := c.ListViewports()
 	if err != nil {
-		return fmt.Errorf("failed to list viewports: %w", err)
+		return "", "", "", "", fmt.Errorf("failed to list viewports: %w", err)
 	}
 
-	var viewportID string
+	var viewportID, viewportName string
 	for _, vp := range viewports {
 		if vp.ID == viewportIdentifier || vp.Name == viewportIdentifier {
 			viewportID = vp.ID
+			viewportName = vp.Name
 			break
 		}
 	}
 
 	if viewportID == "" {
-		return fmt.Errorf("viewport not found: %s", viewportIdentifier)
+		return "", "", "", "", fmt.Errorf("viewport not found: %s", viewportIdentifier)
 	}
 
 	// Find liveview by name or ID
 	liveviews, err := c.ListCameras()
 	if err != nil {
-		return fmt.Errorf("failed to list liveviews: %w", err)
+		return "", "", "", "", fmt.Errorf("failed to list liveviews: %w", err)
 	}
 
-	var liveviewID string
+	var liveviewID, liveviewName string
 	for _, lv := range liveviews {
 		if lv.ID == liveviewIdentifier || lv.Name == liveviewIdentifier {
 			liveviewID = lv.ID
+			liveviewName = lv.Name
 			break
 		}
 	}
 
 	if liveviewID == "" {
-		return fmt.Errorf("liveview not found: %s", liveviewIdentifier)
+		return "", "", "", "", fmt.Errorf("liveview not found: %s", liveviewIdentifier)
 	}
 
 	if err := c.SwitchViewport(viewportID, liveviewID); err != nil {
-		return fmt.Errorf("failed to switch viewport: %w", err)
+		return "", "", "", "", fmt.Errorf("failed to switch viewport: %w", err)
 	}
 
-	fmt.Printf("Successfully switched viewport %s to liveview %s\n", viewportIdentifier, liveviewIdentifier)
-	log.Infow("Switched viewport", "viewportID", viewportID, "liveviewID", liveviewID)
-
-	return nil
+	return viewportID, viewportName, liveviewID, liveviewName, nil
 }
 
-func handleCameraOperation(c *client.Client, cameraNameOrID string, preset int) error {
+func handleCameraOperation(c *client.Client, out io.Writer, opts output.Options, cameraNameOrID string, preset int) error {
 	if preset == -2 {
 		return fmt.Errorf("--preset flag is required when using --camera")
 	}
@@ -232,9 +437,54 @@ func handleCameraOperation(c *client.Client, cameraNameOrID string, preset int)
 		return fmt.Errorf("invalid preset value: %d (must be between -1 and 9)", preset)
 	}
 
+	var cameraID, cameraName string
+	results, forwarded, err := forwardActions(c, []actions.Action{actions.PTZ(cameraNameOrID, preset)})
+	switch {
+	case err != nil:
+		return err
+	case forwarded:
+		r := results[0]
+		if !r.Success {
+			return errors.New(r.Error)
+		}
+		cameraID, cameraName = r.TargetID, r.Target
+	default:
+		cameraID, cameraName, err = moveCamera(c, cameraNameOrID, preset)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Structured() {
+		record := ptzRecord{Action: "ptz", Camera: cameraName, CameraID: cameraID, Preset: preset, Success: true, DryRun: c.DryRun}
+		return output.Write(out, opts, output.Listing{
+			Items:   record,
+			Headers: []string{"action", "camera", "camera_id", "preset", "success", "dry_run"},
+			Rows:    [][]string{{record.Action, record.Camera, record.CameraID, strconv.Itoa(preset), "true", strconv.FormatBool(record.DryRun)}},
+			Names:   []string{record.Camera},
+		})
+	}
+
+	presetLabel := fmt.Sprintf("preset %d", preset)
+	if preset == -1 {
+		presetLabel = "home position"
+	}
+
+	if c.DryRun {
+		fmt.Fprintf(out, "Dry run: would move camera '%s' to %s\n", cameraName, presetLabel)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Successfully moved camera '%s' to %s\n", cameraName, presetLabel)
+	return nil
+}
+
+// moveCamera looks a camera up by name or ID and moves it to a preset,
+// returning the ID and name found
+func moveCamera(c *client.Client, cameraNameOrID string, preset int) (string, string, error) {
 	cameras, err := c.ListPTZCameras()
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
 	var cameraID string
@@ -248,23 +498,17 @@ func handleCameraOperation(c *client.Client, cameraNameOrID string, preset int)
 	}
 
 	if cameraID == "" {
-		return fmt.Errorf("camera not found: %s", cameraNameOrID)
+		return "", "", fmt.Errorf("camera not found: %s", cameraNameOrID)
 	}
 
 	if err := c.MovePTZToPreset(cameraID, preset); err != nil {
-		return err
-	}
-
-	presetLabel := fmt.Sprintf("preset %d", preset)
-	if preset == -1 {
-		presetLabel = "home position"
+		return "", "", err
 	}
 
-	fmt.Printf("Successfully moved camera '%s' to %s\n", cameraName, presetLabel)
-	return nil
+	return cameraID, cameraName, nil
 }
 
-func listViewports(c *client.Client, showIDs bool) error {
+func listViewports(c *client.Client, out io.Writer, opts output.Options, showIDs bool) error {
 	log := logger.Get()
 
 	viewports, err := c.ListViewports()
@@ -272,8 +516,8 @@ func listViewports(c *client.Client, showIDs bool) error {
 		return fmt.Errorf("failed to list viewports: %w", err)
 	}
 
-	if len(viewports) == 0 {
-		fmt.Println("No viewports found")
+	if len(viewports) == 0 && !opts.Structured() {
+		fmt.Fprintln(out, "No viewports found")
 		return nil
 	}
 
@@ -287,33 +531,53 @@ func listViewports(c *client.Client, showIDs bool) error {
 		liveviewMap[lv.ID] = lv.Name
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	if showIDs {
-		fmt.Fprintln(w, "NAME\tCURRENT LIVEVIEW\tID\tLIVEVIEW ID")
-		fmt.Fprintln(w, "----\t----------------\t--\t-----------")
-	} else {
-		fmt.Fprintln(w, "NAME\tCURRENT LIVEVIEW")
-		fmt.Fprintln(w, "----\t----------------")
-	}
-
+	records := make([]viewportRecord, 0, len(viewports))
 	for _, vp := range viewports {
 		liveviewName := liveviewMap[vp.Liveview]
 		if liveviewName == "" {
 			liveviewName = vp.Liveview
 		}
+		records = append(records, viewportRecord{Name: vp.Name, ID: vp.ID, Liveview: liveviewName, LiveviewID: vp.Liveview})
+	}
+
+	defer log.Infow("Listed viewports", "count", len(viewports))
+
+	if opts.Structured() {
+		listing := output.Listing{
+			Items:   records,
+			Headers: []string{"name", "id", "liveview", "liveview_id"},
+		}
+		for _, r := range records {
+			listing.Rows = append(listing.Rows, []string{r.Name, r.ID, r.Liveview, r.LiveviewID})
+			listing.Names = append(listing.Names, r.Name)
+		}
+		return output.Write(out, opts, listing)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		if showIDs {
+			fmt.Fprintln(w, "NAME\tCURRENT LIVEVIEW\tID\tLIVEVIEW ID")
+			fmt.Fprintln(w, "----\t----------------\t--\t-----------")
+		} else {
+			fmt.Fprintln(w, "NAME\tCURRENT LIVEVIEW")
+			fmt.Fprintln(w, "----\t----------------")
+		}
+	}
+
+	for _, r := range records {
 		if showIDs {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", vp.Name, liveviewName, vp.ID, vp.Liveview)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Liveview, r.ID, r.LiveviewID)
 		} else {
-			fmt.Fprintf(w, "%s\t%s\n", vp.Name, liveviewName)
+			fmt.Fprintf(w, "%s\t%s\n", r.Name, r.Liveview)
 		}
 	}
 
 	w.Flush()
-	log.Infow("Listed viewports", "count", len(viewports))
 	return nil
 }
 
-func listLiveviews(c *client.Client, showIDs bool) error {
+func listLiveviews(c *client.Client, out io.Writer, opts output.Options, showIDs bool) error {
 	log := logger.Get()
 
 	cameras, err := c.ListCameras()
@@ -321,52 +585,69 @@ func listLiveviews(c *client.Client, showIDs bool) error {
 		return fmt.Errorf("failed to list liveviews: %w", err)
 	}
 
-	if len(cameras) == 0 {
-		fmt.Println("No liveviews found")
+	if len(cameras) == 0 && !opts.Structured() {
+		fmt.Fprintln(out, "No liveviews found")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer log.Infow("Listed liveviews", "count", len(cameras))
+
+	if opts.Structured() {
+		return writeItems(out, opts, liveviewItems(cameras))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 	if showIDs {
-		fmt.Fprintln(w, "NAME\tID")
-		fmt.Fprintln(w, "----\t--")
+		if !opts.NoHeaders {
+			fmt.Fprintln(w, "NAME\tID")
+			fmt.Fprintln(w, "----\t--")
+		}
 		for _, cam := range cameras {
 			fmt.Fprintf(w, "%s\t%s\n", cam.Name, cam.ID)
 		}
 	} else {
-		fmt.Fprintln(w, "NAME")
-		fmt.Fprintln(w, "----")
+		if !opts.NoHeaders {
+			fmt.Fprintln(w, "NAME")
+			fmt.Fprintln(w, "----")
+		}
 		for _, cam := range cameras {
 			fmt.Fprintf(w, "%s\n", cam.Name)
 		}
 	}
 
 	w.Flush()
-	log.Infow("Listed liveviews", "count", len(cameras))
 	return nil
 }
 
-func listCameras(c *client.Client, showIDs bool) error {
+func listCameras(c *client.Client, out io.Writer, opts output.Options, showIDs bool) error {
 	cameras, err := c.ListPTZCameras()
 	if err != nil {
 		return err
 	}
 
-	if len(cameras) == 0 {
-		fmt.Println("No PTZ cameras found")
+	if len(cameras) == 0 && !opts.Structured() {
+		fmt.Fprintln(out, "No PTZ cameras found")
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if opts.Structured() {
+		return writeItems(out, opts, cameraItems(cameras))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 	if showIDs {
-		fmt.Fprintln(w, "ID\tNAME")
-		fmt.Fprintln(w, "--\t----")
+		if !opts.NoHeaders {
+			fmt.Fprintln(w, "ID\tNAME")
+			fmt.Fprintln(w, "--\t----")
+		}
 		for _, camera := range cameras {
 			fmt.Fprintf(w, "%s\t%s\n", camera.ID, camera.Name)
 		}
 	} else {
-		fmt.Fprintln(w, "NAME")
-		fmt.Fprintln(w, "----")
+		if !opts.NoHeaders {
+			fmt.Fprintln(w, "NAME")
+			fmt.Fprintln(w, "----")
+		}
 		for _, camera := range cameras {
 			fmt.Fprintf(w, "%s\n", camera.Name)
 		}
@@ -376,41 +657,92 @@ func listCameras(c *client.Client, showIDs bool) error {
 	return nil
 }
 
+// liveviewItems converts liveviews to structured records
+func liveviewItems(liveviews []client.Liveview) []itemRecord {
+	records := make([]itemRecord, 0, len(liveviews))
+	for _, lv := range liveviews {
+		records = append(records, itemRecord{Name: lv.Name, ID: lv.ID})
+	}
+	return records
+}
+
+// cameraItems converts cameras to structured records
+func cameraItems(cameras []client.PTZCamera) []itemRecord {
+	records := make([]itemRecord, 0, len(cameras))
+	for _, cam := range cameras {
+		records = append(records, itemRecord{Name: cam.Name, ID: cam.ID})
+	}
+	return records
+}
+
+// writeItems renders name/ID records in a structured format
+func writeItems(out io.Writer, opts output.Options, records []itemRecord) error {
+	listing := output.Listing{
+		Items:   records,
+		Headers: []string{"name", "id"},
+	}
+	for _, r := range records {
+		listing.Rows = append(listing.Rows, []string{r.Name, r.ID})
+		listing.Names = append(listing.Names, r.Name)
+	}
+	return output.Write(out, opts, listing)
+}
+
 // handleSwitchCommand processes the combined switch flag (viewport:liveview)
-func handleSwitchCommand(c *client.Client, switchArg string) error {
+func handleSwitchCommand(c *client.Client, out io.Writer, opts output.Options, switchArg string) error {
+	viewport, liveview, err := parseSwitchPair(switchArg)
+	if err != nil {
+		return err
+	}
+
+	return handleViewportSwitch(c, out, opts, viewport, liveview)
+}
+
+// parseSwitchPair splits a viewport:liveview pair
+func parseSwitchPair(switchArg string) (string, string, error) {
 	parts := strings.Split(switchArg, ":")
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid switch format: %s (expected format: <viewport>:<liveview>)", switchArg)
+		return "", "", fmt.Errorf("invalid switch format: %s (expected format: <viewport>:<liveview>)", switchArg)
 	}
 
 	viewport := strings.TrimSpace(parts[0])
 	liveview := strings.TrimSpace(parts[1])
 
 	if viewport == "" || liveview == "" {
-		return fmt.Errorf("viewport and liveview cannot be empty")
+		return "", "", fmt.Errorf("viewport and liveview cannot be empty")
 	}
 
-	return handleViewportSwitch(c, viewport, liveview)
+	return viewport, liveview, nil
 }
 
 // handlePTZCommand processes the combined PTZ flag (camera:preset)
-func handlePTZCommand(c *client.Client, ptzArg string) error {
+func handlePTZCommand(c *client.Client, out io.Writer, opts output.Options, ptzArg string) error {
+	camera, preset, err := parsePTZPair(ptzArg)
+	if err != nil {
+		return err
+	}
+
+	return handleCameraOperation(c, out, opts, camera, preset)
+}
+
+// parsePTZPair splits a camera:preset pair
+func parsePTZPair(ptzArg string) (string, int, error) {
 	parts := strings.Split(ptzArg, ":")
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid ptz format: %s (expected format: <camera>:<preset>)", ptzArg)
+		return "", 0, fmt.Errorf("invalid ptz format: %s (expected format: <camera>:<preset>)", ptzArg)
 	}
 
 	camera := strings.TrimSpace(parts[0])
 	presetStr := strings.TrimSpace(parts[1])
 
 	if camera == "" || presetStr == "" {
-		return fmt.Errorf("camera and preset cannot be empty")
+		return "", 0, fmt.Errorf("camera and preset cannot be empty")
 	}
 
-	preset, err := strconv.Atoi(presetStr)
+	preset, err := actions.ParsePreset(presetStr)
 	if err != nil {
-		return fmt.Errorf("invalid preset value: %s (must be a number between -1 and 9)", presetStr)
+		return "", 0, err
 	}
 
-	return handleCameraOperation(c, camera, preset)
+	return camera, preset, nil
 }
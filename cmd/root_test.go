@@ -2,8 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/protecttest"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -43,6 +49,27 @@ func TestRootCommandFlags(t *testing.T) {
 		t.Errorf("Expected log-level default to be 'none', got '%s'", logLevelFlag.DefValue)
 	}
 
+	outputFlag := persistentFlags.Lookup("output")
+	if outputFlag == nil {
+		t.Error("Expected 'output' flag to be registered")
+	}
+
+	if outputFlag != nil && outputFlag.DefValue != "table" {
+		t.Errorf("Expected output default to be 'table', got '%s'", outputFlag.DefValue)
+	}
+
+	if persistentFlags.Lookup("template") == nil {
+		t.Error("Expected 'template' flag to be registered")
+	}
+
+	if persistentFlags.Lookup("no-headers") == nil {
+		t.Error("Expected 'no-headers' flag to be registered")
+	}
+
+	if persistentFlags.Lookup("dry-run") == nil {
+		t.Error("Expected 'dry-run' flag to be registered")
+	}
+
 	// Test command-specific flags
 	flags := rootCmd.Flags()
 
@@ -118,14 +145,191 @@ func TestRootCommandHelp(t *testing.T) {
 	rootCmd.SetArgs([]string{})
 }
 
-func TestRootCommandNoSubcommands(t *testing.T) {
-	// Verify that legacy subcommands are not registered
-	commands := rootCmd.Commands()
+func TestRootCommandSubcommands(t *testing.T) {
+	// Legacy flag-based usage stays on the root command; only the
+	// commands listed here may be registered alongside it
+	allowed := map[string]bool{
+		"help":       true,
+		"__complete": true,
+		"completion": true,
+		"events":     true,
+		"assets":     true,
+		"talkback":   true,
+		"viewport":   true,
+		"liveview":   true,
+		"ptz":        true,
+		"tui":        true,
+		"scene":      true,
+		"run":        true,
+		"state":      true,
+		"tour":       true,
+		"schedule":   true,
+		"automate":   true,
+		"serve":      true,
+		"mqtt":       true,
+		"exporter":   true,
+		"doctor":     true,
+		"apply":      true,
+		"where":      true,
+		"shell":      true,
+		"rpc":        true,
+		"agent":      true,
+		"sensor":     true,
+	}
 
-	for _, cmd := range commands {
-		// Only help and completion commands should exist
-		if cmd.Name() != "help" && cmd.Name() != "completion" {
-			t.Errorf("Unexpected command '%s' found - only flag-based usage should be supported", cmd.Name())
+	for _, cmd := range rootCmd.Commands() {
+		if !allowed[cmd.Name()] {
+			t.Errorf("Unexpected command '%s' found", cmd.Name())
 		}
 	}
 }
+
+// newListServer serves viewers and liveviews for listing tests
+func newListServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	return server
+}
+
+func TestConfigSectionsValidatedByCommand(t *testing.T) {
+	server := newListServer(t)
+
+	cfg := config.Get()
+	defer func(schedules []config.Schedule, rules []config.Rule) {
+		cfg.Schedules, cfg.Rules = schedules, rules
+	}(cfg.Schedules, cfg.Rules)
+	cfg.Schedules = []config.Schedule{{Name: "typo", At: "22:00"}}
+	cfg.Rules = []config.Rule{{Name: "empty"}}
+
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	defer func() {
+		rootCmd.SetArgs([]string{})
+		rootCmd.PersistentFlags().Set("url", "")
+		rootCmd.PersistentFlags().Set("token", "")
+		rootCmd.Flags().Set("list", "")
+	}()
+
+	// A broken schedule or rule does not stop unrelated commands
+	rootCmd.SetArgs([]string{"--list=viewports", "--url=" + server.URL, "--token=test-token"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("--list=viewports error = %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"schedule", "next", "--url=" + server.URL, "--token=test-token"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid configuration: schedule typo") {
+		t.Errorf("Expected schedule next to reject the schedule, got %v", err)
+	}
+}
+
+func TestListViewportsOutputFormats(t *testing.T) {
+	server := newListServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	tests := []struct {
+		name string
+		opts output.Options
+		want string
+	}{
+		{
+			name: "Table without headers",
+			opts: output.Options{Format: output.Table, NoHeaders: true},
+			want: "Tower  All Cameras\n",
+		},
+		{
+			name: "CSV",
+			opts: output.Options{Format: output.CSV},
+			want: "name,id,liveview,liveview_id\nTower,vp1,All Cameras,lv1\n",
+		},
+		{
+			name: "Name",
+			opts: output.Options{Format: output.Name},
+			want: "Tower\n",
+		},
+		{
+			name: "Template",
+			opts: output.Options{Format: output.Table, Template: "{{.Name}} -> {{.Liveview}}"},
+			want: "Tower -> All Cameras\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := listViewports(c, buf, tt.opts, false); err != nil {
+				t.Fatalf("listViewports() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("listViewports() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestListLiveviewsJSON(t *testing.T) {
+	server := newListServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	buf := new(bytes.Buffer)
+	if err := listLiveviews(c, buf, output.Options{Format: output.JSON}, false); err != nil {
+		t.Fatalf("listLiveviews() error = %v", err)
+	}
+
+	var records []itemRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Expected JSON array, got %q: %v", buf.String(), err)
+	}
+
+	if len(records) != 2 || records[1].Name != "Driveway" || records[1].ID != "lv2" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestHandleViewportSwitchJSON(t *testing.T) {
+	server := newListServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	buf := new(bytes.Buffer)
+	if err := handleViewportSwitch(c, buf, output.Options{Format: output.JSON}, "Tower", "Driveway"); err != nil {
+		t.Fatalf("handleViewportSwitch() error = %v", err)
+	}
+
+	var record switchRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected JSON object, got %q: %v", buf.String(), err)
+	}
+
+	want := switchRecord{Action: "switch", Viewport: "Tower", ViewportID: "vp1", Liveview: "Driveway", LiveviewID: "lv2", Success: true}
+	if record != want {
+		t.Errorf("handleViewportSwitch() = %+v, want %+v", record, want)
+	}
+}
+
+func TestHandleViewportSwitchDryRun(t *testing.T) {
+	server := newListServer(t)
+
+	requests := new(bytes.Buffer)
+	c := client.NewClient(server.URL, "test-token")
+	c.DryRun = true
+	c.DryRunOutput = requests
+
+	buf := new(bytes.Buffer)
+	if err := handleViewportSwitch(c, buf, output.Options{Format: output.Table}, "Tower", "Driveway"); err != nil {
+		t.Fatalf("handleViewportSwitch() error = %v", err)
+	}
+
+	if buf.String() != "Dry run: would switch viewport Tower to liveview Driveway\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	want := "DRY RUN: PATCH " + server.URL + "/proxy/protect/integration/v1/viewers/vp1\n{\"liveview\":\"lv2\"}\n"
+	if requests.String() != want {
+		t.Errorf("Dry run printed %q, want %q", requests.String(), want)
+	}
+
+	// Lookups are answered as usual; anything else must not reach the server
+	if changes := server.Requests(); len(changes) != 0 {
+		t.Errorf("Unexpected requests during dry run: %v", changes)
+	}
+}
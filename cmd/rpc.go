@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/rpc"
+	"github.com/spf13/cobra"
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Speak JSON-RPC 2.0 over stdin and stdout for plugins",
+	Long: `Run as a long-lived child process that answers JSON-RPC 2.0 requests, so
+Companion modules and editor plugins can control Protect without an HTTP port.
+
+Each request (or batch) is one line of JSON on stdin; each response and
+notification is one line on stdout. Requests run concurrently, so match
+responses by id. Logs and dry-run output go to stderr.
+
+Methods (params by name):
+  listViewports                          Viewports and their liveview
+  listLiveviews                          Liveviews
+  listCameras                            Cameras
+  switchViewport {"viewport", "liveview"}
+  movePTZ        {"camera", "preset"}    Preset 0-9, -1 or "home"
+  applyScene     {"name"}                Apply a configured scene
+
+Notifications:
+  deviceChanged  A device was added, updated or removed ("resync" after
+                 each reconnect means reload everything)
+  eventReceived  A motion, ring or smart detection event
+
+Unknown names fail with code -32001 and failed actions with -32000, whose
+data holds the result of every action. The process exits when stdin closes.`,
+	Example: `  protect rpc --stdio
+  echo '{"jsonrpc":"2.0","id":1,"method":"listViewports"}' | protect rpc --stdio --notifications=false`,
+	Args: cobra.NoArgs,
+	// Usage text on stdout would corrupt the protocol stream
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stdio, _ := cmd.Flags().GetBool("stdio")
+		notify, _ := cmd.Flags().GetBool("notifications")
+
+		if !stdio {
+			return fmt.Errorf("no transport selected (use --stdio)")
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+		// Stdout carries the protocol
+		c.DryRunOutput = cmd.ErrOrStderr()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		s := rpc.New(c, config.Get())
+		s.Notify = notify
+		return s.Serve(ctx, cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rpcCmd.Flags().Bool("stdio", false, "Read requests from stdin and write responses to stdout")
+	rpcCmd.Flags().Bool("notifications", true, "Send device and event notifications (use --notifications=false to disable)")
+
+	rootCmd.AddCommand(rpcCmd)
+}
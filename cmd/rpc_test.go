@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRPCCommand(t *testing.T) {
+	server := newSceneServer(t)
+
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetIn(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"listLiveviews"}` + "\n"))
+	defer func() {
+		rootCmd.SetArgs([]string{})
+		rootCmd.SetIn(nil)
+		rootCmd.PersistentFlags().Set("url", "")
+		rootCmd.PersistentFlags().Set("token", "")
+		rpcCmd.Flags().Set("stdio", "false")
+		rpcCmd.Flags().Set("notifications", "true")
+	}()
+
+	rootCmd.SetArgs([]string{"rpc", "--url=" + server.URL, "--token=test-token"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--stdio") {
+		t.Errorf("Expected an error without a transport, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing on stdout, got %q", out.String())
+	}
+
+	rootCmd.SetArgs([]string{"rpc", "--stdio", "--notifications=false", "--url=" + server.URL, "--token=test-token"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("rpc --stdio error = %v", err)
+	}
+
+	want := `{"jsonrpc":"2.0","id":1,"result":[{"id":"lv1","name":"All Cameras"}]}` + "\n"
+	if out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
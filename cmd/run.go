@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/script"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <script|->",
+	Short: "Run a script of switch and PTZ statements",
+	Long: `Run a line-oriented script from a file, or from stdin with "-".
+
+Statements:
+  switch <viewport> <liveview>   Switch a viewport (or switch <viewport>:<liveview>)
+  ptz <camera> <preset|home>     Move a PTZ camera (or ptz <camera>:<preset>)
+  sleep <duration>               Pause, e.g. "sleep 5s" or "sleep 2"
+  wait                           Wait for actions started in the background
+  set <name> <value>             Set a variable, used later as $name or ${name}
+
+Quote names containing spaces. A trailing & runs a switch or ptz in the
+background, and # starts a comment. Names are looked up once and every
+target is resolved before the script starts.`,
+	Example: `  protect run morning.protect
+  protect run lockdown.protect --on-error=continue
+  protect run - --var=LOBBY="Lobby Screen" < routine.protect`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		onError, _ := cmd.Flags().GetString("on-error")
+		varArgs, _ := cmd.Flags().GetStringArray("var")
+
+		if onError != "stop" && onError != "continue" {
+			return fmt.Errorf("invalid --on-error value: %s (use 'stop' or 'continue')", onError)
+		}
+
+		vars := make(map[string]string)
+		for _, arg := range varArgs {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid variable: %s (expected format: <name>=<value>)", arg)
+			}
+			vars[name] = value
+		}
+
+		in := cmd.InOrStdin()
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open script: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		statements, err := script.Parse(in, vars)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runScript(ctx, c, cmd.OutOrStdout(), opts, statements, onError == "continue")
+	},
+}
+
+func init() {
+	runCmd.Flags().String("on-error", "stop", "What to do when an action fails (use --on-error=<value>: 'stop' or 'continue')")
+	runCmd.Flags().StringArray("var", nil, "Set a script variable (use --var=<name>=<value>, repeatable)")
+
+	runCmd.RegisterFlagCompletionFunc("on-error", cobra.FixedCompletions([]string{"stop", "continue"}, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(runCmd)
+}
+
+// runScript runs parsed statements, printing each result as it finishes in
+// table mode or all results at the end in structured modes
+func runScript(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, statements []script.Statement, continueOnError bool) error {
+	scriptOpts := script.Options{ContinueOnError: continueOnError}
+
+	if !opts.Structured() {
+		if !opts.NoHeaders {
+			fmt.Fprintf(out, "%-5s  %-6s  %-24s  %-24s  %s\n", "LINE", "ACTION", "TARGET", "VALUE", "RESULT")
+		}
+		scriptOpts.OnResult = func(stmt script.Statement, r actions.Result) {
+			fmt.Fprintf(out, "%-5d  %-6s  %-24s  %-24s  %s\n", stmt.Line, r.Action, r.Target, r.Value, resultStatus(r))
+		}
+	}
+
+	results, err := script.Run(ctx, c, statements, scriptOpts)
+
+	if opts.Structured() && results != nil {
+		if writeErr := writeResults(out, opts, results); writeErr != nil {
+			return writeErr
+		}
+		if err != nil {
+			return reportedError{err}
+		}
+	}
+
+	return err
+}
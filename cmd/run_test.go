@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/script"
+)
+
+func TestRunCommandFlags(t *testing.T) {
+	flags := runCmd.Flags()
+
+	onError := flags.Lookup("on-error")
+	if onError == nil {
+		t.Fatal("Expected 'on-error' flag to be registered")
+	}
+	if onError.DefValue != "stop" {
+		t.Errorf("Expected on-error default to be 'stop', got '%s'", onError.DefValue)
+	}
+
+	if flags.Lookup("var") == nil {
+		t.Error("Expected 'var' flag to be registered")
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	statements, err := script.Parse(strings.NewReader("switch Tower \"All Cameras\"\nptz \"Front Door\" 3\n"), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := runScript(context.Background(), c, buf, output.Options{Format: output.Table}, statements, false); err != nil {
+		t.Fatalf("runScript() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "LINE") {
+		t.Fatalf("Expected header and two result lines, got %q", buf.String())
+	}
+
+	if !strings.Contains(lines[2], "Front Door") || !strings.HasSuffix(lines[2], "ok") {
+		t.Errorf("Unexpected result line: %q", lines[2])
+	}
+}
+
+func TestRunScriptJSON(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	statements, err := script.Parse(strings.NewReader("ptz Driveway 1\nptz \"Front Door\" 2\n"), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	err = runScript(context.Background(), c, buf, output.Options{Format: output.JSON}, statements, true)
+	if err == nil || !errors.As(err, new(reportedError)) {
+		t.Fatalf("Expected reported error, got %v", err)
+	}
+
+	var results []actions.Result
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("Expected JSON results, got %q: %v", buf.String(), err)
+	}
+
+	if len(results) != 2 || results[0].Success || !results[1].Success {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var sceneCmd = &cobra.Command{
+	Use:     "scene",
+	Aliases: []string{"scenes"},
+	Short:   "List and apply scenes",
+	Long: `List and apply scenes: named sets of viewport switches and PTZ moves
+defined under "scenes:" in the configuration file.`,
+	Args: cobra.NoArgs,
+}
+
+var sceneListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List configured scenes",
+	Example: `  protect scene list
+  protect scene list --output=yaml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := validateConfig(config.Get().ValidateScenes); err != nil {
+			return err
+		}
+
+		return listScenes(cmd.OutOrStdout(), opts, config.Get().Scenes)
+	},
+}
+
+var sceneApplyCmd = &cobra.Command{
+	Use:   "apply <name>",
+	Short: "Apply a scene",
+	Long: `Apply a scene. Every viewport, liveview and camera is resolved before
+anything changes; the switches and PTZ moves then run concurrently and the
+result of each one is reported.`,
+	Example: `  protect scene apply "Shift Change"
+  protect scene apply night --output=json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeScenes,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateConfig(config.Get().ValidateScenes); err != nil {
+			return err
+		}
+
+		scene, err := config.Get().Scene(args[0])
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return applyScene(ctx, c, cmd.OutOrStdout(), opts, *scene)
+	},
+}
+
+func init() {
+	sceneCmd.AddCommand(sceneListCmd, sceneApplyCmd)
+	rootCmd.AddCommand(sceneCmd)
+}
+
+// sceneRecord is the structured form of a scene listing entry
+type sceneRecord struct {
+	Name     string              `json:"name" yaml:"name"`
+	Switches []sceneSwitchRecord `json:"switches" yaml:"switches"`
+	PTZ      []scenePTZRecord    `json:"ptz" yaml:"ptz"`
+}
+
+type sceneSwitchRecord struct {
+	Viewport string `json:"viewport" yaml:"viewport"`
+	Liveview string `json:"liveview" yaml:"liveview"`
+}
+
+type scenePTZRecord struct {
+	Camera string `json:"camera" yaml:"camera"`
+	Preset string `json:"preset" yaml:"preset"`
+}
+
+func listScenes(out io.Writer, opts output.Options, scenes []config.Scene) error {
+	if opts.Structured() {
+		listing := output.Listing{Headers: []string{"name", "switches", "ptz"}}
+		records := make([]sceneRecord, 0, len(scenes))
+		for _, s := range scenes {
+			record := sceneRecord{Name: s.Name, Switches: []sceneSwitchRecord{}, PTZ: []scenePTZRecord{}}
+			for _, sw := range s.Switches {
+				record.Switches = append(record.Switches, sceneSwitchRecord{Viewport: sw.Viewport, Liveview: sw.Liveview})
+			}
+			for _, move := range s.PTZ {
+				record.PTZ = append(record.PTZ, scenePTZRecord{Camera: move.Camera, Preset: move.Preset})
+			}
+			records = append(records, record)
+			listing.Rows = append(listing.Rows, []string{s.Name, strconv.Itoa(len(s.Switches)), strconv.Itoa(len(s.PTZ))})
+			listing.Names = append(listing.Names, s.Name)
+		}
+		listing.Items = records
+		return output.Write(out, opts, listing)
+	}
+
+	if len(scenes) == 0 {
+		fmt.Fprintln(out, "No scenes configured")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		fmt.Fprintln(w, "NAME\tSWITCHES\tPTZ MOVES")
+		fmt.Fprintln(w, "----\t--------\t---------")
+	}
+	for _, s := range scenes {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", s.Name, len(s.Switches), len(s.PTZ))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// applyScene resolves and runs every action in a scene
+func applyScene(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, scene config.Scene) error {
+	acts, err := actions.FromScene(scene)
+	if err != nil {
+		return err
+	}
+
+	targets, err := actions.NewResolver(c).Resolve(acts)
+	if err != nil {
+		return fmt.Errorf("failed to resolve scene %s: %w", scene.Name, err)
+	}
+
+	results := actions.Execute(ctx, c, targets, actions.DefaultWorkers)
+	if err := writeResults(out, opts, results); err != nil {
+		return err
+	}
+
+	if failed := actions.Failed(results); failed > 0 {
+		err := fmt.Errorf("scene %s: %d of %d actions failed", scene.Name, failed, len(results))
+		if opts.Structured() {
+			return reportedError{err}
+		}
+		return err
+	}
+
+	if !opts.Structured() {
+		if c.DryRun {
+			fmt.Fprintf(out, "Dry run: scene %s would run %d actions\n", scene.Name, len(results))
+		} else {
+			fmt.Fprintf(out, "Successfully applied scene %s (%d actions)\n", scene.Name, len(results))
+		}
+	}
+	return nil
+}
+
+// writeResults prints one line per action result
+func writeResults(out io.Writer, opts output.Options, results []actions.Result) error {
+	if opts.Structured() {
+		listing := output.Listing{
+			Items:   results,
+			Headers: []string{"action", "target", "target_id", "value", "value_id", "success", "dry_run", "error"},
+		}
+		for _, r := range results {
+			listing.Rows = append(listing.Rows, []string{string(r.Action), r.Target, r.TargetID, r.Value, r.ValueID, strconv.FormatBool(r.Success), strconv.FormatBool(r.DryRun), r.Error})
+			listing.Names = append(listing.Names, r.Target)
+		}
+		return output.Write(out, opts, listing)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		fmt.Fprintln(w, "ACTION\tTARGET\tVALUE\tRESULT")
+		fmt.Fprintln(w, "------\t------\t-----\t------")
+	}
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Action, r.Target, r.Value, resultStatus(r))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// resultStatus describes the outcome of an action for table output
+func resultStatus(r actions.Result) string {
+	switch {
+	case !r.Success:
+		return "failed: " + r.Error
+	case r.DryRun:
+		return "dry run"
+	default:
+		return "ok"
+	}
+}
+
+// completeScenes completes configured scene names
+func completeScenes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, s := range cfg.Scenes {
+		names = append(names, s.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
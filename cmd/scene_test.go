@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newSceneServer serves lookups and fails PTZ moves for cam2
+func newSceneServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Driveway"}]`)
+	server.Fail("cameras/cam2/", http.StatusInternalServerError)
+	return server
+}
+
+func TestSceneCommands(t *testing.T) {
+	names := make(map[string]bool)
+	for _, cmd := range sceneCmd.Commands() {
+		names[cmd.Name()] = true
+	}
+
+	for _, want := range []string{"list", "apply"} {
+		if !names[want] {
+			t.Errorf("Expected 'scene %s' command to be registered", want)
+		}
+	}
+}
+
+func TestListScenes(t *testing.T) {
+	scenes := []config.Scene{{
+		Name:     "Shift Change",
+		Switches: []config.SceneSwitch{{Viewport: "Tower", Liveview: "All Cameras"}},
+		PTZ:      []config.ScenePTZ{{Camera: "Front Door", Preset: "5"}},
+	}}
+
+	buf := new(bytes.Buffer)
+	if err := listScenes(buf, output.Options{Format: output.Table}, scenes); err != nil {
+		t.Fatalf("listScenes() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Shift Change  1         1") {
+		t.Errorf("Unexpected table output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := listScenes(buf, output.Options{Format: output.JSON}, scenes); err != nil {
+		t.Fatalf("listScenes() error = %v", err)
+	}
+
+	var records []sceneRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Expected JSON array, got %q: %v", buf.String(), err)
+	}
+	if len(records) != 1 || records[0].PTZ[0].Preset != "5" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestApplyScene(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	scene := config.Scene{
+		Name:     "Shift Change",
+		Switches: []config.SceneSwitch{{Viewport: "Tower", Liveview: "All Cameras"}},
+		PTZ:      []config.ScenePTZ{{Camera: "Front Door", Preset: "home"}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := applyScene(context.Background(), c, buf, output.Options{Format: output.Table}, scene); err != nil {
+		t.Fatalf("applyScene() error = %v", err)
+	}
+
+	for _, want := range []string{"Tower", "All Cameras", "home position", "Successfully applied scene Shift Change (2 actions)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain '%s', got %q", want, buf.String())
+		}
+	}
+}
+
+func TestApplySceneFailures(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	scene := config.Scene{
+		Name: "Night",
+		PTZ:  []config.ScenePTZ{{Camera: "Front Door", Preset: "1"}, {Camera: "Driveway", Preset: "2"}},
+	}
+
+	buf := new(bytes.Buffer)
+	err := applyScene(context.Background(), c, buf, output.Options{Format: output.JSON}, scene)
+	if err == nil || !errors.As(err, new(reportedError)) {
+		t.Fatalf("Expected reported error, got %v", err)
+	}
+
+	var results []actions.Result
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("Expected JSON results, got %q: %v", buf.String(), err)
+	}
+	if len(results) != 2 || !results[0].Success || results[1].Success {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	// Unknown targets fail before anything runs
+	missing := config.Scene{Name: "Bad", Switches: []config.SceneSwitch{{Viewport: "Attic", Liveview: "All Cameras"}}}
+	if err := applyScene(context.Background(), c, new(bytes.Buffer), output.Options{Format: output.Table}, missing); err == nil {
+		t.Error("Expected error for unknown viewport")
+	}
+}
+
+func TestApplySceneDryRun(t *testing.T) {
+	server := newSceneServer(t)
+
+	requests := new(bytes.Buffer)
+	c := client.NewClient(server.URL, "test-token")
+	c.DryRun = true
+	c.DryRunOutput = requests
+
+	// Driveway moves fail on the server, so success means nothing was sent
+	scene := config.Scene{
+		Name: "Night",
+		PTZ:  []config.ScenePTZ{{Camera: "Driveway", Preset: "2"}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := applyScene(context.Background(), c, buf, output.Options{Format: output.Table}, scene); err != nil {
+		t.Fatalf("applyScene() error = %v", err)
+	}
+
+	for _, want := range []string{"dry run", "Dry run: scene Night would run 1 actions"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain '%s', got %q", want, buf.String())
+		}
+	}
+
+	if !strings.Contains(requests.String(), "DRY RUN: POST "+server.URL+"/proxy/protect/integration/v1/cameras/cam2/ptz/goto/2") {
+		t.Errorf("Expected printed PTZ request, got %q", requests.String())
+	}
+}
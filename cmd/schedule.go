@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:     "schedule",
+	Aliases: []string{"schedules"},
+	Short:   "Run viewport switches, PTZ moves and scenes on a schedule",
+	Long: `Run the schedules defined under "schedules:" in the configuration file.
+
+Each schedule runs at a cron expression ("cron: 0 22 * * mon-fri") or a time
+of day with optional days ("at: 22:00", "days: [weekdays]"), in its own
+timezone or the local one, and applies a scene, switches and PTZ moves.`,
+	Args: cobra.NoArgs,
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run schedules in the foreground",
+	Long: `Run schedules in the foreground until interrupted, printing the result of
+each run. Names are resolved on every run, and a failed run is reported
+without stopping the daemon.
+
+Results are printed as text, or as newline-delimited JSON (NDJSON) with
+--output=json.`,
+	Example: `  protect schedule run
+  protect schedule run --output=json >> schedule.log`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if opts.Template != "" || (opts.Format != output.Table && opts.Format != output.JSON) {
+			return fmt.Errorf("schedule run supports only --output=table or --output=json")
+		}
+
+		if err := validateConfig(config.Get().ValidateSchedules); err != nil {
+			return err
+		}
+
+		entries, err := schedule.FromConfig(config.Get())
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runSchedules(ctx, c, cmd.OutOrStdout(), opts, entries, schedule.Options{})
+	},
+}
+
+var scheduleNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Show upcoming schedule runs",
+	Example: `  protect schedule next
+  protect schedule next --count=20 --output=json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		count, _ := cmd.Flags().GetInt("count")
+		if count < 1 {
+			return fmt.Errorf("invalid --count value: %d (must be at least 1)", count)
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := validateConfig(config.Get().ValidateSchedules); err != nil {
+			return err
+		}
+
+		entries, err := schedule.FromConfig(config.Get())
+		if err != nil {
+			return err
+		}
+
+		return listNextRuns(cmd.OutOrStdout(), opts, schedule.Next(entries, time.Now(), count), time.Now())
+	},
+}
+
+func init() {
+	scheduleNextCmd.Flags().Int("count", 10, "Number of upcoming runs to show (use --count=<value>)")
+
+	scheduleCmd.AddCommand(scheduleRunCmd, scheduleNextCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+// nextRunRecord is the structured form of an upcoming run
+type nextRunRecord struct {
+	Schedule string    `json:"schedule" yaml:"schedule"`
+	Time     time.Time `json:"time" yaml:"time"`
+	Timezone string    `json:"timezone" yaml:"timezone"`
+	Actions  int       `json:"actions" yaml:"actions"`
+}
+
+func listNextRuns(out io.Writer, opts output.Options, runs []schedule.Upcoming, now time.Time) error {
+	if opts.Structured() {
+		listing := output.Listing{Headers: []string{"schedule", "time", "timezone", "actions"}}
+		records := make([]nextRunRecord, 0, len(runs))
+		for _, r := range runs {
+			record := nextRunRecord{Schedule: r.Entry.Name, Time: r.Time, Timezone: r.Time.Location().String(), Actions: len(r.Entry.Actions)}
+			records = append(records, record)
+			listing.Rows = append(listing.Rows, []string{record.Schedule, record.Time.Format(time.RFC3339), record.Timezone, strconv.Itoa(record.Actions)})
+			listing.Names = append(listing.Names, record.Schedule)
+		}
+		listing.Items = records
+		return output.Write(out, opts, listing)
+	}
+
+	if len(runs) == 0 {
+		fmt.Fprintln(out, "No upcoming schedule runs")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		fmt.Fprintln(w, "TIME\tSCHEDULE\tIN\tACTIONS")
+		fmt.Fprintln(w, "----\t--------\t--\t-------")
+	}
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", r.Time.Format("Mon 2006-01-02 15:04 MST"), r.Entry.Name, r.Time.Sub(now).Round(time.Minute), len(r.Entry.Actions))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// scheduleRunRecord is the structured form of a completed run
+type scheduleRunRecord struct {
+	Schedule string           `json:"schedule"`
+	Time     time.Time        `json:"time"`
+	Success  bool             `json:"success"`
+	Error    string           `json:"error,omitempty"`
+	Results  []actions.Result `json:"results"`
+}
+
+// runSchedules runs the schedule daemon, printing each run as it finishes
+func runSchedules(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, entries []schedule.Entry, schedOpts schedule.Options) error {
+	enc := json.NewEncoder(out)
+
+	if opts.Format == output.Table {
+		fmt.Fprintf(out, "Running %d schedules\n", len(entries))
+	}
+
+	schedOpts.OnRun = func(e schedule.Entry, results []actions.Result, err error) {
+		record := scheduleRunRecord{Schedule: e.Name, Time: time.Now(), Success: err == nil, Results: results}
+		if record.Results == nil {
+			record.Results = []actions.Result{}
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		if opts.Format == output.JSON {
+			enc.Encode(record)
+			return
+		}
+
+		status := fmt.Sprintf("ok (%d actions)", len(results))
+		if c.DryRun {
+			status = fmt.Sprintf("dry run (%d actions)", len(results))
+		}
+		if err != nil {
+			status = "failed: " + err.Error()
+		}
+		fmt.Fprintf(out, "%s  %s  %s\n", record.Time.Format("2006-01-02 15:04:05"), e.Name, status)
+	}
+
+	err := schedule.Run(ctx, c, entries, schedOpts)
+	if err == context.Canceled {
+		// Interrupting the daemon is the normal way to stop it
+		err = nil
+	}
+	return err
+}
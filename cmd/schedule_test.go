@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/schedule"
+)
+
+func TestScheduleCommands(t *testing.T) {
+	names := make(map[string]bool)
+	for _, cmd := range scheduleCmd.Commands() {
+		names[cmd.Name()] = true
+	}
+
+	for _, want := range []string{"run", "next"} {
+		if !names[want] {
+			t.Errorf("Expected 'schedule %s' command to be registered", want)
+		}
+	}
+}
+
+func TestListNextRuns(t *testing.T) {
+	cron, err := schedule.ParseCron("0 22 * * *", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	entries := []schedule.Entry{{Name: "evening", Cron: cron, Actions: []actions.Action{actions.Switch("Tower", "Driveway")}}}
+	now := time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC)
+	runs := schedule.Next(entries, now, 2)
+
+	buf := new(bytes.Buffer)
+	if err := listNextRuns(buf, output.Options{Format: output.Table}, runs, now); err != nil {
+		t.Fatalf("listNextRuns() error = %v", err)
+	}
+	for _, want := range []string{"Wed 2025-01-15 22:00 UTC  evening   2h0m0s", "Thu 2025-01-16 22:00 UTC  evening   26h0m0s"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got %q", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := listNextRuns(buf, output.Options{Format: output.JSON}, runs, now); err != nil {
+		t.Fatalf("listNextRuns() error = %v", err)
+	}
+	var records []nextRunRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Expected JSON array, got %q: %v", buf.String(), err)
+	}
+	if len(records) != 2 || records[0].Schedule != "evening" || records[0].Timezone != "UTC" || records[0].Actions != 1 {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestRunSchedules(t *testing.T) {
+	server := newListServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	cron, err := schedule.ParseCron("*/5 * * * *", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	entries := []schedule.Entry{{Name: "lobby", Cron: cron, Actions: []actions.Action{actions.Switch("Tower", "Driveway")}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Jump the clock straight to each run, stopping after the first
+	clock := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	after := func(d time.Duration) <-chan time.Time {
+		clock = clock.Add(d)
+		cancelAfter := clock.Minute() == 10
+		ch := make(chan time.Time, 1)
+		ch <- clock
+		if cancelAfter {
+			cancel()
+		}
+		return ch
+	}
+
+	buf := new(bytes.Buffer)
+	opts := schedule.Options{Now: func() time.Time { return clock }, After: after}
+	if err := runSchedules(ctx, c, buf, output.Options{Format: output.JSON}, entries, opts); err != nil {
+		t.Fatalf("runSchedules() error = %v", err)
+	}
+
+	var record scheduleRunRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if record.Schedule != "lobby" || !record.Success || len(record.Results) != 1 || record.Results[0].ValueID != "lv2" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+}
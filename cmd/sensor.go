@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var sensorCmd = &cobra.Command{
+	Use:     "sensor",
+	Aliases: []string{"sensors"},
+	Short:   "List sensors",
+	Long:    `List door, window and environmental sensors.`,
+	Args:    cobra.NoArgs,
+}
+
+var sensorListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List sensors and their readings",
+	Long: `List sensors with their connection state, open/closed state, battery level
+and temperature, humidity and light readings.
+
+Equivalent to the legacy --list=sensors flag.`,
+	Example: `  protect sensor list
+  protect sensor list --watch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showIDs, _ := cmd.Flags().GetBool("show-ids")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchCommand(cmd, c, opts, "sensors", showIDs)
+		}
+
+		return listSensors(c, cmd.OutOrStdout(), opts, showIDs)
+	},
+}
+
+func init() {
+	sensorListCmd.Flags().Bool("show-ids", false, "Show IDs when listing")
+	addWatchFlags(sensorListCmd)
+
+	sensorCmd.AddCommand(sensorListCmd)
+	rootCmd.AddCommand(sensorCmd)
+}
+
+// sensorRecord is the structured form of a sensor listing entry
+type sensorRecord struct {
+	Name           string   `json:"name" yaml:"name"`
+	ID             string   `json:"id" yaml:"id"`
+	State          string   `json:"state" yaml:"state"`
+	Opened         bool     `json:"opened" yaml:"opened"`
+	BatteryPercent *int     `json:"batteryPercent,omitempty" yaml:"batteryPercent,omitempty"`
+	BatteryLow     bool     `json:"batteryLow" yaml:"batteryLow"`
+	Temperature    *float64 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	Humidity       *float64 `json:"humidity,omitempty" yaml:"humidity,omitempty"`
+	Light          *float64 `json:"light,omitempty" yaml:"light,omitempty"`
+}
+
+func listSensors(c *client.Client, out io.Writer, opts output.Options, showIDs bool) error {
+	log := logger.Get()
+
+	sensors, err := c.ListSensors()
+	if err != nil {
+		return err
+	}
+
+	if len(sensors) == 0 && !opts.Structured() {
+		fmt.Fprintln(out, "No sensors found")
+		return nil
+	}
+
+	defer log.Infow("Listed sensors", "count", len(sensors))
+
+	records := sensorItems(sensors)
+
+	if opts.Structured() {
+		listing := output.Listing{
+			Items:   records,
+			Headers: []string{"name", "id", "state", "opened", "battery_percent", "battery_low", "temperature", "humidity", "light"},
+		}
+		for _, r := range records {
+			listing.Rows = append(listing.Rows, []string{
+				r.Name, r.ID, r.State, strconv.FormatBool(r.Opened), optionalInt(r.BatteryPercent),
+				strconv.FormatBool(r.BatteryLow), optionalFloat(r.Temperature), optionalFloat(r.Humidity), optionalFloat(r.Light),
+			})
+			listing.Names = append(listing.Names, r.Name)
+		}
+		return output.Write(out, opts, listing)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		writeTableHeaders(w, sensorHeaders(showIDs))
+	}
+	for _, r := range records {
+		writeTableRow(w, sensorRow(r, showIDs))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// sensorItems converts sensors to structured records
+func sensorItems(sensors []client.Sensor) []sensorRecord {
+	records := make([]sensorRecord, 0, len(sensors))
+	for _, s := range sensors {
+		records = append(records, sensorRecord{
+			Name:           s.Name,
+			ID:             s.ID,
+			State:          s.State,
+			Opened:         s.IsOpened,
+			BatteryPercent: s.BatteryStatus.Percentage,
+			BatteryLow:     s.BatteryStatus.IsLow,
+			Temperature:    s.Stats.Temperature.Value,
+			Humidity:       s.Stats.Humidity.Value,
+			Light:          s.Stats.Light.Value,
+		})
+	}
+	return records
+}
+
+// sensorHeaders are the sensor table's column headers
+func sensorHeaders(showIDs bool) []string {
+	headers := []string{"NAME", "STATE", "OPEN", "BATTERY", "TEMPERATURE", "HUMIDITY", "LIGHT"}
+	if showIDs {
+		headers = append(headers, "ID")
+	}
+	return headers
+}
+
+// sensorRow formats a sensor for the table
+func sensorRow(r sensorRecord, showIDs bool) []string {
+	open := "no"
+	if r.Opened {
+		open = "yes"
+	}
+
+	battery := "-"
+	if r.BatteryPercent != nil {
+		battery = strconv.Itoa(*r.BatteryPercent) + "%"
+	}
+	if r.BatteryLow {
+		battery += " (low)"
+	}
+
+	row := []string{r.Name, r.State, open, battery, withUnit(r.Temperature, "°C"), withUnit(r.Humidity, "%"), withUnit(r.Light, " lx")}
+	if showIDs {
+		row = append(row, r.ID)
+	}
+	return row
+}
+
+// withUnit formats an optional reading, or "-" when there is none
+func withUnit(v *float64, unit string) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64) + unit
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
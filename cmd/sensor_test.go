@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+)
+
+func TestListSensors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"s1","name":"Back Door","state":"CONNECTED","isOpened":true,"batteryStatus":{"percentage":12,"isLow":true},"stats":{"temperature":{"value":21.5},"humidity":{"value":40},"light":{"value":null}}},
+			{"id":"s2","name":"Garage","state":"DISCONNECTED"}
+		]`))
+	}))
+	defer server.Close()
+	c := client.NewClient(server.URL, "test-token")
+
+	tests := []struct {
+		name string
+		opts output.Options
+		want string
+	}{
+		{
+			name: "Table without headers",
+			opts: output.Options{Format: output.Table, NoHeaders: true},
+			want: "Back Door  CONNECTED     yes  12% (low)  21.5°C  40%  -\n" +
+				"Garage     DISCONNECTED  no   -          -       -    -\n",
+		},
+		{
+			name: "CSV",
+			opts: output.Options{Format: output.CSV},
+			want: "name,id,state,opened,battery_percent,battery_low,temperature,humidity,light\n" +
+				"Back Door,s1,CONNECTED,true,12,true,21.5,40,\n" +
+				"Garage,s2,DISCONNECTED,false,,false,,,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := listSensors(c, buf, tt.opts, false); err != nil {
+				t.Fatalf("listSensors() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("listSensors() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
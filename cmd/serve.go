@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// defaultListen is the address served when neither --listen nor
+// serve.listen is set
+const defaultListen = ":8787"
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a REST API for switching viewports and moving PTZ cameras",
+	Long: `Serve a small authenticated HTTP API, so Stream Deck, Companion and Home
+Assistant can control Protect without starting the CLI on every press.
+
+Endpoints (JSON in and out):
+  GET  /viewports       Viewports and the liveview each is showing
+  GET  /liveviews       Liveviews
+  POST /switch          {"viewport": "Tower", "liveview": "Driveway"}
+  POST /ptz             {"camera": "Front Door", "preset": 5}  (or "home")
+  POST /scene/{name}    Apply a configured scene
+
+Every request needs an "Authorization: Bearer <token>" header matching one of
+the tokens from --bearer-token or serve.tokens in the configuration file.
+--token is still the console API token, as on every other command. Serve over
+TLS with --tls-cert and --tls-key.`,
+	Example: `  protect serve --listen=:8787 --bearer-token="$PROTECT_SERVE_TOKEN"
+  protect serve --tls-cert=server.crt --tls-key=server.key
+  curl -H "Authorization: Bearer $TOKEN" -d '{"viewport":"Tower","liveview":"Driveway"}' http://localhost:8787/switch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+		tokens, _ := cmd.Flags().GetStringArray("bearer-token")
+		tlsCert, _ := cmd.Flags().GetString("tls-cert")
+		tlsKey, _ := cmd.Flags().GetString("tls-key")
+
+		// Scenes can be applied over the API
+		for _, validate := range []func() error{config.Get().ValidateServe, config.Get().ValidateScenes} {
+			if err := validateConfig(validate); err != nil {
+				return err
+			}
+		}
+
+		settings, err := serveSettings(config.Get().Serve, listen, tokens, tlsCert, tlsKey)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		srv, err := server.New(c, config.Get(), settings.Tokens)
+		if err != nil {
+			return fmt.Errorf("%w (use --bearer-token=<value> or serve.tokens in the config file)", err)
+		}
+
+		ln, err := net.Listen("tcp", settings.Listen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", settings.Listen, err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runServer(ctx, cmd.OutOrStdout(), ln, srv.Handler(), settings)
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("listen", "", "Address to listen on (use --listen=<host:port>; default "+defaultListen+")")
+	// Not --token, which is the console API token on every command
+	serveCmd.Flags().StringArray("bearer-token", nil, "Bearer token to accept (use --bearer-token=<value>; repeatable)")
+	serveCmd.Flags().String("tls-cert", "", "TLS certificate file (use --tls-cert=<path>)")
+	serveCmd.Flags().String("tls-key", "", "TLS private key file (use --tls-key=<path>)")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+// serveSettings merges the command-line flags over the configuration file
+func serveSettings(settings config.Serve, listen string, tokens []string, tlsCert, tlsKey string) (config.Serve, error) {
+	if listen != "" {
+		settings.Listen = listen
+	}
+	if settings.Listen == "" {
+		settings.Listen = defaultListen
+	}
+
+	settings.Tokens = append(append([]string(nil), tokens...), settings.Tokens...)
+
+	if tlsCert != "" || tlsKey != "" {
+		settings.TLSCert, settings.TLSKey = tlsCert, tlsKey
+	}
+	if (settings.TLSCert == "") != (settings.TLSKey == "") {
+		return settings, fmt.Errorf("--tls-cert and --tls-key must be used together")
+	}
+
+	return settings, nil
+}
+
+// runServer serves handler on ln until ctx is cancelled, then lets
+// in-flight requests finish
+func runServer(ctx context.Context, out io.Writer, ln net.Listener, handler http.Handler, settings config.Serve) error {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	scheme := "http"
+	if settings.TLSCert != "" {
+		scheme = "https"
+	}
+	fmt.Fprintf(out, "Serving on %s://%s\n", scheme, ln.Addr())
+
+	errs := make(chan error, 1)
+	go func() {
+		if settings.TLSCert != "" {
+			errs <- srv.ServeTLS(ln, settings.TLSCert, settings.TLSKey)
+		} else {
+			errs <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/config"
+	"github.com/spf13/pflag"
+)
+
+func TestServeSettings(t *testing.T) {
+	fromConfig := config.Serve{Listen: "127.0.0.1:9000", Tokens: []string{"config-token"}, TLSCert: "a.crt", TLSKey: "a.key"}
+
+	got, err := serveSettings(fromConfig, "", []string{"flag-token"}, "", "")
+	if err != nil {
+		t.Fatalf("serveSettings() error = %v", err)
+	}
+	if got.Listen != "127.0.0.1:9000" || strings.Join(got.Tokens, ",") != "flag-token,config-token" || got.TLSCert != "a.crt" {
+		t.Errorf("Unexpected settings: %+v", got)
+	}
+
+	got, err = serveSettings(config.Serve{}, "", nil, "", "")
+	if err != nil || got.Listen != defaultListen {
+		t.Errorf("Expected the default address, got %+v (%v)", got, err)
+	}
+
+	got, err = serveSettings(fromConfig, ":8080", nil, "b.crt", "b.key")
+	if err != nil || got.Listen != ":8080" || got.TLSCert != "b.crt" || got.TLSKey != "b.key" {
+		t.Errorf("Expected the flags to win, got %+v (%v)", got, err)
+	}
+
+	if _, err := serveSettings(config.Serve{}, "", nil, "b.crt", ""); err == nil {
+		t.Error("Expected an error for a certificate without a key")
+	}
+}
+
+func TestRunServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := new(bytes.Buffer)
+	done := make(chan error, 1)
+	go func() { done <- runServer(ctx, out, ln, handler, config.Serve{}) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("Body = %q, want pong", body)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("runServer() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Serving on http://127.0.0.1:") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
+
+func TestServeTokenFlags(t *testing.T) {
+	defer func() {
+		serveCmd.Flags().Set("token", "")
+		serveCmd.Flags().Lookup("bearer-token").Value.(pflag.SliceValue).Replace(nil)
+	}()
+
+	if err := serveCmd.ParseFlags([]string{"-t", "console-key", "--bearer-token=rest-token"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+
+	cfg, err := loadConfig(serveCmd)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.APIToken != "console-key" {
+		t.Errorf("APIToken = %q, want console-key", cfg.APIToken)
+	}
+
+	tokens, _ := serveCmd.Flags().GetStringArray("bearer-token")
+	if len(tokens) != 1 || tokens[0] != "rest-token" {
+		t.Errorf("Bearer tokens = %q, want [rest-token]", tokens)
+	}
+}
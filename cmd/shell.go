@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/shell"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// shellPrompt is shown before each line in an interactive shell
+const shellPrompt = "protect> "
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive prompt for switching viewports and moving PTZ cameras",
+	Long: `Start an interactive prompt that keeps one connection and a live copy of
+the console's viewports, liveviews and cameras, so commands run without
+looking names up again:
+
+  switch Lobby Entrance
+  ptz "Front Door" home
+  ls viewports
+
+Tab completes commands and names, and history is kept in
+$XDG_STATE_HOME/protect/shell_history (~/.local/state/protect/shell_history).
+Errors are shown inline and the prompt carries on. Type "help" for every
+command and "exit" or Ctrl-D to leave.
+
+When input is not a terminal, each line is run in turn and the command exits
+non-zero if any of them failed.`,
+	Example: `  protect shell
+  printf 'switch Lobby Entrance\nptz "Front Door" home\n' | protect shell`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		inv := inventory.New()
+		go func() {
+			if err := inv.Run(ctx, c, nil); err != nil && ctx.Err() == nil {
+				logger.Get().Warnw("Live inventory unavailable", "error", err)
+			}
+		}()
+
+		if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			return interactiveShell(ctx, c, inv, f, cmd.OutOrStdout())
+		}
+
+		return runShellLines(ctx, shell.New(c, inv, cmd.OutOrStdout()), cmd.InOrStdin(), cmd.ErrOrStderr())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
+
+// interactiveShell reads commands from a terminal with line editing, tab
+// completion and persistent history
+func interactiveShell(ctx context.Context, c *client.Client, inv *inventory.Inventory, in *os.File, out io.Writer) error {
+	path, err := shell.DefaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	history, err := shell.LoadHistory(path)
+	if err != nil {
+		return err
+	}
+
+	fd := int(in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, out}, shellPrompt)
+	t.History = history
+	if width, height, err := term.GetSize(fd); err == nil {
+		t.SetSize(width, height)
+	}
+
+	// Dry-run requests are printed through the terminal so lines stay aligned
+	if c.DryRun {
+		c.DryRunOutput = t
+	}
+
+	sh := shell.New(c, inv, t)
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, candidates := sh.Complete(line, pos)
+		if len(candidates) > 0 {
+			fmt.Fprintln(t, strings.Join(candidates, "  "))
+		}
+		return newLine, newPos, true
+	}
+
+	fmt.Fprintln(t, "Type 'help' for commands, Tab to complete and Ctrl-D to exit.")
+	for {
+		line, err := t.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+
+		if err := sh.Exec(ctx, line); err != nil {
+			if errors.Is(err, shell.ErrExit) {
+				return nil
+			}
+			fmt.Fprintf(t, "Error: %v\n", err)
+		}
+	}
+}
+
+// runShellLines runs each line of in, reporting errors to errOut and
+// carrying on. It fails if any line failed.
+func runShellLines(ctx context.Context, sh *shell.Shell, in io.Reader, errOut io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	lineNo, failed := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		if err := sh.Exec(ctx, scanner.Text()); err != nil {
+			if errors.Is(err, shell.ErrExit) {
+				break
+			}
+			fmt.Fprintf(errOut, "Error: line %d: %v\n", lineNo, err)
+			failed++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d commands failed", failed)
+	}
+	return nil
+}
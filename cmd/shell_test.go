@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/shell"
+)
+
+func TestRunShellLines(t *testing.T) {
+	server := newSceneServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	sh := shell.New(c, inventory.New(), out)
+	input := "switch Tower \"All Cameras\"\nptz Driveway 1\n\nptz \"Front Door\" home\nexit\nptz \"Front Door\" 2\n"
+
+	err := runShellLines(context.Background(), sh, strings.NewReader(input), errOut)
+	if err == nil || err.Error() != "1 commands failed" {
+		t.Errorf("Expected one failed command, got %v", err)
+	}
+
+	want := "Switched viewport Tower to liveview All Cameras\nMoved camera Front Door to home position\n"
+	if out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+	if !strings.HasPrefix(errOut.String(), "Error: line 2: failed to move camera:") {
+		t.Errorf("Expected the failure to be reported inline, got %q", errOut.String())
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Save and restore viewer and PTZ patrol state",
+	Long: `Save the liveview shown on every viewer and the active patrol of every PTZ
+camera to a snapshot, and restore it later.
+
+Snapshots are YAML, or JSON when the file name ends in .json. Without a file,
+$XDG_STATE_HOME/protect/state.yaml (~/.local/state/protect/state.yaml) is used.`,
+	Args: cobra.NoArgs,
+}
+
+var stateSaveCmd = &cobra.Command{
+	Use:   "save [file]",
+	Short: "Save the current state to a snapshot",
+	Example: `  protect state save
+  protect state save before-event.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := statePath(args)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		return saveState(c, cmd.OutOrStdout(), path)
+	},
+}
+
+var stateRestoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Restore a saved snapshot",
+	Long: `Restore a saved snapshot. Viewers and cameras that already match are
+skipped; everything else is resolved before anything changes and then
+switched back concurrently.`,
+	Example: `  protect state restore
+  protect state restore before-event.json --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := statePath(args)
+		if err != nil {
+			return err
+		}
+
+		snap, err := state.Load(path)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return restoreState(ctx, c, cmd.OutOrStdout(), opts, snap)
+	},
+}
+
+func init() {
+	stateCmd.AddCommand(stateSaveCmd, stateRestoreCmd)
+	rootCmd.AddCommand(stateCmd)
+}
+
+// statePath returns the snapshot file from the arguments or the default
+func statePath(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	return state.DefaultPath()
+}
+
+// saveState captures the current state and writes it to path
+func saveState(c *client.Client, out io.Writer, path string) error {
+	snap, err := state.Capture(c)
+	if err != nil {
+		return err
+	}
+
+	if err := state.Save(path, snap); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Saved %d viewers and %d PTZ cameras to %s\n", len(snap.Viewers), len(snap.Patrols), path)
+	return nil
+}
+
+// restoreState switches everything that differs from the snapshot back
+func restoreState(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, snap *state.Snapshot) error {
+	log := logger.Get()
+
+	// Names are used when IDs don't match, so this is not fatal
+	if snap.URL != "" && snap.URL != c.BaseURL {
+		log.Warnw("Snapshot was saved from a different console", "snapshot", snap.URL, "url", c.BaseURL)
+	}
+
+	targets, matched, err := state.Plan(c, snap)
+	if err != nil {
+		return fmt.Errorf("failed to resolve snapshot: %w", err)
+	}
+
+	results := actions.Execute(ctx, c, targets, actions.DefaultWorkers)
+
+	if opts.Structured() || len(results) > 0 {
+		if err := writeResults(out, opts, results); err != nil {
+			return err
+		}
+	}
+
+	if failed := actions.Failed(results); failed > 0 {
+		err := fmt.Errorf("%d of %d restore actions failed", failed, len(results))
+		if opts.Structured() {
+			return reportedError{err}
+		}
+		return err
+	}
+
+	if !opts.Structured() {
+		verb := "Restored"
+		if c.DryRun {
+			verb = "Dry run: would restore"
+		}
+		fmt.Fprintf(out, "%s %d changes (%d already matched)\n", verb, len(results), matched)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/protecttest"
+	"github.com/methridge/protect/internal/state"
+)
+
+// newStateServer serves a viewer showing the current value of liveview
+func newStateServer(t *testing.T, liveview *string) *protecttest.Server {
+	var mu sync.Mutex
+	server := protecttest.New(t)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door","modelKey":"camera","activePatrolSlot":null}]`)
+	server.Handle("GET viewers", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(`[{"id":"vp1","name":"Tower","liveview":"` + *liveview + `"}]`))
+	})
+	return server
+}
+
+func TestStateCommands(t *testing.T) {
+	names := make(map[string]bool)
+	for _, cmd := range stateCmd.Commands() {
+		names[cmd.Name()] = true
+	}
+
+	for _, want := range []string{"save", "restore"} {
+		if !names[want] {
+			t.Errorf("Expected 'state %s' command to be registered", want)
+		}
+	}
+}
+
+func TestSaveRestoreState(t *testing.T) {
+	liveview := "lv1"
+	server := newStateServer(t, &liveview)
+	c := client.NewClient(server.URL, "test-token")
+
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	buf := new(bytes.Buffer)
+	if err := saveState(c, buf, path); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Saved 1 viewers and 1 PTZ cameras") {
+		t.Errorf("Unexpected save output: %q", buf.String())
+	}
+
+	snap, err := state.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Nothing to do while the viewer still matches
+	buf.Reset()
+	if err := restoreState(context.Background(), c, buf, output.Options{Format: output.Table}, snap); err != nil {
+		t.Fatalf("restoreState() error = %v", err)
+	}
+	changes := server.Requests()
+	if buf.String() != "Restored 0 changes (2 already matched)\n" || len(changes) != 0 {
+		t.Errorf("Expected nothing to change, got %q and %v", buf.String(), changes)
+	}
+
+	liveview = "lv2"
+	buf.Reset()
+	if err := restoreState(context.Background(), c, buf, output.Options{Format: output.Table}, snap); err != nil {
+		t.Fatalf("restoreState() error = %v", err)
+	}
+
+	for _, want := range []string{"Tower", "All Cameras", "Restored 1 changes (1 already matched)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain '%s', got %q", want, buf.String())
+		}
+	}
+	changes = server.Requests()
+	if len(changes) != 1 {
+		t.Errorf("Expected one switch, got %v", changes)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/talkback"
+	"github.com/spf13/cobra"
+)
+
+var talkbackCmd = &cobra.Command{
+	Use:   "talkback <camera>",
+	Short: "Play a WAV file through a camera speaker",
+	Long: `Start a talkback session on a camera and stream a pre-recorded WAV file to it.
+
+The WAV file must be mono and match the session's codec, sampling rate and
+bit depth (for example 8 kHz mu-law for a pcmu session).
+
+Only cameras whose talkback session uses pcmu, pcma or pcm/l16 can be played.
+Cameras that ask for a compressed codec such as opus or aac are rejected.
+The API only reports the codec when a session is created, so the file is
+checked first and the codec right after the session opens, before any audio
+is sent.`,
+	Example: `  protect talkback "Front Gate" --file=message.wav
+  protect talkback cam-id --file=closing.wav`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingleCamera,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log := logger.Get()
+
+		file, _ := cmd.Flags().GetString("file")
+		if file == "" {
+			return fmt.Errorf("--file flag is required")
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open audio file: %w", err)
+		}
+		defer f.Close()
+
+		wav, err := talkback.ParseWAV(f)
+		if err != nil {
+			return err
+		}
+
+		// Check what can be checked before a session is opened
+		if err := talkback.ValidateAudio(wav); err != nil {
+			return fmt.Errorf("audio file cannot be used for talkback: %w", err)
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		cameras, err := c.ListPTZCameras()
+		if err != nil {
+			return err
+		}
+
+		var cameraID, cameraName string
+		for _, cam := range cameras {
+			if cam.ID == args[0] || cam.Name == args[0] {
+				cameraID = cam.ID
+				cameraName = cam.Name
+				break
+			}
+		}
+
+		if cameraID == "" {
+			return fmt.Errorf("camera not found: %s", args[0])
+		}
+
+		session, err := c.CreateTalkbackSession(cameraID)
+		if err != nil {
+			return err
+		}
+
+		// A dry run creates no session, so the audio can only be checked
+		// against what every session requires
+		if c.DryRun && session.Codec == "" {
+			fmt.Fprintln(cmd.OutOrStdout(), "Dry run: codec and sample rate not checked, since no talkback session was created")
+		} else {
+			log.Infow("Talkback session created", "url", session.URL, "codec", session.Codec, "samplingRate", session.SamplingRate, "bitsPerSample", session.BitsPerSample)
+			if err := talkback.Validate(session, wav); err != nil {
+				return fmt.Errorf("audio file does not match talkback session: %w", err)
+			}
+		}
+
+		// A dry run has no session to stream to
+		if c.DryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Dry run: would play %s (%s) on camera '%s'\n", file, wav.Duration().Round(100*time.Millisecond), cameraName)
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := talkback.Stream(ctx, session, wav); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Successfully played %s (%s) on camera '%s'\n", file, wav.Duration().Round(100*time.Millisecond), cameraName)
+		return nil
+	},
+}
+
+func init() {
+	talkbackCmd.Flags().String("file", "", "WAV file to play (use --file=<path>)")
+	rootCmd.AddCommand(talkbackCmd)
+}
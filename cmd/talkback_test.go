@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTalkbackCommand(t *testing.T) {
+	if talkbackCmd.Flags().Lookup("file") == nil {
+		t.Error("Expected 'file' flag to be registered")
+	}
+
+	if err := talkbackCmd.Args(talkbackCmd, []string{}); err == nil {
+		t.Error("Expected error when no camera is given")
+	}
+
+	if err := talkbackCmd.Args(talkbackCmd, []string{"Front Gate"}); err != nil {
+		t.Errorf("Expected one camera argument to be accepted, got %v", err)
+	}
+}
+
+// writeWAV writes a short 16-bit PCM file with the given channel count
+func writeWAV(t *testing.T, channels uint16) string {
+	t.Helper()
+
+	const rate, bits = 16000, 16
+	data := make([]byte, 320*int(channels))
+	blockAlign := channels * bits / 8
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), channels, uint32(rate), uint32(rate) * uint32(blockAlign), blockAlign, uint16(bits)} {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	path := filepath.Join(t.TempDir(), "clip.wav")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTalkbackDryRunValidates(t *testing.T) {
+	server := newSceneServer(t)
+
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	defer func() {
+		rootCmd.SetArgs([]string{})
+		rootCmd.PersistentFlags().Set("url", "")
+		rootCmd.PersistentFlags().Set("token", "")
+		rootCmd.PersistentFlags().Set("dry-run", "false")
+		talkbackCmd.Flags().Set("file", "")
+	}()
+
+	args := []string{"talkback", "Front Door", "--dry-run", "--url=" + server.URL, "--token=test-token"}
+
+	rootCmd.SetArgs(append(args, "--file="+writeWAV(t, 2)))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "mono") {
+		t.Errorf("Expected stereo audio to fail a dry run, got %v", err)
+	}
+
+	out.Reset()
+	rootCmd.SetArgs(append(args, "--file="+writeWAV(t, 1)))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("talkback --dry-run error = %v", err)
+	}
+	if !strings.Contains(out.String(), "not checked") || !strings.Contains(out.String(), "Dry run: would play") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/tour"
+	"github.com/spf13/cobra"
+)
+
+var tourCmd = &cobra.Command{
+	Use:   "tour",
+	Short: "Cycle a viewport through liveviews",
+	Long: `Cycle a viewport through liveviews on a timer, for viewers whose own
+liveview cycling can't be configured.
+
+Each liveview is shown for --interval unless it sets its own dwell time with
+=<duration>. Send SIGUSR1 to pause the tour and SIGUSR2 to resume it. When the
+tour ends (Ctrl+C, SIGTERM or --cycles), the viewport is switched back to the
+liveview it was showing before the tour started.`,
+	Example: `  protect tour --viewport=Lobby --liveviews="All Cameras,Driveway,Garage" --interval=20s
+  protect tour --viewport=Lobby --liveviews="All Cameras=1m,Driveway=15s" --shuffle
+  kill -USR1 <pid>    # pause
+  kill -USR2 <pid>    # resume`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viewport, _ := cmd.Flags().GetString("viewport")
+		liveviews, _ := cmd.Flags().GetString("liveviews")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		shuffle, _ := cmd.Flags().GetBool("shuffle")
+		cycles, _ := cmd.Flags().GetInt("cycles")
+
+		if viewport == "" || liveviews == "" {
+			return fmt.Errorf("--viewport and --liveviews are required")
+		}
+
+		if cycles < 0 {
+			return fmt.Errorf("invalid --cycles value: %d (must be 0 or more)", cycles)
+		}
+
+		steps, err := tour.ParseSteps(liveviews, interval)
+		if err != nil {
+			return err
+		}
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runTour(ctx, c, cmd.OutOrStdout(), viewport, steps, tour.Options{Shuffle: shuffle, Cycles: cycles})
+	},
+}
+
+func init() {
+	tourCmd.Flags().String("viewport", "", "Viewport to tour (use --viewport=<name or ID>)")
+	tourCmd.Flags().String("liveviews", "", "Liveviews to cycle through (use --liveviews=<name>[=<duration>],...)")
+	tourCmd.Flags().Duration("interval", 20*time.Second, "How long to show each liveview (use --interval=<duration>)")
+	tourCmd.Flags().Bool("shuffle", false, "Visit the liveviews in a random order on each cycle")
+	tourCmd.Flags().Int("cycles", 0, "Stop after this many passes (use --cycles=<value>; 0 runs until stopped)")
+
+	tourCmd.RegisterFlagCompletionFunc("viewport", completeViewports)
+
+	rootCmd.AddCommand(tourCmd)
+}
+
+// runTour resolves and runs a tour, printing each switch and relaying pause
+// and resume signals
+func runTour(ctx context.Context, c *client.Client, out io.Writer, viewport string, steps []tour.Step, opts tour.Options) error {
+	t, err := tour.New(c, viewport, steps)
+	if err != nil {
+		return fmt.Errorf("failed to resolve tour: %w", err)
+	}
+
+	vpName := t.Viewport().Name
+
+	sigs := make(chan os.Signal, 1)
+	notifyTourSignals(sigs)
+	defer signal.Stop(sigs)
+
+	pause := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigs:
+				paused := isPauseSignal(sig)
+				state := "resumed"
+				if paused {
+					state = "paused"
+				}
+				fmt.Fprintf(out, "%s  %s %s\n", time.Now().Format("15:04:05"), vpName, state)
+				select {
+				case pause <- paused:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	opts.Pause = pause
+	opts.OnSwitch = func(liveview string, err error) {
+		status := ""
+		if err != nil {
+			status = " (failed: " + err.Error() + ")"
+		}
+		fmt.Fprintf(out, "%s  %s -> %s%s\n", time.Now().Format("15:04:05"), vpName, liveview, status)
+	}
+
+	err = t.Run(ctx, opts)
+	if err == context.Canceled {
+		// Interrupting a tour is the normal way to end it
+		err = nil
+	}
+	return err
+}
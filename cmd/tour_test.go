@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/tour"
+)
+
+func TestTourCommandFlags(t *testing.T) {
+	flags := tourCmd.Flags()
+
+	for _, name := range []string{"viewport", "liveviews", "shuffle", "cycles"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected '%s' flag to be registered", name)
+		}
+	}
+
+	interval := flags.Lookup("interval")
+	if interval == nil || interval.DefValue != "20s" {
+		t.Errorf("Expected interval flag with default '20s', got %v", interval)
+	}
+}
+
+func TestRunTour(t *testing.T) {
+	server := newListServer(t)
+	c := client.NewClient(server.URL, "test-token")
+
+	steps := []tour.Step{{Liveview: "Driveway", Dwell: time.Millisecond}, {Liveview: "All Cameras", Dwell: time.Millisecond}}
+
+	buf := new(bytes.Buffer)
+	if err := runTour(context.Background(), c, buf, "Tower", steps, tour.Options{Cycles: 1}); err != nil {
+		t.Fatalf("runTour() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "Tower -> Driveway") || !strings.HasSuffix(lines[1], "Tower -> All Cameras") {
+		t.Errorf("Unexpected tour output: %q", buf.String())
+	}
+
+	if err := runTour(context.Background(), c, buf, "Tower", []tour.Step{{Liveview: "Pool"}}, tour.Options{}); err == nil {
+		t.Error("Expected error for unknown liveview")
+	}
+}
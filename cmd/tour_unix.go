@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyTourSignals relays SIGUSR1 (pause) and SIGUSR2 (resume) to ch
+func notifyTourSignals(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+}
+
+// isPauseSignal returns true for the signal that pauses a tour
+func isPauseSignal(sig os.Signal) bool {
+	return sig == syscall.SIGUSR1
+}
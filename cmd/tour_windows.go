@@ -0,0 +1,14 @@
+//go:build windows
+
+package cmd
+
+import "os"
+
+// notifyTourSignals does nothing: Windows has no user signals, so tours
+// cannot be paused
+func notifyTourSignals(ch chan<- os.Signal) {}
+
+// isPauseSignal returns true for the signal that pauses a tour
+func isPauseSignal(sig os.Signal) bool {
+	return false
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var viewportCmd = &cobra.Command{
+	Use:     "viewport",
+	Aliases: []string{"viewports", "vp"},
+	Short:   "List and switch viewports",
+	Long:    `List viewports (viewers) and switch them between liveviews.`,
+	Args:    cobra.NoArgs,
+}
+
+var viewportListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List viewports and their current liveview",
+	Long: `List viewports and the liveview each one is currently showing.
+
+Equivalent to the legacy --list=viewports flag.`,
+	Example: `  protect viewport list
+  protect viewport list --show-ids
+  protect viewport list --watch --interval=5s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showIDs, _ := cmd.Flags().GetBool("show-ids")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchCommand(cmd, c, opts, "viewports", showIDs)
+		}
+
+		return listViewports(c, cmd.OutOrStdout(), opts, showIDs)
+	},
+}
+
+var viewportSwitchCmd = &cobra.Command{
+	Use:   "switch <viewport> <liveview>",
+	Short: "Switch a viewport to a liveview",
+	Long: `Switch a viewport to a liveview. Both may be given by name or ID.
+
+The single-argument <viewport>:<liveview> form is also accepted, matching
+the legacy --switch flag.`,
+	Example: `  protect viewport switch VP-Office Driveway
+  protect viewport switch Tower "All Cameras"
+  protect viewport switch Tower:Driveway`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeViewportSwitchArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			return handleSwitchCommand(c, cmd.OutOrStdout(), opts, args[0])
+		}
+
+		return handleViewportSwitch(c, cmd.OutOrStdout(), opts, args[0], args[1])
+	},
+}
+
+var liveviewCmd = &cobra.Command{
+	Use:     "liveview",
+	Aliases: []string{"liveviews", "lv"},
+	Short:   "List liveviews",
+	Long:    `List the liveviews that viewports can be switched to.`,
+	Args:    cobra.NoArgs,
+}
+
+var liveviewListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List liveviews",
+	Long: `List the liveviews that viewports can be switched to.
+
+Equivalent to the legacy --list=liveviews flag.`,
+	Example: `  protect liveview list
+  protect liveview list --show-ids
+  protect liveview list --watch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showIDs, _ := cmd.Flags().GetBool("show-ids")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchCommand(cmd, c, opts, "liveviews", showIDs)
+		}
+
+		return listLiveviews(c, cmd.OutOrStdout(), opts, showIDs)
+	},
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Launch the interactive TUI",
+	Long: `Launch the interactive terminal UI for switching viewports and moving PTZ cameras.
+
+Equivalent to the legacy --tui (-i) flag.`,
+	Example: `  protect tui`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		return tui.Run(c, config.Get().Scenes)
+	},
+}
+
+func init() {
+	viewportListCmd.Flags().Bool("show-ids", false, "Show IDs when listing")
+	addWatchFlags(viewportListCmd)
+	liveviewListCmd.Flags().Bool("show-ids", false, "Show IDs when listing")
+	addWatchFlags(liveviewListCmd)
+
+	viewportCmd.AddCommand(viewportListCmd, viewportSwitchCmd)
+	liveviewCmd.AddCommand(liveviewListCmd)
+	rootCmd.AddCommand(viewportCmd, liveviewCmd, tuiCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestViewportCommands(t *testing.T) {
+	tests := []struct {
+		path    []string
+		args    []string
+		wantErr bool
+	}{
+		{path: []string{"viewport", "list"}, args: []string{}},
+		{path: []string{"viewport", "list"}, args: []string{"extra"}, wantErr: true},
+		{path: []string{"viewport", "switch"}, args: []string{"Tower", "Driveway"}},
+		{path: []string{"viewport", "switch"}, args: []string{"Tower:Driveway"}},
+		{path: []string{"viewport", "switch"}, args: []string{}, wantErr: true},
+		{path: []string{"liveview", "list"}, args: []string{}},
+		{path: []string{"tui"}, args: []string{}},
+	}
+
+	for _, tt := range tests {
+		cmd, _, err := rootCmd.Find(tt.path)
+		if err != nil {
+			t.Fatalf("Expected command %v to be registered: %v", tt.path, err)
+		}
+
+		if err := cmd.Args(cmd, tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("%v args %v: error = %v, wantErr %v", tt.path, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestListCommandsHaveShowIDs(t *testing.T) {
+	for _, path := range [][]string{{"viewport", "list"}, {"liveview", "list"}, {"ptz", "list"}, {"sensor", "list"}} {
+		cmd, _, err := rootCmd.Find(path)
+		if err != nil {
+			t.Fatalf("Expected command %v to be registered: %v", path, err)
+		}
+
+		if cmd.Flags().Lookup("show-ids") == nil {
+			t.Errorf("Expected %v to have a 'show-ids' flag", path)
+		}
+
+		if cmd.Flags().Lookup("watch") == nil || cmd.Flags().Lookup("interval") == nil {
+			t.Errorf("Expected %v to have 'watch' and 'interval' flags", path)
+		}
+
+		if cmd.Example == "" {
+			t.Errorf("Expected %v to have examples", path)
+		}
+	}
+}
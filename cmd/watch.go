@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// defaultWatchInterval is how often --watch refreshes a listing
+const defaultWatchInterval = 2 * time.Second
+
+// ANSI sequences used to redraw the watched table in place
+const (
+	clearScreen    = "\x1b[H\x1b[2J"
+	highlightStart = "\x1b[7m"
+	highlightEnd   = "\x1b[0m"
+)
+
+// addWatchFlags adds --watch and --interval to a listing command
+func addWatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("watch", "w", false, "Keep refreshing the listing, highlighting changed rows (JSON output prints only changes)")
+	cmd.Flags().Duration("interval", defaultWatchInterval, "How often to refresh with --watch (use --interval=<duration>, e.g. '5s')")
+}
+
+// watchItem is one entry of a watched listing
+type watchItem struct {
+	ID     string
+	Row    []string
+	Record interface{}
+}
+
+// watchSource fetches the current entries of a listing
+type watchSource struct {
+	Title   string
+	Headers []string
+	Empty   string
+	Fetch   func() ([]watchItem, error)
+}
+
+// watchChange is one NDJSON line emitted by --watch with --output=json
+type watchChange struct {
+	Change string      `json:"change"`
+	ID     string      `json:"id"`
+	Item   interface{} `json:"item"`
+}
+
+// Kinds of watchChange
+const (
+	changeAdd    = "add"
+	changeUpdate = "update"
+	changeRemove = "remove"
+)
+
+// watchCommand runs --watch for a listing command until interrupted
+func watchCommand(cmd *cobra.Command, c *client.Client, opts output.Options, listType string, showIDs bool) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return watchList(ctx, c, cmd.OutOrStdout(), opts, listType, showIDs, interval)
+}
+
+// watchList refreshes a listing every interval until ctx is cancelled
+func watchList(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, listType string, showIDs bool, interval time.Duration) error {
+	if opts.Template != "" || (opts.Format != output.Table && opts.Format != output.JSON) {
+		return fmt.Errorf("--watch supports only --output=table or --output=json")
+	}
+
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval: %s (must be greater than zero)", interval)
+	}
+
+	var src watchSource
+	switch listType {
+	case "viewports":
+		src = viewportWatchSource(c, showIDs)
+	case "liveviews", "views":
+		src = liveviewWatchSource(c, showIDs)
+	case "cameras":
+		src = cameraWatchSource(c, showIDs)
+	case "sensors":
+		src = sensorWatchSource(c, showIDs)
+	default:
+		return fmt.Errorf("invalid list type: %s (use 'viewports', 'liveviews', 'cameras' or 'sensors')", listType)
+	}
+	src.Title = fmt.Sprintf("Every %s: %s", interval, src.Title)
+
+	return runWatch(ctx, out, opts, interval, src)
+}
+
+// runWatch fetches src every interval. Tables are redrawn when anything
+// changes, with the changed rows highlighted; JSON output prints one line
+// per added, updated or removed entry. The first fetch must succeed; later
+// failures are reported and retried on the next tick.
+func runWatch(ctx context.Context, out io.Writer, opts output.Options, interval time.Duration, src watchSource) error {
+	log := logger.Get()
+	enc := json.NewEncoder(out)
+
+	items, err := src.Fetch()
+	if err != nil {
+		return err
+	}
+
+	changes := diffWatchItems(nil, items)
+	if opts.Format == output.JSON {
+		for _, change := range changes {
+			enc.Encode(change)
+		}
+	} else {
+		drawWatchTable(out, opts, src, items, nil, time.Now(), nil)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := src.Fetch()
+		if err != nil {
+			log.Warnw("Failed to refresh listing", "error", err)
+			if opts.Format == output.Table && (lastErr == nil || lastErr.Error() != err.Error()) {
+				drawWatchTable(out, opts, src, items, nil, time.Now(), err)
+			}
+			lastErr = err
+			continue
+		}
+
+		changes := diffWatchItems(items, next)
+		if len(changes) == 0 && lastErr == nil {
+			continue
+		}
+		lastErr = nil
+		items = next
+
+		if opts.Format == output.JSON {
+			for _, change := range changes {
+				enc.Encode(change)
+			}
+			continue
+		}
+
+		changed := make(map[string]bool, len(changes))
+		for _, change := range changes {
+			changed[change.ID] = true
+		}
+		drawWatchTable(out, opts, src, items, changed, time.Now(), nil)
+	}
+}
+
+// diffWatchItems lists what changed between two fetches, in listing order
+// with removals last
+func diffWatchItems(prev, next []watchItem) []watchChange {
+	before := make(map[string]watchItem, len(prev))
+	for _, item := range prev {
+		before[item.ID] = item
+	}
+
+	var changes []watchChange
+	seen := make(map[string]bool, len(next))
+	for _, item := range next {
+		seen[item.ID] = true
+		old, ok := before[item.ID]
+		switch {
+		case !ok:
+			changes = append(changes, watchChange{Change: changeAdd, ID: item.ID, Item: item.Record})
+		case !reflect.DeepEqual(old.Record, item.Record):
+			changes = append(changes, watchChange{Change: changeUpdate, ID: item.ID, Item: item.Record})
+		}
+	}
+
+	for _, item := range prev {
+		if !seen[item.ID] {
+			changes = append(changes, watchChange{Change: changeRemove, ID: item.ID, Item: item.Record})
+		}
+	}
+
+	return changes
+}
+
+// drawWatchTable clears the terminal and draws the listing, highlighting
+// the rows in changed
+func drawWatchTable(out io.Writer, opts output.Options, src watchSource, items []watchItem, changed map[string]bool, now time.Time, fetchErr error) {
+	var buf bytes.Buffer
+	buf.WriteString(clearScreen)
+	fmt.Fprintf(&buf, "%s  (updated %s)\n", src.Title, now.Format("15:04:05"))
+	if fetchErr != nil {
+		fmt.Fprintf(&buf, "Refresh failed: %v\n", fetchErr)
+	}
+	buf.WriteString("\n")
+
+	if len(items) == 0 {
+		fmt.Fprintln(&buf, src.Empty)
+		out.Write(buf.Bytes())
+		return
+	}
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	headerLines := 0
+	if !opts.NoHeaders {
+		writeTableHeaders(w, src.Headers)
+		headerLines = 2
+	}
+	for _, item := range items {
+		writeTableRow(w, item.Row)
+	}
+	w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	for i, line := range lines {
+		if i >= headerLines && changed[items[i-headerLines].ID] {
+			line = highlightStart + line + highlightEnd
+		}
+		buf.WriteString(line + "\n")
+	}
+
+	out.Write(buf.Bytes())
+}
+
+// writeTableHeaders writes column headers underlined with dashes
+func writeTableHeaders(w io.Writer, headers []string) {
+	dashes := make([]string, len(headers))
+	for i, h := range headers {
+		dashes[i] = strings.Repeat("-", len(h))
+	}
+	writeTableRow(w, headers)
+	writeTableRow(w, dashes)
+}
+
+// writeTableRow writes tab-separated cells for a tabwriter
+func writeTableRow(w io.Writer, cells []string) {
+	fmt.Fprintln(w, strings.Join(cells, "\t"))
+}
+
+// viewportWatchSource watches viewports and their current liveview.
+// Liveview names are looked up again on every refresh, so liveviews that
+// are renamed or created while watching show their current name.
+func viewportWatchSource(c *client.Client, showIDs bool) watchSource {
+	headers := []string{"NAME", "CURRENT LIVEVIEW"}
+	if showIDs {
+		headers = append(headers, "ID", "LIVEVIEW ID")
+	}
+
+	return watchSource{
+		Title:   "viewports",
+		Headers: headers,
+		Empty:   "No viewports found",
+		Fetch: func() ([]watchItem, error) {
+			viewports, err := c.ListViewports()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list viewports: %w", err)
+			}
+
+			liveviews, err := c.ListCameras()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list liveviews: %w", err)
+			}
+			names := make(map[string]string, len(liveviews))
+			for _, lv := range liveviews {
+				names[lv.ID] = lv.Name
+			}
+
+			items := make([]watchItem, 0, len(viewports))
+			for _, vp := range viewports {
+				liveviewName := names[vp.Liveview]
+				if liveviewName == "" {
+					liveviewName = vp.Liveview
+				}
+				r := viewportRecord{Name: vp.Name, ID: vp.ID, Liveview: liveviewName, LiveviewID: vp.Liveview}
+				row := []string{r.Name, r.Liveview}
+				if showIDs {
+					row = append(row, r.ID, r.LiveviewID)
+				}
+				items = append(items, watchItem{ID: r.ID, Row: row, Record: r})
+			}
+			return items, nil
+		},
+	}
+}
+
+// liveviewWatchSource watches the liveviews
+func liveviewWatchSource(c *client.Client, showIDs bool) watchSource {
+	return watchSource{
+		Title:   "liveviews",
+		Headers: nameHeaders(showIDs, false),
+		Empty:   "No liveviews found",
+		Fetch: func() ([]watchItem, error) {
+			liveviews, err := c.ListCameras()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list liveviews: %w", err)
+			}
+			return nameItems(liveviewItems(liveviews), showIDs, false), nil
+		},
+	}
+}
+
+// cameraWatchSource watches the cameras
+func cameraWatchSource(c *client.Client, showIDs bool) watchSource {
+	return watchSource{
+		Title:   "cameras",
+		Headers: nameHeaders(showIDs, true),
+		Empty:   "No PTZ cameras found",
+		Fetch: func() ([]watchItem, error) {
+			cameras, err := c.ListPTZCameras()
+			if err != nil {
+				return nil, err
+			}
+			return nameItems(cameraItems(cameras), showIDs, true), nil
+		},
+	}
+}
+
+// sensorWatchSource watches the sensors and their readings
+func sensorWatchSource(c *client.Client, showIDs bool) watchSource {
+	return watchSource{
+		Title:   "sensors",
+		Headers: sensorHeaders(showIDs),
+		Empty:   "No sensors found",
+		Fetch: func() ([]watchItem, error) {
+			sensors, err := c.ListSensors()
+			if err != nil {
+				return nil, err
+			}
+			records := sensorItems(sensors)
+			items := make([]watchItem, 0, len(records))
+			for _, r := range records {
+				items = append(items, watchItem{ID: r.ID, Row: sensorRow(r, showIDs), Record: r})
+			}
+			return items, nil
+		},
+	}
+}
+
+// nameHeaders matches the liveview and camera tables, where the camera
+// table puts the ID first
+func nameHeaders(showIDs, idFirst bool) []string {
+	switch {
+	case !showIDs:
+		return []string{"NAME"}
+	case idFirst:
+		return []string{"ID", "NAME"}
+	default:
+		return []string{"NAME", "ID"}
+	}
+}
+
+// nameItems turns name/ID records into watch entries
+func nameItems(records []itemRecord, showIDs, idFirst bool) []watchItem {
+	items := make([]watchItem, 0, len(records))
+	for _, r := range records {
+		row := []string{r.Name}
+		switch {
+		case showIDs && idFirst:
+			row = []string{r.ID, r.Name}
+		case showIDs:
+			row = append(row, r.ID)
+		}
+		items = append(items, watchItem{ID: r.ID, Row: row, Record: r})
+	}
+	return items
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// watchServer serves a different set of viewers on each fetch, and a
+// different set of liveviews on each lookup, repeating the last ones
+type watchServer struct {
+	mu            sync.Mutex
+	viewers       []string
+	liveviewLists []string
+	fetches       int
+	liveviews     int
+}
+
+func newWatchServer(t *testing.T, viewers ...string) (*watchServer, *client.Client) {
+	t.Helper()
+
+	// Garage is created after the first lookup
+	ws := &watchServer{viewers: viewers, liveviewLists: []string{
+		`[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`,
+		`[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"},{"id":"lv3","name":"Garage"}]`,
+	}}
+	server := protecttest.New(t)
+	server.Handle("viewers", func(w http.ResponseWriter, r *http.Request) {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		w.Write([]byte(ws.viewers[min(ws.fetches, len(ws.viewers)-1)]))
+		ws.fetches++
+	})
+	server.Handle("liveviews", func(w http.ResponseWriter, r *http.Request) {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		w.Write([]byte(ws.liveviewLists[min(ws.liveviews, len(ws.liveviewLists)-1)]))
+		ws.liveviews++
+	})
+
+	return ws, server.APIClient()
+}
+
+// watchUntil runs watchList until the server has served fetches viewer
+// listings and returns the output
+func watchUntil(t *testing.T, ws *watchServer, c *client.Client, opts output.Options, fetches int) string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buf := new(bytes.Buffer)
+	done := make(chan error, 1)
+	go func() { done <- watchList(ctx, c, buf, opts, "viewports", false, 10*time.Millisecond) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ws.mu.Lock()
+		n := ws.fetches
+		ws.mu.Unlock()
+		if n >= fetches {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for refreshes")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchList() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestWatchViewportsJSON(t *testing.T) {
+	ws, c := newWatchServer(t,
+		`[{"id":"vp1","name":"Tower","liveview":"lv1"}]`,
+		`[{"id":"vp1","name":"Tower","liveview":"lv2"}]`,
+		`[{"id":"vp1","name":"Tower","liveview":"lv2"},{"id":"vp2","name":"Lobby","liveview":"lv3"}]`,
+		`[{"id":"vp2","name":"Lobby","liveview":"lv3"}]`,
+	)
+
+	got := watchUntil(t, ws, c, output.Options{Format: output.JSON}, 6)
+
+	var lines []string
+	dec := json.NewDecoder(strings.NewReader(got))
+	for dec.More() {
+		var change struct {
+			Change string         `json:"change"`
+			ID     string         `json:"id"`
+			Item   viewportRecord `json:"item"`
+		}
+		if err := dec.Decode(&change); err != nil {
+			t.Fatalf("Invalid NDJSON %q: %v", got, err)
+		}
+		lines = append(lines, change.Change+" "+change.ID+" "+change.Item.Liveview)
+	}
+
+	want := []string{"add vp1 All Cameras", "update vp1 Driveway", "add vp2 Garage", "remove vp1 Driveway"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Changes = %q, want %q", lines, want)
+	}
+}
+
+func TestWatchViewportsRenamedLiveview(t *testing.T) {
+	ws, c := newWatchServer(t, `[{"id":"vp1","name":"Tower","liveview":"lv1"}]`)
+	ws.liveviewLists = []string{
+		`[{"id":"lv1","name":"All Cameras"}]`,
+		`[{"id":"lv1","name":"Everything"}]`,
+	}
+
+	got := watchUntil(t, ws, c, output.Options{Format: output.JSON}, 3)
+
+	if !strings.Contains(got, `"change":"update","id":"vp1"`) || !strings.Contains(got, `"liveview":"Everything"`) {
+		t.Errorf("Expected the renamed liveview to be shown, got %q", got)
+	}
+}
+
+func TestWatchViewportsTable(t *testing.T) {
+	ws, c := newWatchServer(t,
+		`[{"id":"vp1","name":"Tower","liveview":"lv1"},{"id":"vp2","name":"Lobby","liveview":"lv1"}]`,
+		`[{"id":"vp1","name":"Tower","liveview":"lv1"},{"id":"vp2","name":"Lobby","liveview":"lv2"}]`,
+	)
+
+	got := watchUntil(t, ws, c, output.Options{Format: output.Table}, 4)
+
+	// Unchanged refreshes do not redraw
+	if n := strings.Count(got, clearScreen); n != 2 {
+		t.Fatalf("Expected 2 redraws, got %d in %q", n, got)
+	}
+
+	first, second, _ := strings.Cut(strings.TrimPrefix(got, clearScreen), clearScreen)
+	if strings.Contains(first, highlightStart) || !strings.Contains(first, "Every 10ms: viewports") {
+		t.Errorf("Unexpected first draw: %q", first)
+	}
+	if !strings.Contains(second, highlightStart+"Lobby  Driveway"+highlightEnd) || strings.Contains(second, highlightStart+"Tower") {
+		t.Errorf("Expected only the changed row to be highlighted: %q", second)
+	}
+}
+
+func TestWatchListRejectsFormats(t *testing.T) {
+	if err := watchList(context.Background(), nil, new(bytes.Buffer), output.Options{Format: output.CSV}, "viewports", false, time.Second); err == nil {
+		t.Error("Expected an error for CSV output")
+	}
+	if err := watchList(context.Background(), nil, new(bytes.Buffer), output.Options{Format: output.Table}, "viewports", false, 0); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
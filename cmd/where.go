@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/where"
+	"github.com/spf13/cobra"
+)
+
+var whereCmd = &cobra.Command{
+	Use:   "where <camera>",
+	Short: "Show which liveviews and viewers display a camera",
+	Long: `List every liveview with the camera in one of its slots and the viewers
+currently showing each one. The best match comes first: liveviews where the
+camera has a slot to itself, then those with the fewest cameras.
+
+--show switches a viewer to that best-matching liveview, to get a camera onto
+a wall screen quickly.`,
+	Example: `  protect where "Front Door"
+  protect where "Front Door" --show=Lobby
+  protect where cam1 -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSingleCamera,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		show, _ := cmd.Flags().GetString("show")
+
+		c, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		opts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		result, err := where.Find(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		if show == "" {
+			return writeWhere(cmd.OutOrStdout(), opts, result)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return showCamera(ctx, c, cmd.OutOrStdout(), opts, result, show)
+	},
+}
+
+func init() {
+	whereCmd.Flags().String("show", "", "Switch a viewer to the best liveview for the camera (use --show=<viewer>)")
+	whereCmd.Flags().Lookup("show").Annotations = map[string][]string{"required": {"true"}}
+	whereCmd.RegisterFlagCompletionFunc("show", completeViewports)
+
+	rootCmd.AddCommand(whereCmd)
+}
+
+// whereRecord is the structured form of a liveview showing the camera
+type whereRecord struct {
+	Liveview   string   `json:"liveview" yaml:"liveview"`
+	LiveviewID string   `json:"liveviewId" yaml:"liveviewId"`
+	Slots      []int    `json:"slots" yaml:"slots"`
+	Cameras    int      `json:"cameras" yaml:"cameras"`
+	Dedicated  bool     `json:"dedicated" yaml:"dedicated"`
+	Viewers    []string `json:"viewers" yaml:"viewers"`
+}
+
+// writeWhere prints the liveviews containing the camera, best match first
+func writeWhere(out io.Writer, opts output.Options, result *where.Result) error {
+	records := make([]whereRecord, 0, len(result.Placements))
+	for _, p := range result.Placements {
+		r := whereRecord{
+			Liveview:   p.Liveview.Name,
+			LiveviewID: p.Liveview.ID,
+			Slots:      p.Slots,
+			Cameras:    p.Cameras,
+			Dedicated:  p.Dedicated,
+			Viewers:    []string{},
+		}
+		for _, vp := range p.Viewers {
+			r.Viewers = append(r.Viewers, vp.Name)
+		}
+		records = append(records, r)
+	}
+
+	if opts.Structured() {
+		listing := output.Listing{
+			Items:   records,
+			Headers: []string{"liveview", "liveview_id", "slots", "cameras", "dedicated", "viewers"},
+		}
+		for _, r := range records {
+			listing.Rows = append(listing.Rows, []string{r.Liveview, r.LiveviewID, joinInts(r.Slots), strconv.Itoa(r.Cameras), strconv.FormatBool(r.Dedicated), strings.Join(r.Viewers, ",")})
+			listing.Names = append(listing.Names, r.Liveview)
+		}
+		return output.Write(out, opts, listing)
+	}
+
+	if len(records) == 0 {
+		fmt.Fprintf(out, "Camera %s is not in any liveview\n", result.Camera.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		fmt.Fprintln(w, "LIVEVIEW\tSLOTS\tCAMERAS\tSHOWN ON")
+		fmt.Fprintln(w, "--------\t-----\t-------\t--------")
+	}
+	for _, r := range records {
+		viewers := strings.Join(r.Viewers, ", ")
+		if viewers == "" {
+			viewers = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", r.Liveview, joinInts(r.Slots), r.Cameras, viewers)
+	}
+	w.Flush()
+
+	return nil
+}
+
+// showCamera switches viewer to the best liveview for the camera
+func showCamera(ctx context.Context, c *client.Client, out io.Writer, opts output.Options, result *where.Result, viewer string) error {
+	best := result.Best()
+	if best == nil {
+		return fmt.Errorf("camera %s is not in any liveview", result.Camera.Name)
+	}
+
+	targets, err := actions.NewResolver(c).Resolve([]actions.Action{actions.Switch(viewer, best.ID)})
+	if err != nil {
+		return err
+	}
+
+	results := actions.Execute(ctx, c, targets, 1)
+	if opts.Structured() {
+		if err := writeResults(out, opts, results); err != nil {
+			return err
+		}
+	}
+
+	if r := results[0]; !r.Success {
+		err := fmt.Errorf("failed to switch viewport: %s", r.Error)
+		if opts.Structured() {
+			return reportedError{err}
+		}
+		return err
+	}
+
+	if !opts.Structured() {
+		if c.DryRun {
+			fmt.Fprintf(out, "Dry run: would switch viewport %s to liveview %s to show %s\n", targets[0].ViewportName, best.Name, result.Camera.Name)
+		} else {
+			fmt.Fprintf(out, "Switched viewport %s to liveview %s to show %s\n", targets[0].ViewportName, best.Name, result.Camera.Name)
+		}
+	}
+	return nil
+}
+
+// joinInts formats numbers as a comma-separated list
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/output"
+	"github.com/methridge/protect/internal/protecttest"
+	"github.com/methridge/protect/internal/where"
+)
+
+// newWhereServer serves one grid and one single-camera liveview of Front
+// Door
+func newWhereServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Driveway"}]`)
+	server.Set("liveviews", `[
+		{"id":"lv1","name":"All Cameras","layout":2,"slots":[{"cameras":["cam2"]},{"cameras":["cam1"]}]},
+		{"id":"lv2","name":"Door","layout":1,"slots":[{"cameras":["cam1"]}]}
+	]`)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"},{"id":"vp2","name":"Lobby","liveview":""}]`)
+	return server
+}
+
+func TestWriteWhere(t *testing.T) {
+	server := newWhereServer(t)
+	c := server.APIClient()
+
+	result, err := where.Find(c, "Front Door")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeWhere(buf, output.Options{Format: output.Table}, result); err != nil {
+		t.Fatalf("writeWhere() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "Door         1      1        -") || !strings.HasPrefix(lines[3], "All Cameras  2      2        Tower") {
+		t.Errorf("Unexpected table output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeWhere(buf, output.Options{Format: output.JSON}, result); err != nil {
+		t.Fatalf("writeWhere() error = %v", err)
+	}
+	var records []whereRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Expected JSON array, got %q: %v", buf.String(), err)
+	}
+	if len(records) != 2 || records[0].LiveviewID != "lv2" || len(records[0].Viewers) != 0 || records[1].Viewers[0] != "Tower" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestShowCamera(t *testing.T) {
+	server := newWhereServer(t)
+	c := server.APIClient()
+
+	result, err := where.Find(c, "cam1")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := showCamera(context.Background(), c, buf, output.Options{Format: output.Table}, result, "Lobby"); err != nil {
+		t.Fatalf("showCamera() error = %v", err)
+	}
+	if requests := server.Requests(); len(requests) != 1 || requests[0] != "PATCH /proxy/protect/integration/v1/viewers/vp2" {
+		t.Errorf("Expected Lobby (vp2) to be switched, got %v", requests)
+	}
+	if buf.String() != "Switched viewport Lobby to liveview Door to show Front Door\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	if err := showCamera(context.Background(), c, buf, output.Options{Format: output.Table}, result, "Office"); err == nil {
+		t.Error("Expected an error for an unknown viewer")
+	}
+}
@@ -0,0 +1,430 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// DefaultWorkers is the number of actions run at once
+const DefaultWorkers = 4
+
+// Kind is the type of an action
+type Kind string
+
+// Action kinds
+const (
+	KindSwitch Kind = "switch"
+	KindPTZ    Kind = "ptz"
+	KindPatrol Kind = "patrol"
+	KindLight  Kind = "light"
+)
+
+// Action is a viewport switch, PTZ move, PTZ patrol change or floodlight
+// mode change, with targets given by name or ID
+type Action struct {
+	Kind     Kind
+	Viewport string
+	Liveview string
+	Camera   string
+	Preset   int
+	// Slot is the patrol slot to start, or -1 to stop patrolling
+	Slot int
+	// Light, LightMode and EnableAt set a floodlight's mode; an empty
+	// EnableAt leaves the activation time unchanged
+	Light     string
+	LightMode string
+	EnableAt  string
+}
+
+// Switch returns an action that switches a viewport to a liveview
+func Switch(viewport, liveview string) Action {
+	return Action{Kind: KindSwitch, Viewport: viewport, Liveview: liveview}
+}
+
+// PTZ returns an action that moves a camera to a preset
+func PTZ(camera string, preset int) Action {
+	return Action{Kind: KindPTZ, Camera: camera, Preset: preset}
+}
+
+// Patrol returns an action that starts a PTZ patrol, or stops it if slot is -1
+func Patrol(camera string, slot int) Action {
+	return Action{Kind: KindPatrol, Camera: camera, Slot: slot}
+}
+
+// LightMode returns an action that sets a floodlight's mode
+func LightMode(light, mode, enableAt string) Action {
+	return Action{Kind: KindLight, Light: light, LightMode: mode, EnableAt: enableAt}
+}
+
+// Target is an action whose names have been resolved to IDs
+type Target struct {
+	Action
+	ViewportID   string
+	ViewportName string
+	LiveviewID   string
+	LiveviewName string
+	CameraID     string
+	CameraName   string
+	LightID      string
+	LightName    string
+}
+
+// Result is the outcome of a single action
+type Result struct {
+	Action   Kind   `json:"action" yaml:"action"`
+	Target   string `json:"target" yaml:"target"`
+	TargetID string `json:"targetId" yaml:"targetId"`
+	Value    string `json:"value" yaml:"value"`
+	ValueID  string `json:"valueId" yaml:"valueId"`
+	Success  bool   `json:"success" yaml:"success"`
+	DryRun   bool   `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ParsePreset parses a preset number, accepting "home" for -1
+func ParsePreset(s string) (int, error) {
+	if strings.EqualFold(s, "home") {
+		return -1, nil
+	}
+
+	preset, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid preset value: %s (must be a number between -1 and 9, or 'home')", s)
+	}
+
+	return preset, nil
+}
+
+// PresetLabel describes a preset for people
+func PresetLabel(preset int) string {
+	if preset == -1 {
+		return "home position"
+	}
+	return fmt.Sprintf("preset %d", preset)
+}
+
+// PatrolLabel describes a patrol slot for people
+func PatrolLabel(slot int) string {
+	if slot == -1 {
+		return "no patrol"
+	}
+	return fmt.Sprintf("patrol %d", slot)
+}
+
+// LightLabel describes a floodlight mode for people
+func LightLabel(mode, enableAt string) string {
+	switch enableAt {
+	case client.LightEnableDark:
+		return mode + " (when dark)"
+	case client.LightEnableAlways:
+		return mode + " (day and night)"
+	}
+	return mode
+}
+
+// ValidateLightMode checks a floodlight mode and activation time
+func ValidateLightMode(mode, enableAt string) error {
+	switch mode {
+	case client.LightModeAlways, client.LightModeMotion, client.LightModeOff:
+	default:
+		return fmt.Errorf("invalid light mode: %s (use 'always', 'motion' or 'off')", mode)
+	}
+
+	switch enableAt {
+	case "", client.LightEnableAlways, client.LightEnableDark:
+	default:
+		return fmt.Errorf("invalid light activation: %s (use 'fulltime' or 'dark')", enableAt)
+	}
+
+	return nil
+}
+
+// FromScene converts a configured scene into actions
+func FromScene(scene config.Scene) ([]Action, error) {
+	var actions []Action
+	for _, sw := range scene.Switches {
+		actions = append(actions, Switch(sw.Viewport, sw.Liveview))
+	}
+
+	for _, move := range scene.PTZ {
+		preset, err := ParsePreset(move.Preset)
+		if err != nil {
+			return nil, fmt.Errorf("scene %s: %w", scene.Name, err)
+		}
+		actions = append(actions, PTZ(move.Camera, preset))
+	}
+
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("scene %s has no switches or PTZ moves", scene.Name)
+	}
+
+	return actions, nil
+}
+
+// Resolver resolves viewport, liveview, camera and light names to IDs. Each list
+// is fetched at most once, so a resolver can be reused for many actions.
+type Resolver struct {
+	client    *client.Client
+	viewports []client.Viewport
+	liveviews []client.Liveview
+	cameras   []client.PTZCamera
+	lights    []client.Light
+}
+
+// NewResolver creates a resolver that looks names up with c
+func NewResolver(c *client.Client) *Resolver {
+	return &Resolver{client: c}
+}
+
+// NewCachedResolver creates a resolver that looks names up in lists that
+// have already been fetched. A nil list is fetched with c when first needed.
+func NewCachedResolver(c *client.Client, viewports []client.Viewport, liveviews []client.Liveview, cameras []client.PTZCamera, lights []client.Light) *Resolver {
+	return &Resolver{client: c, viewports: viewports, liveviews: liveviews, cameras: cameras, lights: lights}
+}
+
+// Resolve resolves every action before anything is run, so a typo in one
+// target does not leave the others half applied. All unresolved targets are
+// reported together.
+func (r *Resolver) Resolve(actions []Action) ([]Target, error) {
+	var errs []error
+	targets := make([]Target, 0, len(actions))
+
+	for _, a := range actions {
+		t, err := r.resolve(a)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		targets = append(targets, t)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return targets, nil
+}
+
+func (r *Resolver) resolve(a Action) (Target, error) {
+	t := Target{Action: a}
+
+	switch a.Kind {
+	case KindSwitch:
+		vp, err := r.Viewport(a.Viewport)
+		if err != nil {
+			return t, err
+		}
+		lv, err := r.Liveview(a.Liveview)
+		if err != nil {
+			return t, err
+		}
+		t.ViewportID, t.ViewportName = vp.ID, vp.Name
+		t.LiveviewID, t.LiveviewName = lv.ID, lv.Name
+
+	case KindPTZ:
+		if a.Preset < -1 || a.Preset > 9 {
+			return t, fmt.Errorf("invalid preset value: %d (must be between -1 and 9)", a.Preset)
+		}
+		cam, err := r.Camera(a.Camera)
+		if err != nil {
+			return t, err
+		}
+		t.CameraID, t.CameraName = cam.ID, cam.Name
+
+	case KindPatrol:
+		if a.Slot < -1 || a.Slot > 4 {
+			return t, fmt.Errorf("invalid patrol slot: %d (must be between 0 and 4, or -1 to stop)", a.Slot)
+		}
+		cam, err := r.Camera(a.Camera)
+		if err != nil {
+			return t, err
+		}
+		t.CameraID, t.CameraName = cam.ID, cam.Name
+
+	case KindLight:
+		if err := ValidateLightMode(a.LightMode, a.EnableAt); err != nil {
+			return t, err
+		}
+		light, err := r.Light(a.Light)
+		if err != nil {
+			return t, err
+		}
+		t.LightID, t.LightName = light.ID, light.Name
+
+	default:
+		return t, fmt.Errorf("unknown action: %s", a.Kind)
+	}
+
+	return t, nil
+}
+
+// Viewport finds a viewport by name or ID
+func (r *Resolver) Viewport(nameOrID string) (*client.Viewport, error) {
+	if r.viewports == nil {
+		viewports, err := r.client.ListViewports()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list viewports: %w", err)
+		}
+		r.viewports = viewports
+	}
+
+	for i, vp := range r.viewports {
+		if vp.ID == nameOrID || vp.Name == nameOrID {
+			return &r.viewports[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("viewport not found: %s", nameOrID)
+}
+
+// Liveview finds a liveview by name or ID
+func (r *Resolver) Liveview(nameOrID string) (*client.Liveview, error) {
+	if r.liveviews == nil {
+		liveviews, err := r.client.ListCameras()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list liveviews: %w", err)
+		}
+		r.liveviews = liveviews
+	}
+
+	for i, lv := range r.liveviews {
+		if lv.ID == nameOrID || lv.Name == nameOrID {
+			return &r.liveviews[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("liveview not found: %s", nameOrID)
+}
+
+// Camera finds a camera by name or ID
+func (r *Resolver) Camera(nameOrID string) (*client.PTZCamera, error) {
+	if r.cameras == nil {
+		cameras, err := r.client.ListPTZCameras()
+		if err != nil {
+			return nil, err
+		}
+		r.cameras = cameras
+	}
+
+	for i, cam := range r.cameras {
+		if cam.ID == nameOrID || cam.Name == nameOrID {
+			return &r.cameras[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("camera not found: %s", nameOrID)
+}
+
+// Light finds a floodlight by name or ID
+func (r *Resolver) Light(nameOrID string) (*client.Light, error) {
+	if r.lights == nil {
+		lights, err := r.client.ListLights()
+		if err != nil {
+			return nil, err
+		}
+		r.lights = lights
+	}
+
+	for i, light := range r.lights {
+		if light.ID == nameOrID || light.Name == nameOrID {
+			return &r.lights[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("light not found: %s", nameOrID)
+}
+
+// Execute runs the targets concurrently with at most workers in flight and
+// returns one result per target, in the same order
+func Execute(ctx context.Context, c *client.Client, targets []Target, workers int) []Result {
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+
+	results := make([]Result, len(targets))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = run(ctx, c, targets[i])
+			}
+		}()
+	}
+
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// run performs a single target and records its result
+func run(ctx context.Context, c *client.Client, t Target) Result {
+	log := logger.Get()
+
+	var result Result
+	var err error
+
+	switch t.Kind {
+	case KindSwitch:
+		result = Result{Action: KindSwitch, Target: t.ViewportName, TargetID: t.ViewportID, Value: t.LiveviewName, ValueID: t.LiveviewID}
+		if err = ctx.Err(); err == nil {
+			err = c.SwitchViewport(t.ViewportID, t.LiveviewID)
+		}
+	case KindPTZ:
+		result = Result{Action: KindPTZ, Target: t.CameraName, TargetID: t.CameraID, Value: PresetLabel(t.Preset), ValueID: strconv.Itoa(t.Preset)}
+		if err = ctx.Err(); err == nil {
+			err = c.MovePTZToPreset(t.CameraID, t.Preset)
+		}
+	case KindPatrol:
+		result = Result{Action: KindPatrol, Target: t.CameraName, TargetID: t.CameraID, Value: PatrolLabel(t.Slot), ValueID: strconv.Itoa(t.Slot)}
+		if err = ctx.Err(); err == nil {
+			if t.Slot == -1 {
+				err = c.StopPTZPatrol(t.CameraID)
+			} else {
+				err = c.StartPTZPatrol(t.CameraID, t.Slot)
+			}
+		}
+	case KindLight:
+		result = Result{Action: KindLight, Target: t.LightName, TargetID: t.LightID, Value: LightLabel(t.LightMode, t.EnableAt), ValueID: t.LightMode}
+		if err = ctx.Err(); err == nil {
+			err = c.SetLightMode(t.LightID, t.LightMode, t.EnableAt)
+		}
+	}
+
+	if err != nil {
+		log.Warnw("Action failed", "action", t.Kind, "target", result.Target, "error", err)
+		result.Error = err.Error()
+		return result
+	}
+
+	log.Infow("Action succeeded", "action", t.Kind, "target", result.Target, "value", result.Value, "dryRun", c.DryRun)
+	result.Success = true
+	result.DryRun = c.DryRun
+	return result
+}
+
+// Failed returns the number of failed results
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if !r.Success {
+			n++
+		}
+	}
+	return n
+}
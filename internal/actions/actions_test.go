@@ -0,0 +1,274 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newTestServer serves viewers, liveviews, cameras and lights
+func newTestServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower"},{"id":"vp2","name":"Lobby"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Driveway"}]`)
+	server.Set("lights", `[{"id":"light1","name":"Garage"}]`)
+	return server
+}
+
+func TestParsePreset(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "home", want: -1},
+		{input: "HOME", want: -1},
+		{input: "-1", want: -1},
+		{input: "5", want: 5},
+		{input: "five", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePreset(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePreset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFromScene(t *testing.T) {
+	scene := config.Scene{
+		Name:     "Shift Change",
+		Switches: []config.SceneSwitch{{Viewport: "Tower", Liveview: "Driveway"}},
+		PTZ:      []config.ScenePTZ{{Camera: "Front Door", Preset: "home"}},
+	}
+
+	got, err := FromScene(scene)
+	if err != nil {
+		t.Fatalf("FromScene() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0] != Switch("Tower", "Driveway") || got[1] != PTZ("Front Door", -1) {
+		t.Errorf("FromScene() = %+v", got)
+	}
+
+	if _, err := FromScene(config.Scene{Name: "Empty"}); err == nil {
+		t.Error("Expected error for empty scene")
+	}
+
+	bad := config.Scene{Name: "Bad", PTZ: []config.ScenePTZ{{Camera: "Front Door", Preset: "up"}}}
+	if _, err := FromScene(bad); err == nil {
+		t.Error("Expected error for invalid preset")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	server := newTestServer(t)
+
+	r := NewResolver(server.APIClient())
+
+	targets, err := r.Resolve([]Action{Switch("Tower", "lv2"), PTZ("Front Door", 3)})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	if targets[0].ViewportID != "vp1" || targets[0].LiveviewName != "Driveway" {
+		t.Errorf("Unexpected switch target: %+v", targets[0])
+	}
+
+	if targets[1].CameraID != "cam1" || targets[1].Preset != 3 {
+		t.Errorf("Unexpected PTZ target: %+v", targets[1])
+	}
+
+	// Every unresolved target is reported, not just the first
+	_, err = r.Resolve([]Action{Switch("Attic", "Driveway"), PTZ("Garage", 1), PTZ("Front Door", 12)})
+	if err == nil {
+		t.Fatal("Expected error for unresolved targets")
+	}
+
+	for _, want := range []string{"viewport not found: Attic", "camera not found: Garage", "invalid preset value: 12"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain '%s', got '%v'", want, err)
+		}
+	}
+
+	mutations := server.Requests()
+	if len(mutations) != 0 {
+		t.Errorf("Expected no mutations while resolving, got %v", mutations)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail("cameras/cam2/ptz/goto/0", http.StatusInternalServerError)
+
+	c := server.APIClient()
+	targets, err := NewResolver(c).Resolve([]Action{
+		Switch("Tower", "Driveway"),
+		Switch("Lobby", "All Cameras"),
+		PTZ("Front Door", -1),
+		PTZ("Driveway", 0),
+	})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	results := Execute(context.Background(), c, targets, 2)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	mutations := server.Requests()
+	if len(mutations) != 4 {
+		t.Errorf("Expected 4 requests, got %v", mutations)
+	}
+
+	if !results[0].Success || results[0].Target != "Tower" || results[0].Value != "Driveway" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+
+	if !results[2].Success || results[2].Value != "home position" || results[2].ValueID != "-1" {
+		t.Errorf("Unexpected home result: %+v", results[2])
+	}
+
+	if results[3].Success || results[3].Error == "" {
+		t.Errorf("Expected last action to fail, got %+v", results[3])
+	}
+
+	if got := Failed(results); got != 1 {
+		t.Errorf("Failed() = %d, want 1", got)
+	}
+}
+
+// writeRecorder records each Write separately. It has no lock of its own,
+// so concurrent writes show up under the race detector.
+type writeRecorder struct {
+	writes []string
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestExecuteDryRun(t *testing.T) {
+	var viewers []client.Viewer
+	var liveviews []client.Liveview
+	var acts []Action
+	for i := 1; i <= 8; i++ {
+		viewers = append(viewers, client.Viewer{ID: fmt.Sprintf("vp%d", i), Name: fmt.Sprintf("Viewer %d", i)})
+		liveviews = append(liveviews, client.Liveview{ID: fmt.Sprintf("lv%d", i), Name: fmt.Sprintf("Liveview %d", i)})
+		acts = append(acts, Switch(fmt.Sprintf("Viewer %d", i), fmt.Sprintf("Liveview %d", i)))
+	}
+
+	c := client.NewClient("https://protect.example.com", "test-token")
+	c.DryRun = true
+	out := &writeRecorder{}
+	c.DryRunOutput = out
+
+	targets, err := NewCachedResolver(c, viewers, liveviews, nil, nil).Resolve(acts)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if failed := Failed(Execute(context.Background(), c, targets, DefaultWorkers)); failed != 0 {
+		t.Fatalf("Expected no failures, got %d", failed)
+	}
+
+	if len(out.writes) != len(acts) {
+		t.Fatalf("Expected one write per request, got %q", out.writes)
+	}
+	for _, w := range out.writes {
+		lines := strings.Split(strings.TrimSuffix(w, "\n"), "\n")
+		if len(lines) != 2 {
+			t.Errorf("Expected a request line and its body, got %q", w)
+			continue
+		}
+		id := strings.TrimPrefix(lines[0], "DRY RUN: PATCH https://protect.example.com/proxy/protect/integration/v1/viewers/vp")
+		if want := fmt.Sprintf(`{"liveview":"lv%s"}`, id); lines[1] != want {
+			t.Errorf("Body %q follows %q, want %q", lines[1], lines[0], want)
+		}
+	}
+}
+
+func TestExecutePatrol(t *testing.T) {
+	server := newTestServer(t)
+
+	c := server.APIClient()
+	if _, err := NewResolver(c).Resolve([]Action{Patrol("Front Door", 5)}); err == nil {
+		t.Error("Expected error for patrol slot 5")
+	}
+
+	targets, err := NewResolver(c).Resolve([]Action{Patrol("Front Door", 2), Patrol("Driveway", -1)})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	results := Execute(context.Background(), c, targets, 1)
+	if Failed(results) != 0 || results[0].Value != "patrol 2" || results[1].Value != "no patrol" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	want := "POST /proxy/protect/integration/v1/cameras/cam1/ptz/patrol/start/2 POST /proxy/protect/integration/v1/cameras/cam2/ptz/patrol/stop"
+	mutations := server.Requests()
+	if strings.Join(mutations, " ") != want {
+		t.Errorf("Expected requests %q, got %v", want, mutations)
+	}
+}
+
+func TestExecuteLight(t *testing.T) {
+	server := newTestServer(t)
+
+	c := server.APIClient()
+	if _, err := NewResolver(c).Resolve([]Action{LightMode("Garage", "sometimes", "")}); err == nil {
+		t.Error("Expected error for an unknown light mode")
+	}
+	if _, err := NewResolver(c).Resolve([]Action{LightMode("Porch", "motion", "")}); err == nil {
+		t.Error("Expected error for an unknown light")
+	}
+
+	targets, err := NewResolver(c).Resolve([]Action{LightMode("Garage", "motion", "dark")})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	results := Execute(context.Background(), c, targets, 1)
+	if Failed(results) != 0 || results[0].Target != "Garage" || results[0].Value != "motion (when dark)" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	mutations := server.Requests()
+	if strings.Join(mutations, " ") != "PATCH /proxy/protect/integration/v1/lights/light1" {
+		t.Errorf("Unexpected requests: %v", mutations)
+	}
+}
+
+func TestExecuteCancelled(t *testing.T) {
+	server := newTestServer(t)
+
+	c := server.APIClient()
+	targets, err := NewResolver(c).Resolve([]Action{Switch("Tower", "Driveway")})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := Execute(ctx, c, targets, 0)
+	mutations := server.Requests()
+	if results[0].Success || len(mutations) != 0 {
+		t.Errorf("Expected cancelled action not to run, got %+v (%v)", results[0], mutations)
+	}
+}
@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/rpc"
+)
+
+// Methods the agent answers in addition to those of the rpc package
+const (
+	MethodRun    = "run"
+	MethodStatus = "status"
+)
+
+// Error codes for callers whose settings differ from the agent's
+const (
+	// CodeWrongConsole is returned when a caller is configured for a
+	// different console than the agent
+	CodeWrongConsole = -32002
+	// CodeWrongToken is returned when a caller uses a different API token
+	// than the agent
+	CodeWrongToken = -32003
+)
+
+// RunParams are the params of MethodRun. URL is the caller's console and
+// TokenHash the hash of its API token, so an agent started with other
+// settings, or with a key the caller was not given, is never used.
+type RunParams struct {
+	URL       string           `json:"url"`
+	TokenHash string           `json:"token_hash"`
+	Actions   []actions.Action `json:"actions"`
+}
+
+// TokenHash returns the hex SHA-256 of an API token, so requests can prove
+// which token a caller holds without sending it
+func TokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Status describes a running agent
+type Status struct {
+	URL       string    `json:"url"`
+	PID       int       `json:"pid"`
+	Started   time.Time `json:"started"`
+	Loaded    bool      `json:"loaded"`
+	Viewports int       `json:"viewports"`
+	Liveviews int       `json:"liveviews"`
+	Cameras   int       `json:"cameras"`
+}
+
+// SocketPath returns the default socket path:
+// $XDG_RUNTIME_DIR/protect/agent.sock, or a per-user directory under the
+// system temp directory when XDG_RUNTIME_DIR is unset
+func SocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("protect-%d", os.Getuid()), "agent.sock")
+	}
+	return filepath.Join(dir, "protect", "agent.sock")
+}
+
+// Agent holds one client and a live inventory and answers JSON-RPC
+// requests on a Unix socket
+type Agent struct {
+	client  *client.Client
+	inv     *inventory.Inventory
+	server  *rpc.Server
+	started time.Time
+}
+
+// New creates an agent for c. Scenes are looked up in cfg.
+func New(c *client.Client, cfg *config.Config) *Agent {
+	a := &Agent{client: c, inv: inventory.New(), started: time.Now()}
+
+	a.server = rpc.New(c, cfg)
+	// Callers connect for a single request; they have no use for events
+	a.server.Notify = false
+	a.server.Inventory = a.inv
+	a.server.Handle(MethodRun, a.run)
+	a.server.Handle(MethodStatus, a.status)
+	return a
+}
+
+// Inventory returns the agent's live inventory
+func (a *Agent) Inventory() *inventory.Inventory {
+	return a.inv
+}
+
+// Run keeps the inventory current and serves requests on path until ctx is
+// cancelled. The socket is removed on return.
+func (a *Agent) Run(ctx context.Context, path string) error {
+	l, err := Listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	go func() {
+		if err := a.inv.Run(ctx, a.client, nil); err != nil && ctx.Err() == nil {
+			logger.Get().Warnw("Live inventory unavailable", "error", err)
+		}
+	}()
+
+	logger.Get().Infow("Agent listening", "socket", path)
+	return a.Serve(ctx, l)
+}
+
+// Serve answers requests on every connection accepted from l until ctx is
+// cancelled, then closes l
+func (a *Agent) Serve(ctx context.Context, l net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			// Close the connection on shutdown so Serve stops reading
+			connCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go func() {
+				<-connCtx.Done()
+				conn.Close()
+			}()
+
+			if err := a.server.Serve(connCtx, conn, conn); err != nil && ctx.Err() == nil {
+				logger.Get().Debugw("Agent connection closed", "error", err)
+			}
+		}()
+	}
+}
+
+// Listen creates the socket at path, readable only by the current user.
+// A socket left behind by an agent that has exited is replaced; one that
+// still answers is an error.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("agent already running on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return l, nil
+}
+
+func (a *Agent) run(ctx context.Context, params json.RawMessage) (any, error) {
+	var p RunParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpc.Error{Code: rpc.CodeInvalidParams, Message: err.Error()}
+	}
+	if p.URL != a.client.BaseURL {
+		return nil, &rpc.Error{Code: CodeWrongConsole, Message: fmt.Sprintf("agent is connected to %s", a.client.BaseURL)}
+	}
+	if subtle.ConstantTimeCompare([]byte(p.TokenHash), []byte(TokenHash(a.client.APIToken))) != 1 {
+		return nil, &rpc.Error{Code: CodeWrongToken, Message: "agent uses a different API token"}
+	}
+	if len(p.Actions) == 0 {
+		return nil, &rpc.Error{Code: rpc.CodeInvalidParams, Message: "no actions given"}
+	}
+
+	return a.server.Run(ctx, p.Actions)
+}
+
+func (a *Agent) status(ctx context.Context, params json.RawMessage) (any, error) {
+	snap := a.inv.Snapshot()
+	return &Status{
+		URL:       a.client.BaseURL,
+		PID:       os.Getpid(),
+		Started:   a.started,
+		Loaded:    a.inv.Loaded(),
+		Viewports: len(snap.Viewers),
+		Liveviews: len(snap.Liveviews),
+		Cameras:   len(snap.Cameras),
+	}, nil
+}
@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newTestServer serves fixed device lists and fails PTZ moves for cam2
+func newTestServer(t *testing.T) (*protecttest.Server, *client.Client) {
+	t.Helper()
+
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door","modelKey":"camera"},{"id":"cam2","name":"Garage","modelKey":"camera"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Fail("cameras/cam2/", http.StatusInternalServerError)
+	return server, server.APIClient()
+}
+
+// socketPath returns a short socket path, since Unix socket paths are
+// limited to about 100 bytes
+func socketPath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "protect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "agent.sock")
+}
+
+// startAgent serves a with a loaded inventory until the test ends
+func startAgent(t *testing.T, a *Agent, c *client.Client, path string) {
+	t.Helper()
+
+	if err := a.Inventory().Load(c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	ts, c := newTestServer(t)
+	path := socketPath(t)
+	startAgent(t, New(c, &config.Config{}), c, path)
+
+	lookups := ts.Lookups()
+
+	conn, err := Dial(path)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	results, err := conn.Run(c.BaseURL, c.APIToken, []actions.Action{actions.Switch("Tower", "Driveway"), actions.PTZ("Garage", 1)})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 2 || !results[0].Success || results[0].ValueID != "lv2" || results[1].Success || results[1].Error == "" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	if n := ts.Lookups(); n != lookups {
+		t.Errorf("Expected names to come from the inventory, got %d lookups", n-lookups)
+	}
+	if requests := ts.Requests(); len(requests) != 2 || requests[0] != "PATCH /proxy/protect/integration/v1/viewers/vp1" {
+		t.Errorf("Unexpected requests: %v", requests)
+	}
+
+	// The same connection serves further requests
+	if _, err := conn.Run(c.BaseURL, c.APIToken, []actions.Action{actions.Switch("Lobby", "Driveway")}); err == nil || !strings.Contains(err.Error(), "viewport not found: Lobby") {
+		t.Errorf("Expected an unknown viewport error, got %v", err)
+	}
+	if _, err := conn.Run("https://other.example.com", c.APIToken, []actions.Action{actions.Switch("Tower", "Driveway")}); !errors.Is(err, ErrWrongConsole) {
+		t.Errorf("Expected ErrWrongConsole, got %v", err)
+	}
+
+	// A caller with another key never runs with the agent's
+	if _, err := conn.Run(c.BaseURL, "restricted-token", []actions.Action{actions.Switch("Tower", "Driveway")}); !errors.Is(err, ErrWrongToken) {
+		t.Errorf("Expected ErrWrongToken, got %v", err)
+	}
+	if requests := ts.Requests(); len(requests) != 2 {
+		t.Errorf("Expected no requests for other settings, got %v", requests)
+	}
+
+	status, err := conn.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.URL != c.BaseURL || status.PID != os.Getpid() || !status.Loaded || status.Viewports != 1 || status.Liveviews != 2 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+func TestListen(t *testing.T) {
+	_, c := newTestServer(t)
+	path := socketPath(t)
+
+	// A file left behind by an agent that exited is replaced
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	startAgent(t, New(c, &config.Config{}), c, path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Socket mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("Expected an already running error, got %v", err)
+	}
+}
+
+func TestDialNoAgent(t *testing.T) {
+	if _, err := Dial(socketPath(t)); err == nil {
+		t.Error("Expected an error without an agent")
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	if got := SocketPath(); got != filepath.Join("/run/user/1000", "protect", "agent.sock") {
+		t.Errorf("SocketPath() = %q", got)
+	}
+}
+
+// stuckListener accepts connections on path but never replies
+func stuckListener(t *testing.T, path string) {
+	t.Helper()
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		l.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				<-done
+			}()
+		}
+	}()
+}
+
+func TestRunNoResponse(t *testing.T) {
+	defer func(timeout time.Duration) { CallTimeout = timeout }(CallTimeout)
+	CallTimeout = 50 * time.Millisecond
+
+	path := socketPath(t)
+	stuckListener(t, path)
+
+	conn, err := Dial(path)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err := conn.Run("https://protect.example.com", "test-token", []actions.Action{actions.Switch("Tower", "Driveway")}); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("Expected ErrNoResponse, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run() took %v to give up", elapsed)
+	}
+}
+
+func TestRunNotDelivered(t *testing.T) {
+	defer func(timeout time.Duration) { sendTimeout = timeout }(sendTimeout)
+	sendTimeout = 50 * time.Millisecond
+
+	// Nothing reads the other end of the pipe, so the write never starts
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &Conn{conn: client, reader: bufio.NewReader(client)}
+	defer conn.Close()
+
+	if _, err := conn.Run("https://protect.example.com", "test-token", []actions.Action{actions.Switch("Tower", "Driveway")}); !errors.Is(err, ErrNotDelivered) {
+		t.Errorf("Expected ErrNotDelivered, got %v", err)
+	}
+}
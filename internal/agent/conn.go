@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/rpc"
+)
+
+// dialTimeout bounds how long a caller waits for an agent that is not
+// accepting connections before running the command itself
+const dialTimeout = 200 * time.Millisecond
+
+// sendTimeout bounds writing a request. An agent that is not reading
+// never received the request, so the caller can safely run it itself.
+var sendTimeout = 1 * time.Second
+
+// CallTimeout bounds waiting for the reply to a request that was sent. The
+// agent may still be carrying it out, so it is long enough for a bulk run
+// against a slow console.
+var CallTimeout = 2 * time.Minute
+
+var (
+	// ErrWrongConsole is returned by Run when the agent is connected to a
+	// different console than the caller
+	ErrWrongConsole = errors.New("agent is connected to a different console")
+	// ErrWrongToken is returned by Run when the agent uses a different API
+	// token than the caller
+	ErrWrongToken = errors.New("agent uses a different API token")
+	// ErrNotDelivered is returned when no part of a request reached the
+	// agent, so nothing was run
+	ErrNotDelivered = errors.New("request not delivered to agent")
+	// ErrNoResponse is returned when the agent does not answer within
+	// CallTimeout; the connection is closed and the request may still run
+	ErrNoResponse = errors.New("agent did not respond")
+)
+
+// Conn is a connection to a running agent
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+// Dial connects to the agent listening on path
+func Dial(path string) (*Conn, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	return &Conn{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// call sends one request and decodes its result into result. An error
+// reply is returned as an *rpc.Error.
+func (c *Conn) call(method string, params, result any) error {
+	c.nextID++
+
+	req := struct {
+		JSONRPC string `json:"jsonrpc"`
+		ID      int    `json:"id"`
+		Method  string `json:"method"`
+		Params  any    `json:"params,omitempty"`
+	}{rpc.Version, c.nextID, method, params}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	if err := c.conn.SetWriteDeadline(time.Now().Add(sendTimeout)); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+	if n, err := c.conn.Write(append(data, '\n')); err != nil {
+		c.conn.Close()
+		if n == 0 {
+			return fmt.Errorf("failed to send request: %w: %v", ErrNotDelivered, err)
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if err := c.conn.SetReadDeadline(time.Now().Add(CallTimeout)); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.conn.Close()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("failed to read response: %w after %v", ErrNoResponse, CallTimeout)
+		}
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resp struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpc.Error      `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if string(resp.ID) != strconv.Itoa(c.nextID) {
+		return fmt.Errorf("unexpected response id %s", resp.ID)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// Run asks the agent to run acts against the console at url with token
+// and returns one result per action. Failed actions are reported in their
+// results; unknown names and other errors are returned as an error, with
+// ErrWrongConsole or ErrWrongToken when the agent's settings differ,
+// ErrNotDelivered when the request never reached it and ErrNoResponse when
+// it does not answer in time.
+func (c *Conn) Run(url, token string, acts []actions.Action) ([]actions.Result, error) {
+	var result rpc.ActionResult
+	err := c.call(MethodRun, RunParams{URL: url, TokenHash: TokenHash(token), Actions: acts}, &result)
+
+	var rpcErr *rpc.Error
+	switch {
+	case errors.As(err, &rpcErr) && rpcErr.Code == CodeWrongConsole:
+		return nil, ErrWrongConsole
+	case errors.As(err, &rpcErr) && rpcErr.Code == CodeWrongToken:
+		return nil, ErrWrongToken
+	case errors.As(err, &rpcErr) && rpcErr.Code == rpc.CodeActionFailed:
+		// The results of every action ride along with the error
+		data, mErr := json.Marshal(rpcErr.Data)
+		if mErr == nil {
+			mErr = json.Unmarshal(data, &result)
+		}
+		if mErr != nil {
+			return nil, fmt.Errorf("failed to decode results: %w", mErr)
+		}
+		return result.Results, nil
+	case err != nil:
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// Status returns the agent's status
+func (c *Conn) Status() (*Status, error) {
+	var status Status
+	if err := c.call(MethodStatus, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,599 @@
+package automate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/logger"
+	"github.com/methridge/protect/internal/schedule"
+)
+
+// Kinds of rule steps that are not Protect actions
+const (
+	KindExec    actions.Kind = "exec"
+	KindWebhook actions.Kind = "webhook"
+)
+
+// Timeouts for exec and webhook steps
+var (
+	ExecTimeout    = 30 * time.Second
+	WebhookTimeout = 10 * time.Second
+)
+
+// step is one resolved rule action
+type step struct {
+	target  *actions.Target
+	exec    string
+	webhook *config.Webhook
+}
+
+// window limits a rule to a time of day and days of the week
+type window struct {
+	start, end int // minutes since midnight; start == end means all day
+	days       *schedule.Cron
+}
+
+// Rule is a configured rule with every name resolved
+type Rule struct {
+	Name     string
+	cameras  map[string]bool
+	types    map[client.EventType]bool
+	smart    bool
+	sensor   bool
+	classes  map[string]bool
+	window   *window
+	steps    []step
+	cooldown time.Duration
+	revert   time.Duration
+}
+
+// Compile resolves the configured rules. Every camera, viewport, liveview
+// and scene is looked up once, so a typo is reported at startup rather than
+// when an event arrives; all problems are reported together.
+func Compile(c *client.Client, cfg *config.Config) ([]*Rule, error) {
+	resolver := actions.NewResolver(c)
+
+	var rules []*Rule
+	var errs []error
+	for _, r := range cfg.Rules {
+		rule, err := compile(resolver, cfg, r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.Name, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return rules, nil
+}
+
+func compile(resolver *actions.Resolver, cfg *config.Config, r config.Rule) (*Rule, error) {
+	rule := &Rule{Name: r.Name, cooldown: r.Cooldown, revert: r.Revert}
+	var errs []error
+
+	if len(r.When.Cameras) > 0 {
+		rule.cameras = make(map[string]bool)
+		for _, name := range r.When.Cameras {
+			cam, err := resolver.Camera(name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			rule.cameras[cam.ID] = true
+		}
+	}
+
+	if len(r.When.Types) > 0 {
+		rule.types = make(map[client.EventType]bool)
+		for _, t := range r.When.Types {
+			switch t {
+			case "smart":
+				rule.smart = true
+			case "sensor":
+				rule.sensor = true
+			default:
+				rule.types[client.EventType(t)] = true
+			}
+		}
+	}
+
+	if len(r.When.Smart) > 0 {
+		rule.classes = make(map[string]bool)
+		for _, class := range r.When.Smart {
+			rule.classes[strings.ToLower(class)] = true
+		}
+	}
+
+	if r.When.Between != "" || len(r.When.Days) > 0 {
+		w, err := parseWindow(r.When.Between, r.When.Days, r.When.Timezone)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		rule.window = w
+	}
+
+	var acts []actions.Action
+	for _, a := range r.Then {
+		switch {
+		case a.Switch != nil:
+			acts = append(acts, actions.Switch(a.Switch.Viewport, a.Switch.Liveview))
+			rule.steps = append(rule.steps, step{})
+		case a.PTZ != nil:
+			preset, err := actions.ParsePreset(a.PTZ.Preset)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			acts = append(acts, actions.PTZ(a.PTZ.Camera, preset))
+			rule.steps = append(rule.steps, step{})
+		case a.Scene != "":
+			scene, err := cfg.Scene(a.Scene)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			sceneActs, err := actions.FromScene(*scene)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, sa := range sceneActs {
+				acts = append(acts, sa)
+				rule.steps = append(rule.steps, step{})
+			}
+		case a.Exec != "":
+			rule.steps = append(rule.steps, step{exec: a.Exec})
+		case a.Webhook != nil:
+			rule.steps = append(rule.steps, step{webhook: a.Webhook})
+		}
+	}
+
+	targets, err := resolver.Resolve(acts)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// Fill the Protect steps in order with their resolved targets
+	next := 0
+	for i := range rule.steps {
+		if rule.steps[i].exec == "" && rule.steps[i].webhook == nil {
+			rule.steps[i].target = &targets[next]
+			next++
+		}
+	}
+
+	return rule, nil
+}
+
+// parseWindow parses "HH:MM-HH:MM" (which may wrap past midnight) and days
+func parseWindow(between string, days []string, timezone string) (*window, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone: %s", timezone)
+		}
+	}
+
+	dow, err := schedule.DaysToCron(days)
+	if err != nil {
+		return nil, err
+	}
+	cron, err := schedule.ParseCron("* * * * "+dow, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &window{days: cron}
+	if between != "" {
+		startArg, endArg, ok := strings.Cut(between, "-")
+		start, startErr := parseClock(startArg)
+		end, endErr := parseClock(endArg)
+		if !ok || startErr != nil || endErr != nil {
+			return nil, fmt.Errorf("invalid time window: %s (expected format: HH:MM-HH:MM)", between)
+		}
+		w.start, w.end = start, end
+	}
+
+	return w, nil
+}
+
+// parseClock parses HH:MM into minutes since midnight
+func parseClock(s string) (int, error) {
+	hourArg, minuteArg, ok := strings.Cut(strings.TrimSpace(s), ":")
+	hour, hourErr := strconv.Atoi(hourArg)
+	minute, minuteErr := strconv.Atoi(minuteArg)
+	if !ok || hourErr != nil || minuteErr != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time: %s", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// contains returns true if t falls within the window
+func (w *window) contains(t time.Time) bool {
+	if !w.days.Matches(t) {
+		return false
+	}
+
+	t = t.In(w.days.Location())
+	m := t.Hour()*60 + t.Minute()
+	switch {
+	case w.start == w.end:
+		return true
+	case w.start < w.end:
+		return m >= w.start && m < w.end
+	default:
+		return m >= w.start || m < w.end
+	}
+}
+
+// Match returns true if the event satisfies the rule's conditions at t.
+// Only new events ("add") match, so an event's later updates do not fire
+// the rule again.
+func (r *Rule) Match(e client.Event, t time.Time) bool {
+	if e.Action != "add" {
+		return false
+	}
+
+	if r.cameras != nil && !r.cameras[e.Device] {
+		return false
+	}
+
+	if r.types != nil && !r.types[e.Type] && !(r.smart && e.Type.IsSmartDetect()) && !(r.sensor && e.Type.IsSensor()) {
+		return false
+	}
+
+	if r.classes != nil {
+		found := false
+		for _, class := range e.SmartDetectTypes {
+			if r.classes[strings.ToLower(class)] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return r.window == nil || r.window.contains(t)
+}
+
+// Firing reports a rule run, or the revert of its viewport switches
+type Firing struct {
+	Rule     string           `json:"rule"`
+	Time     time.Time        `json:"time"`
+	Event    *client.Event    `json:"event,omitempty"`
+	Reverted bool             `json:"reverted,omitempty"`
+	Results  []actions.Result `json:"results"`
+}
+
+// Options controls how the engine runs
+type Options struct {
+	// OnFire, if set, is called after each rule run and each revert
+	OnFire func(Firing)
+	// Now replaces the clock, for tests
+	Now func() time.Time
+	// HTTPClient sends webhooks; http.DefaultClient is used if nil
+	HTTPClient *http.Client
+}
+
+// pendingRevert is a viewport waiting to be switched back
+type pendingRevert struct {
+	rule     string
+	target   actions.Target
+	original string
+	timer    *time.Timer
+}
+
+// Engine evaluates rules against events
+type Engine struct {
+	client *client.Client
+	rules  []*Rule
+	opts   Options
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+	reverts   map[string]*pendingRevert
+	wg        sync.WaitGroup
+}
+
+// NewEngine creates an engine for the compiled rules
+func NewEngine(c *client.Client, rules []*Rule, opts Options) *Engine {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	return &Engine{
+		client:    c,
+		rules:     rules,
+		opts:      opts,
+		lastFired: make(map[string]time.Time),
+		reverts:   make(map[string]*pendingRevert),
+	}
+}
+
+// Run handles events until the channel closes or ctx is cancelled. Pending
+// reverts are then carried out immediately, so viewports are never left on
+// a temporary liveview.
+func (e *Engine) Run(ctx context.Context, events <-chan client.Event) error {
+	defer e.flushReverts()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.wg.Wait()
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				e.wg.Wait()
+				return nil
+			}
+			e.Handle(ctx, event)
+		}
+	}
+}
+
+// Handle fires every rule that matches the event and is not cooling down.
+// Rules run in the background so a slow webhook does not hold up others.
+func (e *Engine) Handle(ctx context.Context, event client.Event) {
+	log := logger.Get()
+
+	// Time windows apply to when the event started, not when it arrived
+	at := event.StartTime()
+	if at.IsZero() {
+		at = e.opts.Now()
+	}
+
+	for _, r := range e.rules {
+		if !r.Match(event, at) {
+			continue
+		}
+
+		now := e.opts.Now()
+
+		e.mu.Lock()
+		if last, ok := e.lastFired[r.Name]; ok && r.cooldown > 0 && now.Sub(last) < r.cooldown {
+			e.mu.Unlock()
+			log.Debugw("Rule cooling down", "rule", r.Name, "event", event.ID)
+			continue
+		}
+		e.lastFired[r.Name] = now
+		e.mu.Unlock()
+
+		log.Infow("Rule matched", "rule", r.Name, "event", event.ID, "type", event.Type, "device", event.Device)
+
+		e.wg.Add(1)
+		go func(r *Rule) {
+			defer e.wg.Done()
+			e.fire(ctx, r, event)
+		}(r)
+	}
+}
+
+// fire runs a rule's steps in order
+func (e *Engine) fire(ctx context.Context, r *Rule, event client.Event) {
+	var current map[string]string
+	if r.revert > 0 {
+		current = e.currentLiveviews()
+	}
+
+	var results []actions.Result
+	for _, s := range r.steps {
+		var result actions.Result
+		switch {
+		case s.exec != "":
+			result = e.runExec(ctx, r, s.exec, event)
+		case s.webhook != nil:
+			result = e.sendWebhook(ctx, r, s.webhook, event)
+		default:
+			result = actions.Execute(ctx, e.client, []actions.Target{*s.target}, 1)[0]
+			if result.Success && r.revert > 0 && s.target.Kind == actions.KindSwitch {
+				e.scheduleRevert(r, *s.target, current[s.target.ViewportID])
+			}
+		}
+		results = append(results, result)
+	}
+
+	if e.opts.OnFire != nil {
+		e.opts.OnFire(Firing{Rule: r.Name, Time: e.opts.Now(), Event: &event, Results: results})
+	}
+}
+
+// currentLiveviews returns the liveview each viewport is showing
+func (e *Engine) currentLiveviews() map[string]string {
+	viewports, err := e.client.ListViewports()
+	if err != nil {
+		logger.Get().Warnw("Failed to read viewports for revert", "error", err)
+		return nil
+	}
+
+	current := make(map[string]string, len(viewports))
+	for _, vp := range viewports {
+		current[vp.ID] = vp.Liveview
+	}
+	return current
+}
+
+// scheduleRevert arranges for a viewport to be switched back after the
+// rule's revert time. If a revert is already pending, its timer restarts
+// and the liveview from before the first switch is kept.
+func (e *Engine) scheduleRevert(r *Rule, target actions.Target, original string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if p, ok := e.reverts[target.ViewportID]; ok {
+		p.timer.Reset(r.revert)
+		return
+	}
+
+	if original == "" || original == target.LiveviewID {
+		return
+	}
+
+	p := &pendingRevert{rule: r.Name, target: target, original: original}
+	p.timer = time.AfterFunc(r.revert, func() { e.revertNow(target.ViewportID) })
+	e.reverts[target.ViewportID] = p
+}
+
+// revertNow switches a viewport back if a revert is still pending
+func (e *Engine) revertNow(viewportID string) {
+	e.mu.Lock()
+	p, ok := e.reverts[viewportID]
+	if ok {
+		delete(e.reverts, viewportID)
+	}
+	e.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	t := p.target
+	t.Action = actions.Switch(t.ViewportID, p.original)
+	t.LiveviewID, t.LiveviewName = p.original, p.original
+	if lv, err := actions.NewResolver(e.client).Liveview(p.original); err == nil {
+		t.LiveviewName = lv.Name
+	}
+	result := actions.Execute(context.Background(), e.client, []actions.Target{t}, 1)[0]
+
+	if e.opts.OnFire != nil {
+		e.opts.OnFire(Firing{Rule: p.rule, Time: e.opts.Now(), Reverted: true, Results: []actions.Result{result}})
+	}
+}
+
+// flushReverts carries out every pending revert now
+func (e *Engine) flushReverts() {
+	e.mu.Lock()
+	var ids []string
+	for id, p := range e.reverts {
+		p.timer.Stop()
+		ids = append(ids, id)
+	}
+	e.mu.Unlock()
+
+	for _, id := range ids {
+		e.revertNow(id)
+	}
+}
+
+// runExec runs a command through the shell with details of the event in
+// the environment
+func (e *Engine) runExec(ctx context.Context, r *Rule, command string, event client.Event) actions.Result {
+	log := logger.Get()
+	result := actions.Result{Action: KindExec, Target: command}
+
+	if e.client.DryRun {
+		fmt.Fprintf(e.client.DryRunWriter(), "DRY RUN: exec %s\n", command)
+		result.Success, result.DryRun = true, true
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ExecTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"PROTECT_RULE="+r.Name,
+		"PROTECT_EVENT_ID="+event.ID,
+		"PROTECT_EVENT_TYPE="+string(event.Type),
+		"PROTECT_CAMERA_ID="+event.Device,
+		"PROTECT_SMART_TYPES="+strings.Join(event.SmartDetectTypes, ","),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Warnw("Rule command failed", "rule", r.Name, "command", command, "error", err, "output", string(out))
+		result.Error = fmt.Sprintf("command failed: %v", err)
+		return result
+	}
+
+	log.Debugw("Rule command finished", "rule", r.Name, "command", command, "output", string(out))
+	result.Success = true
+	return result
+}
+
+// webhookPayload is the JSON body sent to webhooks
+type webhookPayload struct {
+	Rule  string       `json:"rule"`
+	Event client.Event `json:"event"`
+}
+
+// sendWebhook sends the event to a webhook
+func (e *Engine) sendWebhook(ctx context.Context, r *Rule, hook *config.Webhook, event client.Event) actions.Result {
+	method := strings.ToUpper(hook.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	result := actions.Result{Action: KindWebhook, Target: hook.URL, Value: method}
+
+	body, err := json.Marshal(webhookPayload{Rule: r.Name, Event: event})
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to encode webhook: %v", err)
+		return result
+	}
+
+	if e.client.DryRun {
+		fmt.Fprintf(e.client.DryRunWriter(), "DRY RUN: %s %s\n%s\n", method, hook.URL, body)
+		result.Success, result.DryRun = true, true
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create webhook request: %v", err)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.opts.HTTPClient.Do(req)
+	if err != nil {
+		result.Error = fmt.Sprintf("webhook failed: %v", err)
+		return result
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("webhook returned status %d", resp.StatusCode)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
@@ -0,0 +1,298 @@
+package automate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+)
+
+// fakeProtect serves viewers, liveviews and cameras, records every change
+// and tracks the liveview each viewer is showing
+type fakeProtect struct {
+	mu       sync.Mutex
+	requests []string
+	liveview string
+}
+
+func (f *fakeProtect) handler(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/viewers":
+		w.Write([]byte(`[{"id":"vp1","name":"Reception","liveview":"` + f.liveview + `"}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/liveviews":
+		w.Write([]byte(`[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Entrance"}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/cameras":
+		w.Write([]byte(`[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Garage"}]`))
+	case r.Method == http.MethodPatch:
+		var body struct {
+			Liveview string `json:"liveview"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.liveview = body.Liveview
+		f.requests = append(f.requests, "PATCH "+r.URL.Path+" "+body.Liveview)
+		w.Write([]byte(`{}`))
+	default:
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{}`))
+	}
+}
+
+func (f *fakeProtect) log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func newFake(t *testing.T) (*fakeProtect, *client.Client) {
+	t.Helper()
+	f := &fakeProtect{liveview: "lv1"}
+	server := httptest.NewServer(http.HandlerFunc(f.handler))
+	t.Cleanup(server.Close)
+	return f, client.NewClient(server.URL, "test-token")
+}
+
+func TestCompile(t *testing.T) {
+	_, c := newFake(t)
+
+	cfg := &config.Config{
+		Scenes: []config.Scene{{Name: "night", PTZ: []config.ScenePTZ{{Camera: "Garage", Preset: "2"}}}},
+		Rules: []config.Rule{
+			{Name: "doorbell", When: config.RuleCondition{Cameras: []string{"Front Door"}, Types: []string{"ring"}}, Then: []config.RuleAction{
+				{Switch: &config.SceneSwitch{Viewport: "Reception", Liveview: "Entrance"}},
+				{Exec: "true"},
+				{Scene: "night"},
+			}},
+		},
+	}
+
+	rules, err := Compile(c, cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	steps := rules[0].steps
+	if len(steps) != 3 || steps[0].target.ViewportID != "vp1" || steps[0].target.LiveviewID != "lv2" || steps[1].exec != "true" || steps[2].target.CameraID != "cam2" {
+		t.Errorf("Unexpected steps: %+v", steps)
+	}
+
+	cfg.Rules = []config.Rule{
+		{Name: "bad-camera", When: config.RuleCondition{Cameras: []string{"Attic"}}, Then: []config.RuleAction{{Exec: "true"}}},
+		{Name: "bad-window", When: config.RuleCondition{Between: "22:00"}, Then: []config.RuleAction{{Exec: "true"}}},
+		{Name: "bad-liveview", Then: []config.RuleAction{{Switch: &config.SceneSwitch{Viewport: "Reception", Liveview: "Nowhere"}}}},
+	}
+	_, err = Compile(c, cfg)
+	for _, want := range []string{"rule bad-camera: camera not found: Attic", "rule bad-window: invalid time window", "rule bad-liveview: liveview not found: Nowhere"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Compile() error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	w, err := parseWindow("22:00-06:00", []string{"weekdays"}, "UTC")
+	if err != nil {
+		t.Fatalf("parseWindow() error = %v", err)
+	}
+
+	rule := &Rule{
+		cameras: map[string]bool{"cam1": true},
+		types:   map[client.EventType]bool{},
+		smart:   true,
+		classes: map[string]bool{"person": true},
+		window:  w,
+	}
+
+	// Wednesday 23:30 UTC
+	night := time.Date(2025, 1, 15, 23, 30, 0, 0, time.UTC)
+	person := client.Event{Action: "add", Type: client.EventSmartDetectZone, Device: "cam1", SmartDetectTypes: []string{"vehicle", "Person"}}
+
+	tests := []struct {
+		name  string
+		event client.Event
+		at    time.Time
+		want  bool
+	}{
+		{name: "match", event: person, at: night, want: true},
+		{name: "after midnight", event: person, at: night.Add(3 * time.Hour), want: true},
+		{name: "daytime", event: person, at: night.Add(-12 * time.Hour), want: false},
+		{name: "weekend", event: person, at: night.AddDate(0, 0, 3), want: false},
+		{name: "update", event: client.Event{Action: "update", Type: person.Type, Device: "cam1", SmartDetectTypes: person.SmartDetectTypes}, at: night, want: false},
+		{name: "other camera", event: client.Event{Action: "add", Type: person.Type, Device: "cam2", SmartDetectTypes: person.SmartDetectTypes}, at: night, want: false},
+		{name: "other type", event: client.Event{Action: "add", Type: client.EventMotion, Device: "cam1"}, at: night, want: false},
+		{name: "other class", event: client.Event{Action: "add", Type: person.Type, Device: "cam1", SmartDetectTypes: []string{"animal"}}, at: night, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.Match(tt.event, tt.at); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineCooldownAndRevert(t *testing.T) {
+	f, c := newFake(t)
+
+	cfg := &config.Config{Rules: []config.Rule{{
+		Name:     "doorbell",
+		When:     config.RuleCondition{Cameras: []string{"Front Door"}, Types: []string{"ring"}},
+		Then:     []config.RuleAction{{Switch: &config.SceneSwitch{Viewport: "Reception", Liveview: "Entrance"}}},
+		Cooldown: time.Minute,
+		Revert:   20 * time.Millisecond,
+	}}}
+	rules, err := Compile(c, cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var firings []Firing
+	reverted := make(chan struct{})
+	engine := NewEngine(c, rules, Options{OnFire: func(fr Firing) {
+		mu.Lock()
+		firings = append(firings, fr)
+		mu.Unlock()
+		if fr.Reverted {
+			close(reverted)
+		}
+	}})
+
+	ring := client.Event{Action: "add", ID: "e1", Type: client.EventRing, Device: "cam1"}
+	ctx := context.Background()
+	engine.Handle(ctx, ring)
+	engine.Handle(ctx, ring) // cooling down
+	engine.wg.Wait()
+
+	select {
+	case <-reverted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Viewport was not reverted")
+	}
+
+	want := []string{"PATCH /proxy/protect/integration/v1/viewers/vp1 lv2", "PATCH /proxy/protect/integration/v1/viewers/vp1 lv1"}
+	if got := f.log(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests = %v, want %v", got, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(firings) != 2 || firings[0].Rule != "doorbell" || !firings[0].Results[0].Success || firings[1].Results[0].Value != "All Cameras" {
+		t.Errorf("Unexpected firings: %+v", firings)
+	}
+}
+
+func TestRunFlushesReverts(t *testing.T) {
+	f, c := newFake(t)
+
+	cfg := &config.Config{Rules: []config.Rule{{
+		Name:   "doorbell",
+		Then:   []config.RuleAction{{Switch: &config.SceneSwitch{Viewport: "Reception", Liveview: "Entrance"}}},
+		Revert: time.Hour,
+	}}}
+	rules, err := Compile(c, cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	events := make(chan client.Event, 1)
+	events <- client.Event{Action: "add", ID: "e1", Type: client.EventRing, Device: "cam1"}
+	close(events)
+
+	if err := NewEngine(c, rules, Options{}).Run(context.Background(), events); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := f.log(); len(got) != 2 || !strings.HasSuffix(got[1], " lv1") {
+		t.Errorf("Expected the switch to be reverted on exit, got %v", got)
+	}
+}
+
+func TestExecAndWebhook(t *testing.T) {
+	_, c := newFake(t)
+
+	var got webhookPayload
+	var header string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Token")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer hook.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{Rules: []config.Rule{{
+		Name: "notify",
+		Then: []config.RuleAction{
+			{Exec: `echo "$PROTECT_RULE $PROTECT_EVENT_TYPE $PROTECT_SMART_TYPES" > ` + dir + `/out`},
+			{Webhook: &config.Webhook{URL: hook.URL, Headers: map[string]string{"X-Token": "secret"}}},
+			{Exec: "exit 3"},
+		},
+	}}}
+	rules, err := Compile(c, cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	var firing Firing
+	engine := NewEngine(c, rules, Options{OnFire: func(fr Firing) { firing = fr }})
+	engine.Handle(context.Background(), client.Event{Action: "add", ID: "e1", Type: client.EventSmartDetectZone, Device: "cam1", SmartDetectTypes: []string{"person", "vehicle"}})
+	engine.wg.Wait()
+
+	if len(firing.Results) != 3 || !firing.Results[0].Success || !firing.Results[1].Success || firing.Results[2].Success {
+		t.Fatalf("Unexpected results: %+v", firing.Results)
+	}
+
+	out, _ := os.ReadFile(dir + "/out")
+	if string(out) != "notify smartDetectZone person,vehicle\n" {
+		t.Errorf("Command output = %q", out)
+	}
+
+	if got.Rule != "notify" || got.Event.ID != "e1" || header != "secret" {
+		t.Errorf("Webhook received %+v with header %q", got, header)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	f, c := newFake(t)
+	var buf bytes.Buffer
+	c.DryRun, c.DryRunOutput = true, &buf
+
+	cfg := &config.Config{Rules: []config.Rule{{
+		Name: "notify",
+		Then: []config.RuleAction{
+			{Switch: &config.SceneSwitch{Viewport: "Reception", Liveview: "Entrance"}},
+			{Exec: "touch should-not-exist"},
+			{Webhook: &config.Webhook{URL: "http://example.invalid/hook"}},
+		},
+	}}}
+	rules, err := Compile(c, cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	engine := NewEngine(c, rules, Options{})
+	engine.Handle(context.Background(), client.Event{Action: "add", ID: "e1", Type: client.EventRing})
+	engine.wg.Wait()
+
+	if got := f.log(); len(got) != 0 {
+		t.Errorf("Expected no changes in dry-run mode, got %v", got)
+	}
+
+	for _, want := range []string{"DRY RUN: PATCH", "DRY RUN: exec touch should-not-exist", "DRY RUN: POST http://example.invalid/hook"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Dry-run output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
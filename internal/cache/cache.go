@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is a named resource
+type Entry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Names is a snapshot of resource names used for shell completion, so that
+// pressing tab does not always have to wait for the console
+type Names struct {
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Viewports []Entry   `json:"viewports"`
+	Liveviews []Entry   `json:"liveviews"`
+	Cameras   []Entry   `json:"cameras"`
+}
+
+// Fresh returns true if the names were fetched from url within ttl
+func (n *Names) Fresh(url string, ttl time.Duration) bool {
+	return n.URL == url && time.Since(n.UpdatedAt) < ttl
+}
+
+// DefaultPath returns the cache file location in the user cache directory
+// (honouring XDG_CACHE_HOME)
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "protect", "names.json"), nil
+}
+
+// Load reads cached names from path
+func Load(path string) (*Names, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var names Names
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse cache: %w", err)
+	}
+
+	return &names, nil
+}
+
+// Save writes names to path, creating the directory if needed
+func Save(path string, names *Names) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(names)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent completions never see a
+	// partially written cache
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	return nil
+}
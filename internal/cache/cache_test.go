@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "protect", "names.json")
+
+	names := &Names{
+		URL:       "https://protect.local",
+		UpdatedAt: time.Now(),
+		Viewports: []Entry{{ID: "vp1", Name: "Tower"}},
+		Liveviews: []Entry{{ID: "lv1", Name: "All Cameras"}},
+		Cameras:   []Entry{{ID: "cam1", Name: "Front Door"}},
+	}
+
+	if err := Save(path, names); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if loaded.URL != names.URL || len(loaded.Cameras) != 1 || loaded.Cameras[0].Name != "Front Door" {
+		t.Errorf("Load() = %+v, want %+v", loaded, names)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing cache file")
+	}
+}
+
+func TestFresh(t *testing.T) {
+	names := &Names{URL: "https://protect.local", UpdatedAt: time.Now().Add(-time.Minute)}
+
+	if !names.Fresh("https://protect.local", 5*time.Minute) {
+		t.Error("Expected recent cache to be fresh")
+	}
+
+	if names.Fresh("https://protect.local", 30*time.Second) {
+		t.Error("Expected cache older than the TTL to be stale")
+	}
+
+	if names.Fresh("https://other.local", 5*time.Minute) {
+		t.Error("Expected cache for a different console to be stale")
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error = %v", err)
+	}
+
+	if filepath.Base(path) != "names.json" || filepath.Base(filepath.Dir(path)) != "protect" {
+		t.Errorf("Unexpected cache path '%s'", path)
+	}
+}
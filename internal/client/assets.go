@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/methridge/protect/internal/logger"
+)
+
+// AssetType identifies a kind of device asset file
+type AssetType string
+
+// Asset types supported by the integration API
+const (
+	AssetAnimations AssetType = "animations"
+	AssetRingtones  AssetType = "ringtones"
+)
+
+// assetRules describes the files accepted for an asset type
+type assetRules struct {
+	extensions   []string
+	contentTypes []string
+	maxSize      int64
+}
+
+var assetTypes = map[AssetType]assetRules{
+	AssetAnimations: {
+		extensions:   []string{".gif", ".png"},
+		contentTypes: []string{"image/gif", "image/png"},
+		maxSize:      5 << 20,
+	},
+	AssetRingtones: {
+		extensions:   []string{".mp3", ".wav"},
+		contentTypes: []string{"audio/mpeg", "audio/wave"},
+		maxSize:      10 << 20,
+	},
+}
+
+// Asset represents a device asset file such as a doorbell animation or
+// chime ringtone
+type Asset struct {
+	Name         string    `json:"name"`
+	Type         AssetType `json:"type"`
+	OriginalName string    `json:"originalName"`
+	Path         string    `json:"path"`
+}
+
+// ParseAssetType validates an asset type name
+func ParseAssetType(s string) (AssetType, error) {
+	t := AssetType(s)
+	if _, ok := assetTypes[t]; !ok {
+		return "", fmt.Errorf("invalid asset type: %s (use 'animations' or 'ringtones')", s)
+	}
+	return t, nil
+}
+
+// AssetTypeForFile infers the asset type from a file extension
+func AssetTypeForFile(filename string) (AssetType, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for t, rules := range assetTypes {
+		for _, e := range rules.extensions {
+			if e == ext {
+				return t, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot infer asset type from file: %s", filename)
+}
+
+// ValidateAsset checks a file's extension, content and size against the
+// rules for the asset type
+func ValidateAsset(t AssetType, filename string, data []byte) error {
+	rules, ok := assetTypes[t]
+	if !ok {
+		return fmt.Errorf("invalid asset type: %s", t)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	validExt := false
+	for _, e := range rules.extensions {
+		if e == ext {
+			validExt = true
+			break
+		}
+	}
+	if !validExt {
+		return fmt.Errorf("invalid file extension %q for %s (use %s)", ext, t, strings.Join(rules.extensions, ", "))
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("file is empty: %s", filename)
+	}
+
+	if int64(len(data)) > rules.maxSize {
+		return fmt.Errorf("file too large: %d bytes (maximum %d bytes for %s)", len(data), rules.maxSize, t)
+	}
+
+	contentType := sniffContentType(data)
+	for _, ct := range rules.contentTypes {
+		if ct == contentType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("file content %q does not match %s (expected %s)", contentType, t, strings.Join(rules.contentTypes, ", "))
+}
+
+// sniffContentType detects the media type of a file from its contents
+func sniffContentType(data []byte) string {
+	// MP3 files commonly start with an ID3 tag or an MPEG frame sync,
+	// neither of which http.DetectContentType recognises reliably
+	if bytes.HasPrefix(data, []byte("ID3")) || (len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0) {
+		return "audio/mpeg"
+	}
+
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+// ListAssets retrieves the asset files of the given type
+func (c *Client) ListAssets(t AssetType) ([]Asset, error) {
+	log := logger.Get()
+	log.Debugw("Fetching assets", "type", t)
+
+	data, err := c.doRequest("GET", fmt.Sprintf("/proxy/protect/integration/v1/files/%s", t), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", t, err)
+	}
+
+	var assets []Asset
+	if err := json.Unmarshal(data, &assets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", t, err)
+	}
+
+	return assets, nil
+}
+
+// UploadAsset validates and uploads an asset file
+func (c *Client) UploadAsset(t AssetType, filename string, data []byte) (*Asset, error) {
+	log := logger.Get()
+	log.Infow("Uploading asset", "type", t, "file", filename, "size", len(data))
+
+	if err := ValidateAsset(t, filename, data); err != nil {
+		return nil, err
+	}
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	part, err := mw.CreateFormFile("file", filepath.Base(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/files/%s", t)
+	resp, err := c.doRawRequest("POST", path, mw.FormDataContentType(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload asset: %w", err)
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(resp, &asset); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal asset: %w", err)
+	}
+
+	return &asset, nil
+}
+
+// DeleteAsset deletes an asset file by name
+func (c *Client) DeleteAsset(t AssetType, name string) error {
+	log := logger.Get()
+	log.Infow("Deleting asset", "type", t, "name", name)
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/files/%s/%s", t, url.PathEscape(name))
+	if _, err := c.doRequest("DELETE", path, nil); err != nil {
+		return fmt.Errorf("failed to delete asset: %w", err)
+	}
+
+	return nil
+}
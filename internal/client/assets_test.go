@@ -0,0 +1,164 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testGIF = []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	testWAV = []byte("RIFF\x24\x00\x00\x00WAVEfmt \x10\x00\x00\x00")
+	testMP3 = []byte("ID3\x03\x00\x00\x00\x00\x00\x00")
+)
+
+func TestAssetTypeForFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     AssetType
+		wantErr  bool
+	}{
+		{filename: "snow.gif", want: AssetAnimations},
+		{filename: "Holiday.PNG", want: AssetAnimations},
+		{filename: "bells.mp3", want: AssetRingtones},
+		{filename: "bells.wav", want: AssetRingtones},
+		{filename: "notes.txt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, err := AssetTypeForFile(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AssetTypeForFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := ParseAssetType("videos"); err == nil {
+		t.Error("Expected error for unknown asset type")
+	}
+}
+
+func TestValidateAsset(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        AssetType
+		filename string
+		data     []byte
+		wantErr  bool
+	}{
+		{name: "Valid GIF animation", t: AssetAnimations, filename: "snow.gif", data: testGIF},
+		{name: "Valid WAV ringtone", t: AssetRingtones, filename: "bells.wav", data: testWAV},
+		{name: "Valid MP3 ringtone", t: AssetRingtones, filename: "bells.mp3", data: testMP3},
+		{name: "Wrong extension", t: AssetRingtones, filename: "snow.gif", data: testGIF, wantErr: true},
+		{name: "Content does not match", t: AssetAnimations, filename: "fake.gif", data: testWAV, wantErr: true},
+		{name: "Empty file", t: AssetAnimations, filename: "empty.gif", data: nil, wantErr: true},
+		{name: "Too large", t: AssetAnimations, filename: "big.gif", data: append(testGIF, make([]byte, 6<<20)...), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAsset(tt.t, tt.filename, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAsset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/files/ringtones" {
+			t.Errorf("Expected path '/proxy/protect/integration/v1/files/ringtones', got '%s'", r.URL.Path)
+		}
+
+		if r.Method != "GET" {
+			t.Errorf("Expected GET method, got '%s'", r.Method)
+		}
+
+		json.NewEncoder(w).Encode([]Asset{
+			{Name: "abc123", Type: AssetRingtones, OriginalName: "bells.mp3"},
+		})
+	}))
+	defer server.Close()
+
+	assets, err := NewClient(server.URL, "test-token").ListAssets(AssetRingtones)
+	if err != nil {
+		t.Fatalf("ListAssets() error = %v", err)
+	}
+
+	if len(assets) != 1 || assets[0].OriginalName != "bells.mp3" {
+		t.Errorf("Unexpected asset data: %+v", assets)
+	}
+}
+
+func TestUploadAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/files/animations" {
+			t.Errorf("Expected path '/proxy/protect/integration/v1/files/animations', got '%s'", r.URL.Path)
+		}
+
+		if r.Method != "POST" {
+			t.Errorf("Expected POST method, got '%s'", r.Method)
+		}
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			t.Errorf("Expected multipart upload, got '%s'", r.Header.Get("Content-Type"))
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file part: %v", err)
+		}
+		defer file.Close()
+
+		data, _ := io.ReadAll(file)
+		if header.Filename != "snow.gif" || string(data) != string(testGIF) {
+			t.Errorf("Unexpected upload '%s' (%d bytes)", header.Filename, len(data))
+		}
+
+		json.NewEncoder(w).Encode(Asset{Name: "abc123", Type: AssetAnimations, OriginalName: header.Filename})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+
+	asset, err := c.UploadAsset(AssetAnimations, "/tmp/snow.gif", testGIF)
+	if err != nil {
+		t.Fatalf("UploadAsset() error = %v", err)
+	}
+
+	if asset.Name != "abc123" {
+		t.Errorf("Expected asset name 'abc123', got '%s'", asset.Name)
+	}
+
+	// Invalid files are rejected before anything is sent
+	if _, err := c.UploadAsset(AssetAnimations, "bells.wav", testWAV); err == nil {
+		t.Error("Expected validation error")
+	}
+}
+
+func TestDeleteAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/files/ringtones/abc123" {
+			t.Errorf("Expected path '/proxy/protect/integration/v1/files/ringtones/abc123', got '%s'", r.URL.Path)
+		}
+
+		if r.Method != "DELETE" {
+			t.Errorf("Expected DELETE method, got '%s'", r.Method)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := NewClient(server.URL, "test-token").DeleteAsset(AssetRingtones, "abc123"); err != nil {
+		t.Fatalf("DeleteAsset() error = %v", err)
+	}
+}
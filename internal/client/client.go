@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/methridge/protect/internal/logger"
@@ -16,6 +19,20 @@ type Client struct {
 	BaseURL    string
 	APIToken   string
 	HTTPClient *http.Client
+
+	// DryRun prints mutating requests to DryRunOutput (stdout if nil)
+	// instead of sending them. GET requests are still sent so that lookups
+	// and name resolution work as usual.
+	DryRun       bool
+	DryRunOutput io.Writer
+
+	// OnRequest, if set, is called after every request sent to the API
+	// with the response status (0 if no response arrived) and duration
+	OnRequest func(method, path string, status int, elapsed time.Duration, err error)
+
+	// dryRunMu keeps each dry-run request and its body together when
+	// requests are sent concurrently
+	dryRunMu sync.Mutex
 }
 
 // NewClient creates a new UniFi Protect API client
@@ -43,15 +60,34 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 		log.Debugw("Request body", "body", string(jsonBody))
 	}
 
+	return c.doRawRequest(method, path, "application/json", reqBody)
+}
+
+// doRawRequest performs an HTTP request with authentication and an
+// arbitrary request body
+func (c *Client) doRawRequest(method, path, contentType string, reqBody io.Reader) (respBody []byte, err error) {
+	log := logger.Get()
+
 	url := fmt.Sprintf("%s%s", c.BaseURL, path)
+
+	if c.DryRun && method != http.MethodGet {
+		return c.printDryRun(method, url, contentType, reqBody)
+	}
+
 	log.Debugw("Making request", "method", method, "url", url)
 
+	start := time.Now()
+	status := 0
+	if c.OnRequest != nil {
+		defer func() { c.OnRequest(method, path, status, time.Since(start), err) }()
+	}
+
 	req, err := http.NewRequest(method, url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("X-API-Key", c.APIToken)
 
 	resp, err := c.HTTPClient.Do(req)
@@ -59,25 +95,74 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	status = resp.StatusCode
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		log.Errorw("Request failed", "status", resp.StatusCode, "body", string(respBody))
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	return respBody, nil
 }
 
+// StatusError is returned when the API responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// DryRunWriter returns where dry-run requests are printed
+func (c *Client) DryRunWriter() io.Writer {
+	if c.DryRunOutput == nil {
+		return os.Stdout
+	}
+	return c.DryRunOutput
+}
+
+// printDryRun prints the request that would have been sent and returns an
+// empty JSON object as the response
+func (c *Client) printDryRun(method, url, contentType string, reqBody io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DRY RUN: %s %s\n", method, url)
+
+	if reqBody != nil {
+		data, err := io.ReadAll(reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+
+		if contentType == "application/json" {
+			fmt.Fprintf(&buf, "%s\n", data)
+		} else if len(data) > 0 {
+			mediaType, _, _ := strings.Cut(contentType, ";")
+			fmt.Fprintf(&buf, "<%d bytes of %s>\n", len(data), mediaType)
+		}
+	}
+
+	c.dryRunMu.Lock()
+	defer c.dryRunMu.Unlock()
+	if _, err := c.DryRunWriter().Write(buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to print dry run: %w", err)
+	}
+
+	return []byte("{}"), nil
+}
+
 // Viewer represents a UniFi Protect viewer (viewport)
 type Viewer struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	Liveview string `json:"liveview"`
+	State    string `json:"state,omitempty"`
 }
 
 // Viewport is an alias for Viewer for backward compatibility
@@ -85,8 +170,16 @@ type Viewport = Viewer
 
 // Liveview represents a UniFi Protect liveview
 type Liveview struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID     string         `json:"id"`
+	Name   string         `json:"name"`
+	Layout int            `json:"layout,omitempty"`
+	Slots  []LiveviewSlot `json:"slots,omitempty"`
+}
+
+// LiveviewSlot is one tile of a liveview layout. A slot with several cameras
+// cycles through them.
+type LiveviewSlot struct {
+	Cameras []string `json:"cameras"`
 }
 
 // Camera is an alias for Liveview for backward compatibility
@@ -97,6 +190,7 @@ type PTZCamera struct {
 	ID               string `json:"id"`
 	Name             string `json:"name"`
 	ModelKey         string `json:"modelKey"`
+	State            string `json:"state,omitempty"`
 	ActivePatrolSlot *int   `json:"activePatrolSlot"`
 }
 
@@ -107,6 +201,29 @@ func (p *PTZCamera) HasPTZ() bool {
 	return p.ActivePatrolSlot != nil || p.ModelKey == "camera"
 }
 
+// MetaInfo describes the UniFi Protect application serving the API
+type MetaInfo struct {
+	ApplicationVersion string `json:"applicationVersion"`
+}
+
+// GetMetaInfo retrieves the UniFi Protect application version
+func (c *Client) GetMetaInfo() (*MetaInfo, error) {
+	log := logger.Get()
+	log.Debug("Fetching meta info")
+
+	data, err := c.doRequest("GET", "/proxy/protect/integration/v1/meta/info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meta info: %w", err)
+	}
+
+	var info MetaInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal meta info: %w", err)
+	}
+
+	return &info, nil
+}
+
 // ListViewports retrieves all available viewports (viewers)
 func (c *Client) ListViewports() ([]Viewport, error) {
 	log := logger.Get()
@@ -188,6 +305,23 @@ func (c *Client) ListPTZCameras() ([]PTZCamera, error) {
 	return cameras, nil
 }
 
+// RenameCamera sets a camera's name
+func (c *Client) RenameCamera(cameraID, name string) error {
+	log := logger.Get()
+	log.Infow("Renaming camera", "cameraID", cameraID, "name", name)
+
+	body := map[string]string{
+		"name": name,
+	}
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/cameras/%s", cameraID)
+	if _, err := c.doRequest("PATCH", path, body); err != nil {
+		return fmt.Errorf("failed to rename camera: %w", err)
+	}
+
+	return nil
+}
+
 // MovePTZToPreset moves a PTZ camera to a specific preset position
 // Preset values can be: -1 (home), 0-9 (preset slots)
 func (c *Client) MovePTZToPreset(cameraID string, preset int) error {
@@ -206,3 +340,61 @@ func (c *Client) MovePTZToPreset(cameraID string, preset int) error {
 
 	return nil
 }
+
+// StartPTZPatrol starts the patrol saved in a slot (0-4) on a PTZ camera
+func (c *Client) StartPTZPatrol(cameraID string, slot int) error {
+	log := logger.Get()
+	log.Infow("Starting PTZ patrol", "cameraID", cameraID, "slot", slot)
+
+	if slot < 0 || slot > 4 {
+		return fmt.Errorf("invalid patrol slot: %d (must be between 0 and 4)", slot)
+	}
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/cameras/%s/ptz/patrol/start/%d", cameraID, slot)
+	if _, err := c.doRequest("POST", path, nil); err != nil {
+		return fmt.Errorf("failed to start PTZ patrol: %w", err)
+	}
+
+	return nil
+}
+
+// StopPTZPatrol stops the active patrol on a PTZ camera
+func (c *Client) StopPTZPatrol(cameraID string) error {
+	log := logger.Get()
+	log.Infow("Stopping PTZ patrol", "cameraID", cameraID)
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/cameras/%s/ptz/patrol/stop", cameraID)
+	if _, err := c.doRequest("POST", path, nil); err != nil {
+		return fmt.Errorf("failed to stop PTZ patrol: %w", err)
+	}
+
+	return nil
+}
+
+// TalkbackSession describes an audio talkback session on a camera speaker
+type TalkbackSession struct {
+	URL           string `json:"url"`
+	Codec         string `json:"codec"`
+	SamplingRate  int    `json:"samplingRate"`
+	BitsPerSample int    `json:"bitsPerSample"`
+}
+
+// CreateTalkbackSession starts a talkback session on a camera and returns
+// where and in which format to send audio
+func (c *Client) CreateTalkbackSession(cameraID string) (*TalkbackSession, error) {
+	log := logger.Get()
+	log.Infow("Creating talkback session", "cameraID", cameraID)
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/cameras/%s/talkback-session", cameraID)
+	data, err := c.doRequest("POST", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create talkback session: %w", err)
+	}
+
+	var session TalkbackSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal talkback session: %w", err)
+	}
+
+	return &session, nil
+}
@@ -1,10 +1,15 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -277,3 +282,162 @@ func TestMovePTZToPreset(t *testing.T) {
 		})
 	}
 }
+
+func TestPTZPatrol(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST method, got '%s'", r.Method)
+		}
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	if err := client.StartPTZPatrol("cam1", 2); err != nil {
+		t.Fatalf("StartPTZPatrol() error = %v", err)
+	}
+	if err := client.StopPTZPatrol("cam1"); err != nil {
+		t.Fatalf("StopPTZPatrol() error = %v", err)
+	}
+	if err := client.StartPTZPatrol("cam1", 5); err == nil {
+		t.Error("Expected error for patrol slot 5")
+	}
+
+	want := []string{
+		"/proxy/protect/integration/v1/cameras/cam1/ptz/patrol/start/2",
+		"/proxy/protect/integration/v1/cameras/cam1/ptz/patrol/stop",
+	}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected requests %v, got %v", want, paths)
+	}
+}
+
+func TestCreateTalkbackSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/cameras/cam1/talkback-session" {
+			t.Errorf("Expected path '/proxy/protect/integration/v1/cameras/cam1/talkback-session', got '%s'", r.URL.Path)
+		}
+
+		if r.Method != "POST" {
+			t.Errorf("Expected POST method, got '%s'", r.Method)
+		}
+
+		w.Write([]byte(`{"url":"rtp://192.168.1.20:7004","codec":"pcmu","samplingRate":8000,"bitsPerSample":8}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	session, err := client.CreateTalkbackSession("cam1")
+	if err != nil {
+		t.Fatalf("CreateTalkbackSession() error = %v", err)
+	}
+
+	if session.URL != "rtp://192.168.1.20:7004" || session.Codec != "pcmu" || session.SamplingRate != 8000 || session.BitsPerSample != 8 {
+		t.Errorf("Unexpected session data: %+v", session)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Unexpected %s %s during dry run", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"vp1","name":"Tower"}]`))
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	client := NewClient(server.URL, "test-token")
+	client.DryRun = true
+	client.DryRunOutput = buf
+
+	// Lookups are still sent
+	viewports, err := client.ListViewports()
+	if err != nil || len(viewports) != 1 {
+		t.Fatalf("ListViewports() = %v, %v", viewports, err)
+	}
+
+	if err := client.SwitchViewport("vp1", "lv1"); err != nil {
+		t.Fatalf("SwitchViewport() error = %v", err)
+	}
+	if _, err := client.UploadAsset(AssetAnimations, "holiday.gif", testGIF); err != nil {
+		t.Fatalf("UploadAsset() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"DRY RUN: PATCH " + server.URL + "/proxy/protect/integration/v1/viewers/vp1\n{\"liveview\":\"lv1\"}\n",
+		"DRY RUN: POST " + server.URL + "/proxy/protect/integration/v1/files/animations\n<",
+		"bytes of multipart/form-data>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected dry run output to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestOnRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	var calls []string
+	client := NewClient(server.URL, "test-token")
+	client.OnRequest = func(method, path string, status int, elapsed time.Duration, err error) {
+		calls = append(calls, fmt.Sprintf("%s %s %d %v", method, path, status, err != nil))
+	}
+
+	client.ListViewports()
+	client.SwitchViewport("vp1", "lv1")
+
+	want := []string{
+		"GET /proxy/protect/integration/v1/viewers 200 false",
+		"PATCH /proxy/protect/integration/v1/viewers/vp1 404 true",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("OnRequest calls = %v, want %v", calls, want)
+	}
+}
+
+func TestStatusErrorAndMetaInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/proxy/protect/integration/v1/meta/info":
+			w.Write([]byte(`{"applicationVersion":"6.1.79"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/proxy/protect/integration/v1/cameras/cam1":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"forbidden"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	info, err := client.GetMetaInfo()
+	if err != nil {
+		t.Fatalf("GetMetaInfo() error = %v", err)
+	}
+	if info.ApplicationVersion != "6.1.79" {
+		t.Errorf("ApplicationVersion = %s", info.ApplicationVersion)
+	}
+
+	err = client.RenameCamera("cam1", "Front Door")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected a 403 StatusError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "request failed with status 403") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
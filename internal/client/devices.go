@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Device update actions reported by the devices WebSocket
+const (
+	DeviceAdd    = "add"
+	DeviceUpdate = "update"
+	DeviceRemove = "remove"
+	// DeviceResync is emitted after every (re)connect so consumers can
+	// refresh state that may have changed while disconnected
+	DeviceResync = "resync"
+)
+
+// DeviceChange is a single add, update or remove message for a device.
+// Fields holds the raw device properties; for updates it only contains the
+// properties that changed.
+type DeviceChange struct {
+	Action   string                     `json:"action"`
+	ModelKey string                     `json:"modelKey"`
+	ID       string                     `json:"id"`
+	Fields   map[string]json.RawMessage `json:"fields,omitempty"`
+}
+
+// DeviceSubscription delivers changes from the devices WebSocket.
+// Both channels are closed once the subscription ends.
+type DeviceSubscription struct {
+	Changes <-chan DeviceChange
+	Errors  <-chan error
+}
+
+// Light represents a UniFi Protect floodlight
+type Light struct {
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	ModelKey          string            `json:"modelKey"`
+	State             string            `json:"state"`
+	IsLightOn         bool              `json:"isLightOn"`
+	LightModeSettings LightModeSettings `json:"lightModeSettings"`
+}
+
+// LightModeSettings holds the floodlight activation mode
+type LightModeSettings struct {
+	Mode     string `json:"mode"`
+	EnableAt string `json:"enableAt,omitempty"`
+}
+
+// Floodlight modes and activation times
+const (
+	LightModeAlways   = "always"
+	LightModeMotion   = "motion"
+	LightModeOff      = "off"
+	LightEnableAlways = "fulltime"
+	LightEnableDark   = "dark"
+)
+
+// Sensor represents a UniFi Protect sensor
+type Sensor struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	ModelKey      string        `json:"modelKey"`
+	State         string        `json:"state"`
+	IsOpened      bool          `json:"isOpened"`
+	BatteryStatus BatteryStatus `json:"batteryStatus"`
+	Stats         SensorStats   `json:"stats"`
+}
+
+// BatteryStatus holds a sensor's battery level
+type BatteryStatus struct {
+	Percentage *int `json:"percentage"`
+	IsLow      bool `json:"isLow"`
+}
+
+// SensorStats holds a sensor's environmental readings
+type SensorStats struct {
+	Temperature SensorReading `json:"temperature"`
+	Humidity    SensorReading `json:"humidity"`
+	Light       SensorReading `json:"light"`
+}
+
+// SensorReading is a single sensor measurement
+type SensorReading struct {
+	Value  *float64 `json:"value"`
+	Status string   `json:"status,omitempty"`
+}
+
+// ListLights retrieves all floodlights
+func (c *Client) ListLights() ([]Light, error) {
+	log := logger.Get()
+	log.Debug("Fetching lights")
+
+	data, err := c.doRequest("GET", "/proxy/protect/integration/v1/lights", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lights: %w", err)
+	}
+
+	var lights []Light
+	if err := json.Unmarshal(data, &lights); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lights: %w", err)
+	}
+
+	return lights, nil
+}
+
+// SetLightMode sets a floodlight's mode and, if enableAt is not empty, when
+// the mode applies
+func (c *Client) SetLightMode(lightID, mode, enableAt string) error {
+	log := logger.Get()
+	log.Infow("Setting light mode", "lightID", lightID, "mode", mode, "enableAt", enableAt)
+
+	body := map[string]LightModeSettings{
+		"lightModeSettings": {Mode: mode, EnableAt: enableAt},
+	}
+
+	path := fmt.Sprintf("/proxy/protect/integration/v1/lights/%s", lightID)
+	if _, err := c.doRequest("PATCH", path, body); err != nil {
+		return fmt.Errorf("failed to set light mode: %w", err)
+	}
+
+	return nil
+}
+
+// ListSensors retrieves all sensors
+func (c *Client) ListSensors() ([]Sensor, error) {
+	log := logger.Get()
+	log.Debug("Fetching sensors")
+
+	data, err := c.doRequest("GET", "/proxy/protect/integration/v1/sensors", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sensors: %w", err)
+	}
+
+	var sensors []Sensor
+	if err := json.Unmarshal(data, &sensors); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sensors: %w", err)
+	}
+
+	return sensors, nil
+}
+
+// parseDeviceMessage decodes a single devices WebSocket message
+func parseDeviceMessage(data []byte) (DeviceChange, error) {
+	var msg subscriptionMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return DeviceChange{}, fmt.Errorf("failed to unmarshal device message: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Item, &fields); err != nil {
+		return DeviceChange{}, fmt.Errorf("failed to unmarshal device: %w", err)
+	}
+
+	change := DeviceChange{Action: msg.Type, Fields: fields}
+	if raw, ok := fields["id"]; ok {
+		json.Unmarshal(raw, &change.ID)
+	}
+	if raw, ok := fields["modelKey"]; ok {
+		json.Unmarshal(raw, &change.ModelKey)
+	}
+
+	if change.ID == "" {
+		return DeviceChange{}, fmt.Errorf("device message without id")
+	}
+
+	return change, nil
+}
+
+// SubscribeDevices streams device changes from the integration API devices
+// WebSocket. The connection is re-established automatically until ctx is
+// cancelled, and a DeviceResync change is sent after every connect.
+func (c *Client) SubscribeDevices(ctx context.Context) *DeviceSubscription {
+	changes := make(chan DeviceChange, 64)
+	errs := make(chan error, 8)
+
+	send := func(change DeviceChange) {
+		select {
+		case changes <- change:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(changes)
+		defer close(errs)
+
+		c.subscribe(ctx, "/proxy/protect/integration/v1/subscribe/devices", func(data []byte) error {
+			change, err := parseDeviceMessage(data)
+			if err != nil {
+				return err
+			}
+
+			send(change)
+			return nil
+		}, errs, func() {
+			send(DeviceChange{Action: DeviceResync})
+		})
+	}()
+
+	return &DeviceSubscription{Changes: changes, Errors: errs}
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseDeviceMessage(t *testing.T) {
+	data := []byte(`{"type":"update","item":{"id":"vp1","modelKey":"viewer","liveview":"lv2"}}`)
+
+	change, err := parseDeviceMessage(data)
+	if err != nil {
+		t.Fatalf("parseDeviceMessage() error = %v", err)
+	}
+
+	if change.Action != DeviceUpdate || change.ID != "vp1" || change.ModelKey != "viewer" {
+		t.Errorf("Unexpected change data: %+v", change)
+	}
+
+	var liveview string
+	json.Unmarshal(change.Fields["liveview"], &liveview)
+	if liveview != "lv2" {
+		t.Errorf("Expected liveview 'lv2', got '%s'", liveview)
+	}
+
+	if _, err := parseDeviceMessage([]byte(`{"type":"update","item":{"liveview":"lv2"}}`)); err == nil {
+		t.Error("Expected error for message without id")
+	}
+}
+
+func TestListLights(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/lights" {
+			t.Errorf("Expected path '/proxy/protect/integration/v1/lights', got '%s'", r.URL.Path)
+		}
+
+		w.Write([]byte(`[{"id":"light1","name":"Garage","modelKey":"light","state":"CONNECTED","isLightOn":true,"lightModeSettings":{"mode":"motion","enableAt":"dark"}}]`))
+	}))
+	defer server.Close()
+
+	lights, err := NewClient(server.URL, "test-token").ListLights()
+	if err != nil {
+		t.Fatalf("ListLights() error = %v", err)
+	}
+
+	if len(lights) != 1 || !lights[0].IsLightOn || lights[0].LightModeSettings.Mode != "motion" {
+		t.Errorf("Unexpected light data: %+v", lights)
+	}
+}
+
+func TestSetLightMode(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+
+	if err := c.SetLightMode("light1", LightModeMotion, LightEnableDark); err != nil {
+		t.Fatalf("SetLightMode() error = %v", err)
+	}
+	if method != http.MethodPatch || path != "/proxy/protect/integration/v1/lights/light1" || body != `{"lightModeSettings":{"mode":"motion","enableAt":"dark"}}` {
+		t.Errorf("Unexpected request: %s %s %s", method, path, body)
+	}
+
+	if err := c.SetLightMode("light1", LightModeOff, ""); err != nil {
+		t.Fatalf("SetLightMode() error = %v", err)
+	}
+	if body != `{"lightModeSettings":{"mode":"off"}}` {
+		t.Errorf("Expected enableAt to be omitted, got %s", body)
+	}
+}
+
+func TestListSensors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/sensors" {
+			t.Errorf("Expected path '/proxy/protect/integration/v1/sensors', got '%s'", r.URL.Path)
+		}
+
+		w.Write([]byte(`[{"id":"sensor1","name":"Back Door","modelKey":"sensor","state":"CONNECTED","isOpened":true,"batteryStatus":{"percentage":80,"isLow":false},"stats":{"temperature":{"value":21.5},"humidity":{"value":40}}}]`))
+	}))
+	defer server.Close()
+
+	sensors, err := NewClient(server.URL, "test-token").ListSensors()
+	if err != nil {
+		t.Fatalf("ListSensors() error = %v", err)
+	}
+
+	if len(sensors) != 1 {
+		t.Fatalf("Expected 1 sensor, got %d", len(sensors))
+	}
+
+	s := sensors[0]
+	if !s.IsOpened || s.BatteryStatus.Percentage == nil || *s.BatteryStatus.Percentage != 80 {
+		t.Errorf("Unexpected sensor data: %+v", s)
+	}
+
+	if s.Stats.Temperature.Value == nil || *s.Stats.Temperature.Value != 21.5 {
+		t.Errorf("Unexpected temperature: %+v", s.Stats.Temperature)
+	}
+}
+
+func TestSubscribeDevices(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/subscribe/devices" {
+			t.Errorf("Unexpected path '%s'", r.URL.Path)
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"update","item":{"id":"vp1","modelKey":"viewer","liveview":"lv2"}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"remove","item":{"id":"light1","modelKey":"light"}}`))
+
+		// Keep the connection open until the client goes away
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := NewClient(server.URL, "test-token").SubscribeDevices(ctx)
+
+	want := []struct {
+		action string
+		id     string
+	}{
+		{action: DeviceResync},
+		{action: DeviceUpdate, id: "vp1"},
+		{action: DeviceRemove, id: "light1"},
+	}
+
+	for _, w := range want {
+		select {
+		case change := <-sub.Changes:
+			if change.Action != w.action || change.ID != w.id {
+				t.Errorf("Expected %s %s, got %+v", w.action, w.id, change)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s change", w.action)
+		}
+	}
+
+	cancel()
+	for range sub.Changes {
+	}
+}
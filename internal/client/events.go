@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventType identifies the kind of a UniFi Protect event
+type EventType string
+
+// Event types reported by the integration API
+const (
+	EventMotion           EventType = "motion"
+	EventRing             EventType = "ring"
+	EventSmartDetectZone  EventType = "smartDetectZone"
+	EventSmartDetectLine  EventType = "smartDetectLine"
+	EventSmartAudioDetect EventType = "smartAudioDetect"
+	EventSensorMotion     EventType = "sensorMotion"
+	EventSensorOpened     EventType = "sensorOpened"
+	EventSensorClosed     EventType = "sensorClosed"
+	EventSensorAlarm      EventType = "sensorAlarm"
+	EventSensorWaterLeak  EventType = "sensorWaterLeak"
+	EventSensorTamper     EventType = "sensorTamper"
+	EventSensorBatteryLow EventType = "sensorBatteryLow"
+	EventSensorExtreme    EventType = "sensorExtremeValues"
+)
+
+// EventTypes lists every known event type
+var EventTypes = []EventType{
+	EventMotion, EventRing, EventSmartDetectZone, EventSmartDetectLine, EventSmartAudioDetect,
+	EventSensorMotion, EventSensorOpened, EventSensorClosed, EventSensorAlarm,
+	EventSensorWaterLeak, EventSensorTamper, EventSensorBatteryLow, EventSensorExtreme,
+}
+
+// IsSmartDetect returns true for smart detection events
+func (t EventType) IsSmartDetect() bool {
+	return t == EventSmartDetectZone || t == EventSmartDetectLine || t == EventSmartAudioDetect
+}
+
+// IsSensor returns true for sensor state change events
+func (t EventType) IsSensor() bool {
+	return strings.HasPrefix(string(t), "sensor")
+}
+
+// Event represents a UniFi Protect event
+type Event struct {
+	Action           string    `json:"action"`
+	ID               string    `json:"id"`
+	Type             EventType `json:"type"`
+	Start            int64     `json:"start,omitempty"`
+	End              int64     `json:"end,omitempty"`
+	Device           string    `json:"device"`
+	SmartDetectTypes []string  `json:"smartDetectTypes,omitempty"`
+}
+
+// StartTime returns the event start as a time.Time
+func (e Event) StartTime() time.Time {
+	if e.Start == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(e.Start)
+}
+
+// EventSubscription delivers events from the events WebSocket.
+// Both channels are closed once the subscription ends.
+type EventSubscription struct {
+	Events <-chan Event
+	Errors <-chan error
+}
+
+// subscriptionMessage is the envelope used by the subscription WebSockets
+type subscriptionMessage struct {
+	Type string          `json:"type"`
+	Item json.RawMessage `json:"item"`
+}
+
+// parseEventMessage decodes a single events WebSocket message
+func parseEventMessage(data []byte) (Event, error) {
+	var msg subscriptionMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Event{}, fmt.Errorf("failed to unmarshal event message: %w", err)
+	}
+
+	var event Event
+	if err := json.Unmarshal(msg.Item, &event); err != nil {
+		return Event{}, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	event.Action = msg.Type
+
+	return event, nil
+}
+
+// SubscribeEvents streams events from the integration API events WebSocket.
+// The connection is re-established automatically until ctx is cancelled.
+func (c *Client) SubscribeEvents(ctx context.Context) *EventSubscription {
+	events := make(chan Event, 64)
+	errs := make(chan error, 8)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		c.subscribe(ctx, "/proxy/protect/integration/v1/subscribe/events", func(data []byte) error {
+			event, err := parseEventMessage(data)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+			return nil
+		}, errs, nil)
+	}()
+
+	return &EventSubscription{Events: events, Errors: errs}
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseEventMessage(t *testing.T) {
+	data := []byte(`{"type":"add","item":{"id":"ev1","modelKey":"event","type":"smartDetectZone","start":1700000000000,"device":"cam1","smartDetectTypes":["person","vehicle"]}}`)
+
+	event, err := parseEventMessage(data)
+	if err != nil {
+		t.Fatalf("parseEventMessage() error = %v", err)
+	}
+
+	if event.Action != "add" || event.ID != "ev1" || event.Device != "cam1" {
+		t.Errorf("Unexpected event data: %+v", event)
+	}
+
+	if !event.Type.IsSmartDetect() {
+		t.Errorf("Expected %s to be a smart detection event", event.Type)
+	}
+
+	if len(event.SmartDetectTypes) != 2 || event.SmartDetectTypes[0] != "person" {
+		t.Errorf("Unexpected smart detect types: %v", event.SmartDetectTypes)
+	}
+
+	if event.StartTime().UnixMilli() != 1700000000000 {
+		t.Errorf("Unexpected start time: %v", event.StartTime())
+	}
+
+	if _, err := parseEventMessage([]byte("not json")); err == nil {
+		t.Error("Expected error for malformed message")
+	}
+}
+
+func TestEventTypeIsSensor(t *testing.T) {
+	if !EventSensorOpened.IsSensor() {
+		t.Error("Expected sensorOpened to be a sensor event")
+	}
+
+	if EventRing.IsSensor() {
+		t.Error("Expected ring not to be a sensor event")
+	}
+}
+
+func TestWebsocketURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+		wantErr bool
+	}{
+		{baseURL: "https://protect.example.com", want: "wss://protect.example.com/path"},
+		{baseURL: "http://192.168.1.1/", want: "ws://192.168.1.1/path"},
+		{baseURL: "ftp://protect.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			c := NewClient(tt.baseURL, "test-token")
+			got, err := c.websocketURL("/path")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("websocketURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSubscribeEventsReconnects(t *testing.T) {
+	reconnectMinBackoff = 10 * time.Millisecond
+	defer func() { reconnectMinBackoff = time.Second }()
+
+	var connections int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/protect/integration/v1/subscribe/events" {
+			t.Errorf("Unexpected path '%s'", r.URL.Path)
+		}
+
+		if r.Header.Get("X-API-Key") != "test-token" {
+			t.Errorf("Expected X-API-Key header to be 'test-token', got '%s'", r.Header.Get("X-API-Key"))
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Send one event per connection, then drop the connection
+		n := atomic.AddInt32(&connections, 1)
+		msg := `{"type":"add","item":{"id":"ev` + string(rune('0'+n)) + `","type":"ring","device":"cam1"}}`
+		conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := NewClient(server.URL, "test-token").SubscribeEvents(ctx)
+
+	for _, want := range []string{"ev1", "ev2"} {
+		select {
+		case event := <-sub.Events:
+			if event.ID != want || event.Type != EventRing {
+				t.Errorf("Expected ring event %s, got %+v", want, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for event %s", want)
+		}
+	}
+
+	cancel()
+	for range sub.Events {
+	}
+}
+
+func TestSubscribeBacksOffOnDroppedConnections(t *testing.T) {
+	reconnectMinBackoff = 20 * time.Millisecond
+	defer func() { reconnectMinBackoff = time.Second }()
+
+	// Accept each connection and drop it straight away, without a message
+	connects := make(chan time.Time, 8)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		select {
+		case connects <- time.Now():
+		default:
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := NewClient(server.URL, "test-token").SubscribeEvents(ctx)
+
+	var times []time.Time
+	for len(times) < 4 {
+		select {
+		case at := <-connects:
+			times = append(times, at)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out after %d connects", len(times))
+		}
+	}
+
+	// The waits double (20, 40, 80ms) instead of resetting to 20ms
+	if gap := times[3].Sub(times[2]); gap < 60*time.Millisecond {
+		t.Errorf("Expected the backoff to grow, third reconnect came after %v", gap)
+	}
+
+	cancel()
+	for range sub.Events {
+	}
+}
+
+func TestSubscribeEventsUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	sub := NewClient(server.URL, "bad-token").SubscribeEvents(context.Background())
+
+	select {
+	case err := <-sub.Errors:
+		if err == nil {
+			t.Fatal("Expected an error for unauthorized subscription")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for error")
+	}
+
+	select {
+	case _, ok := <-sub.Events:
+		if ok {
+			t.Error("Expected events channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for events channel to close")
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Reconnect backoff bounds for WebSocket subscriptions. The backoff is only
+// reset once a connection has delivered a message or stayed up for
+// reconnectStableAfter, so a server that accepts and drops connections is
+// not retried every second.
+var (
+	reconnectMinBackoff  = 1 * time.Second
+	reconnectMaxBackoff  = 30 * time.Second
+	reconnectStableAfter = 30 * time.Second
+)
+
+// errSubscriptionFatal marks errors that should not trigger a reconnect
+var errSubscriptionFatal = errors.New("subscription rejected")
+
+// websocketURL converts the client base URL to a WebSocket URL for the given path
+func (c *Client) websocketURL(path string) (string, error) {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	case "wss", "ws":
+	default:
+		return "", fmt.Errorf("unsupported URL scheme: %s", u.Scheme)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	return u.String(), nil
+}
+
+// dialWebsocket opens an authenticated WebSocket connection
+func (c *Client) dialWebsocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	log := logger.Get()
+
+	wsURL, err := c.websocketURL(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errSubscriptionFatal, err)
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 30 * time.Second,
+	}
+	if c.HTTPClient != nil {
+		if transport, ok := c.HTTPClient.Transport.(*http.Transport); ok {
+			dialer.TLSClientConfig = transport.TLSClientConfig
+		}
+	}
+
+	header := http.Header{}
+	header.Set("X-API-Key", c.APIToken)
+
+	log.Debugw("Opening WebSocket", "url", wsURL)
+	conn, resp, err := dialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return nil, fmt.Errorf("%w: status %d", errSubscriptionFatal, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("websocket dial failed: %w", err)
+	}
+
+	return conn, nil
+}
+
+// subscribe keeps a WebSocket open on path, reconnecting with exponential
+// backoff, and hands every text message to handle. onConnect, if set, runs
+// after each successful connect. Connection errors are reported through
+// errs; the loop ends when ctx is cancelled or the server rejects the
+// subscription.
+func (c *Client) subscribe(ctx context.Context, path string, handle func([]byte) error, errs chan<- error, onConnect func()) {
+	log := logger.Get()
+	backoff := reconnectMinBackoff
+
+	for {
+		conn, err := c.dialWebsocket(ctx, path)
+		if err == nil {
+			connected := time.Now()
+			if onConnect != nil {
+				onConnect()
+			}
+
+			var received bool
+			received, err = readMessages(ctx, conn, handle)
+			if received || time.Since(connected) >= reconnectStableAfter {
+				backoff = reconnectMinBackoff
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Warnw("Subscription interrupted", "path", path, "error", err)
+			reportError(errs, err)
+			if errors.Is(err, errSubscriptionFatal) {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > reconnectMaxBackoff {
+			backoff = reconnectMaxBackoff
+		}
+	}
+}
+
+// readMessages reads from conn until it fails or ctx is cancelled, and
+// reports whether any message arrived
+func readMessages(ctx context.Context, conn *websocket.Conn, handle func([]byte) error) (bool, error) {
+	log := logger.Get()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+			conn.Close()
+		}
+	}()
+
+	received := false
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return received, fmt.Errorf("websocket read failed: %w", err)
+		}
+		received = true
+
+		if err := handle(data); err != nil {
+			log.Warnw("Skipping malformed message", "error", err)
+		}
+	}
+}
+
+// reportError delivers err without blocking the subscription loop
+func reportError(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
+}
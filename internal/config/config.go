@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -14,39 +16,172 @@ type Config struct {
 	ProtectURL string `mapstructure:"protect_url"`
 	APIToken   string `mapstructure:"api_token"`
 	LogLevel   string `mapstructure:"log_level"`
+	// Agent forwards commands to a running agent when one is found
+	Agent     bool       `mapstructure:"agent"`
+	Scenes    []Scene    `mapstructure:"scenes"`
+	Schedules []Schedule `mapstructure:"schedules"`
+	Rules     []Rule     `mapstructure:"rules"`
+	Serve     Serve      `mapstructure:"serve"`
+	MQTT      MQTT       `mapstructure:"mqtt"`
+}
+
+// Scene is a named set of viewport switches and PTZ moves applied together
+type Scene struct {
+	Name     string        `mapstructure:"name"`
+	Switches []SceneSwitch `mapstructure:"switches"`
+	PTZ      []ScenePTZ    `mapstructure:"ptz"`
+}
+
+// SceneSwitch assigns a liveview to a viewport, each by name or ID
+type SceneSwitch struct {
+	Viewport string `mapstructure:"viewport"`
+	Liveview string `mapstructure:"liveview"`
+}
+
+// ScenePTZ moves a camera (by name or ID) to a preset number or "home"
+type ScenePTZ struct {
+	Camera string `mapstructure:"camera"`
+	Preset string `mapstructure:"preset"`
+}
+
+// Schedule applies switches, PTZ moves and a scene at set times. The time is
+// either a cron expression or a time of day with optional days of the week.
+type Schedule struct {
+	Name     string        `mapstructure:"name"`
+	Cron     string        `mapstructure:"cron"`
+	At       string        `mapstructure:"at"`
+	Days     []string      `mapstructure:"days"`
+	Timezone string        `mapstructure:"timezone"`
+	Scene    string        `mapstructure:"scene"`
+	Switches []SceneSwitch `mapstructure:"switches"`
+	PTZ      []ScenePTZ    `mapstructure:"ptz"`
+}
+
+// Rule runs actions when a matching event arrives
+type Rule struct {
+	Name     string        `mapstructure:"name"`
+	When     RuleCondition `mapstructure:"when"`
+	Then     []RuleAction  `mapstructure:"then"`
+	Cooldown time.Duration `mapstructure:"cooldown"`
+	Revert   time.Duration `mapstructure:"revert"`
+}
+
+// RuleCondition selects events; empty fields match everything
+type RuleCondition struct {
+	Cameras  []string `mapstructure:"cameras"`
+	Types    []string `mapstructure:"types"`
+	Smart    []string `mapstructure:"smart"`
+	Between  string   `mapstructure:"between"`
+	Days     []string `mapstructure:"days"`
+	Timezone string   `mapstructure:"timezone"`
+}
+
+// RuleAction is one step of a rule; exactly one field is set
+type RuleAction struct {
+	Switch  *SceneSwitch `mapstructure:"switch"`
+	PTZ     *ScenePTZ    `mapstructure:"ptz"`
+	Scene   string       `mapstructure:"scene"`
+	Exec    string       `mapstructure:"exec"`
+	Webhook *Webhook     `mapstructure:"webhook"`
+}
+
+// Webhook is an HTTP request sent by a rule
+type Webhook struct {
+	URL     string            `mapstructure:"url"`
+	Method  string            `mapstructure:"method"`
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+// Serve configures the REST control server
+type Serve struct {
+	Listen  string   `mapstructure:"listen"`
+	Tokens  []string `mapstructure:"tokens"`
+	TLSCert string   `mapstructure:"tls_cert"`
+	TLSKey  string   `mapstructure:"tls_key"`
+}
+
+// MQTT configures the MQTT bridge
+type MQTT struct {
+	Broker          string   `mapstructure:"broker"`
+	Username        string   `mapstructure:"username"`
+	Password        string   `mapstructure:"password"`
+	ClientID        string   `mapstructure:"client_id"`
+	Topic           string   `mapstructure:"topic"`
+	DiscoveryPrefix string   `mapstructure:"discovery_prefix"`
+	Cameras         []string `mapstructure:"cameras"`
+	Presets         []string `mapstructure:"presets"`
 }
 
 var cfg *Config
 
-// Load loads the configuration from the XDG config directory
-func Load() (*Config, error) {
-	if cfg != nil {
-		return cfg, nil
-	}
+// envKeys are the top-level settings that PROTECT_ environment variables
+// can override
+var envKeys = []string{"protect_url", "api_token", "log_level", "agent"}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
+// SearchPaths returns the directories searched for config.yaml, in order
+func SearchPaths() []string {
+	var dirs []string
 
 	// Try XDG config directory first (Linux/BSD standard)
 	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
-		viper.AddConfigPath(filepath.Join(xdgConfig, "protect"))
+		dirs = append(dirs, filepath.Join(xdgConfig, "protect"))
 	}
 
 	// Try ~/.config (common convention)
 	if home, err := os.UserHomeDir(); err == nil {
-		viper.AddConfigPath(filepath.Join(home, ".config", "protect"))
+		dirs = append(dirs, filepath.Join(home, ".config", "protect"))
 	}
 
 	// Try OS-specific config directory (macOS: ~/Library/Application Support)
 	if configDir, err := os.UserConfigDir(); err == nil {
-		appConfigDir := filepath.Join(configDir, "protect")
-		viper.AddConfigPath(appConfigDir)
-		// Create config directory if it doesn't exist
-		os.MkdirAll(appConfigDir, 0755)
+		dir := filepath.Join(configDir, "protect")
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// FileUsed returns the config file found by Load, or "" if there was none
+func FileUsed() string {
+	return viper.ConfigFileUsed()
+}
+
+// EnvOverrides returns the PROTECT_ environment variables that are set and
+// override a setting
+func EnvOverrides() []string {
+	var names []string
+	for _, key := range envKeys {
+		name := "PROTECT_" + strings.ToUpper(key)
+		if _, ok := os.LookupEnv(name); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Load loads the configuration from the XDG config directory
+func Load() (*Config, error) {
+	if cfg != nil {
+		return cfg, nil
+	}
+
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+
+	for _, dir := range SearchPaths() {
+		viper.AddConfigPath(dir)
+	}
+
+	// Create the OS-specific config directory if it doesn't exist
+	if configDir, err := os.UserConfigDir(); err == nil {
+		os.MkdirAll(filepath.Join(configDir, "protect"), 0755)
 	}
 
 	// Set defaults
 	viper.SetDefault("log_level", "none")
+	viper.SetDefault("agent", true)
 
 	// Allow environment variables to override config
 	// This must be set before reading the config file
@@ -81,7 +216,9 @@ func Get() *Config {
 	return cfg
 }
 
-// Validate checks if the configuration is valid
+// Validate checks the connection settings every command needs. Scenes,
+// schedules, rules and serve settings are checked by the commands that use
+// them, so a mistake in one section does not break the others.
 func (c *Config) Validate() error {
 	if c.ProtectURL == "" {
 		return fmt.Errorf("protect_url is required")
@@ -91,3 +228,126 @@ func (c *Config) Validate() error {
 	}
 	return nil
 }
+
+// ValidateScenes checks that every scene has a unique name
+func (c *Config) ValidateScenes() error {
+	seen := make(map[string]bool)
+	for i, scene := range c.Scenes {
+		if scene.Name == "" {
+			return fmt.Errorf("scene %d has no name", i+1)
+		}
+		if seen[scene.Name] {
+			return fmt.Errorf("duplicate scene name: %s", scene.Name)
+		}
+		seen[scene.Name] = true
+	}
+	return nil
+}
+
+// ValidateSchedules checks the schedules and the scenes they refer to
+func (c *Config) ValidateSchedules() error {
+	if err := c.ValidateScenes(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i, s := range c.Schedules {
+		if s.Name == "" {
+			return fmt.Errorf("schedule %d has no name", i+1)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate schedule name: %s", s.Name)
+		}
+		seen[s.Name] = true
+
+		if (s.Cron == "") == (s.At == "") {
+			return fmt.Errorf("schedule %s needs either cron or at", s.Name)
+		}
+		if len(s.Days) > 0 && s.At == "" {
+			return fmt.Errorf("schedule %s: days can only be used with at", s.Name)
+		}
+		if s.Scene == "" && len(s.Switches) == 0 && len(s.PTZ) == 0 {
+			return fmt.Errorf("schedule %s has no scene, switches or PTZ moves", s.Name)
+		}
+		if s.Scene != "" {
+			if _, err := c.Scene(s.Scene); err != nil {
+				return fmt.Errorf("schedule %s: %w", s.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRules checks the automation rules and the scenes they refer to
+func (c *Config) ValidateRules() error {
+	if err := c.ValidateScenes(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i, r := range c.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate rule name: %s", r.Name)
+		}
+		seen[r.Name] = true
+
+		if len(r.Then) == 0 {
+			return fmt.Errorf("rule %s has no actions", r.Name)
+		}
+		for j, a := range r.Then {
+			if err := a.validate(c); err != nil {
+				return fmt.Errorf("rule %s, action %d: %w", r.Name, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateServe checks the serve settings
+func (c *Config) ValidateServe() error {
+	if (c.Serve.TLSCert == "") != (c.Serve.TLSKey == "") {
+		return fmt.Errorf("serve: tls_cert and tls_key must be set together")
+	}
+	return nil
+}
+
+// validate checks that exactly one action is set and that it is complete
+func (a RuleAction) validate(c *Config) error {
+	set := 0
+	for _, ok := range []bool{a.Switch != nil, a.PTZ != nil, a.Scene != "", a.Exec != "", a.Webhook != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("expected exactly one of switch, ptz, scene, exec or webhook")
+	}
+
+	switch {
+	case a.Switch != nil && (a.Switch.Viewport == "" || a.Switch.Liveview == ""):
+		return fmt.Errorf("switch needs a viewport and a liveview")
+	case a.PTZ != nil && (a.PTZ.Camera == "" || a.PTZ.Preset == ""):
+		return fmt.Errorf("ptz needs a camera and a preset")
+	case a.Webhook != nil && a.Webhook.URL == "":
+		return fmt.Errorf("webhook needs a url")
+	case a.Scene != "":
+		if _, err := c.Scene(a.Scene); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Scene returns the scene with the given name
+func (c *Config) Scene(name string) (*Scene, error) {
+	for i := range c.Scenes {
+		if c.Scenes[i].Name == name {
+			return &c.Scenes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("scene not found: %s", name)
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/spf13/viper"
 )
 
 func TestLoad(t *testing.T) {
@@ -23,11 +28,36 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestSearchPaths(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	dirs := SearchPaths()
+	if len(dirs) == 0 || dirs[0] != filepath.Join(xdg, "protect") {
+		t.Errorf("Expected XDG_CONFIG_HOME to be searched first, got %v", dirs)
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv("PROTECT_API_TOKEN", "secret")
+	t.Setenv("PROTECT_UNRELATED", "x")
+	for _, name := range []string{"PROTECT_PROTECT_URL", "PROTECT_LOG_LEVEL"} {
+		t.Setenv(name, "") // restored after the test
+		os.Unsetenv(name)
+	}
+
+	got := EnvOverrides()
+	if len(got) != 1 || got[0] != "PROTECT_API_TOKEN" {
+		t.Errorf("EnvOverrides() = %v", got)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
-		name    string
-		config  Config
-		wantErr bool
+		name     string
+		config   Config
+		validate func(*Config) error
+		wantErr  bool
 	}{
 		{
 			name: "valid config",
@@ -56,14 +86,251 @@ func TestValidate(t *testing.T) {
 			config:  Config{},
 			wantErr: true,
 		},
+		{
+			name: "scene without name",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Scenes:     []Scene{{Switches: []SceneSwitch{{Viewport: "Tower", Liveview: "Driveway"}}}},
+			},
+			validate: (*Config).ValidateScenes,
+			wantErr:  true,
+		},
+		{
+			name: "duplicate scene names",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Scenes:     []Scene{{Name: "night"}, {Name: "night"}},
+			},
+			validate: (*Config).ValidateScenes,
+			wantErr:  true,
+		},
+		{
+			name: "valid schedules",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Scenes:     []Scene{{Name: "night"}},
+				Schedules: []Schedule{
+					{Name: "evening", At: "22:00", Days: []string{"mon"}, Scene: "night"},
+					{Name: "morning", Cron: "0 7 * * *", Switches: []SceneSwitch{{Viewport: "Tower", Liveview: "Driveway"}}},
+				},
+			},
+			validate: (*Config).ValidateSchedules,
+			wantErr:  false,
+		},
+		{
+			name: "schedule with cron and at",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Schedules:  []Schedule{{Name: "both", Cron: "0 7 * * *", At: "07:00", PTZ: []ScenePTZ{{Camera: "Front Door", Preset: "1"}}}},
+			},
+			validate: (*Config).ValidateSchedules,
+			wantErr:  true,
+		},
+		{
+			name: "schedule without actions",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Schedules:  []Schedule{{Name: "empty", At: "07:00"}},
+			},
+			validate: (*Config).ValidateSchedules,
+			wantErr:  true,
+		},
+		{
+			name: "rule with two actions in one step",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Rules:      []Rule{{Name: "doorbell", Then: []RuleAction{{Exec: "true", Scene: "night"}}}},
+			},
+			validate: (*Config).ValidateRules,
+			wantErr:  true,
+		},
+		{
+			name: "rule without actions",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Rules:      []Rule{{Name: "doorbell"}},
+			},
+			validate: (*Config).ValidateRules,
+			wantErr:  true,
+		},
+		{
+			name: "valid rule",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Rules:      []Rule{{Name: "doorbell", Then: []RuleAction{{Switch: &SceneSwitch{Viewport: "Reception", Liveview: "Entrance"}}, {Exec: "notify.sh"}}}},
+			},
+			validate: (*Config).ValidateRules,
+			wantErr:  false,
+		},
+		{
+			name: "schedule with unknown scene",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Schedules:  []Schedule{{Name: "evening", At: "22:00", Scene: "missing"}},
+			},
+			validate: (*Config).ValidateSchedules,
+			wantErr:  true,
+		},
+		{
+			name: "serve with TLS cert but no key",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Serve:      Serve{TLSCert: "server.crt"},
+			},
+			validate: (*Config).ValidateServe,
+			wantErr:  true,
+		},
+		{
+			name: "connection check ignores other sections",
+			config: Config{
+				ProtectURL: "https://protect.example.com",
+				APIToken:   "test-token",
+				Schedules:  []Schedule{{Name: "empty", At: "07:00"}},
+				Rules:      []Rule{{Name: "doorbell"}},
+				Serve:      Serve{TLSCert: "server.crt"},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.config.Validate()
+			validate := tt.validate
+			if validate == nil {
+				validate = (*Config).Validate
+			}
+			err := validate(&tt.config)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestLoadScenes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "protect"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `protect_url: https://protect.example.com
+api_token: test-token
+scenes:
+  - name: Shift Change
+    switches:
+      - viewport: Tower
+        liveview: All Cameras
+    ptz:
+      - camera: Front Door
+        preset: 5
+      - camera: Driveway
+        preset: home
+`
+	if err := os.WriteFile(filepath.Join(dir, "protect", "config.yaml"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	cfg = nil
+	defer func() {
+		viper.Reset()
+		cfg = nil
+	}()
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	scene, err := config.Scene("Shift Change")
+	if err != nil {
+		t.Fatalf("Scene() error = %v", err)
+	}
+
+	if len(scene.Switches) != 1 || scene.Switches[0].Viewport != "Tower" || scene.Switches[0].Liveview != "All Cameras" {
+		t.Errorf("Unexpected switches: %+v", scene.Switches)
+	}
+
+	if len(scene.PTZ) != 2 || scene.PTZ[0].Preset != "5" || scene.PTZ[1].Preset != "home" {
+		t.Errorf("Unexpected PTZ moves: %+v", scene.PTZ)
+	}
+
+	if _, err := config.Scene("Missing"); err == nil {
+		t.Error("Expected error for unknown scene")
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "protect"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `protect_url: https://protect.example.com
+api_token: test-token
+rules:
+  - name: Doorbell
+    when:
+      cameras: [Front Door]
+      types: [ring]
+      between: "08:00-18:00"
+    then:
+      - switch:
+          viewport: Reception
+          liveview: Entrance
+      - webhook:
+          url: https://hooks.example.com/ring
+    cooldown: 30s
+    revert: 90s
+`
+	if err := os.WriteFile(filepath.Join(dir, "protect", "config.yaml"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	cfg = nil
+	defer func() {
+		viper.Reset()
+		cfg = nil
+	}()
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if err := config.ValidateRules(); err != nil {
+		t.Fatalf("ValidateRules() error = %v", err)
+	}
+
+	if len(config.Rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %+v", config.Rules)
+	}
+
+	rule := config.Rules[0]
+	if rule.Cooldown != 30*time.Second || rule.Revert != 90*time.Second {
+		t.Errorf("Unexpected timers: cooldown %v, revert %v", rule.Cooldown, rule.Revert)
+	}
+
+	if len(rule.When.Cameras) != 1 || rule.When.Cameras[0] != "Front Door" || rule.When.Between != "08:00-18:00" {
+		t.Errorf("Unexpected condition: %+v", rule.When)
+	}
+
+	if len(rule.Then) != 2 || rule.Then[0].Switch == nil || rule.Then[0].Switch.Liveview != "Entrance" || rule.Then[1].Webhook == nil {
+		t.Errorf("Unexpected actions: %+v", rule.Then)
+	}
+}
@@ -0,0 +1,263 @@
+package desired
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"gopkg.in/yaml.v3"
+)
+
+// State is the desired liveview of viewers, patrol of PTZ cameras and mode
+// of floodlights. Anything not listed is left alone.
+type State struct {
+	Viewports []Viewport `yaml:"viewports"`
+	Patrols   []Patrol   `yaml:"patrols"`
+	Lights    []Light    `yaml:"lights"`
+}
+
+// Viewport assigns a liveview to a viewport, each by name or ID
+type Viewport struct {
+	Viewport string `yaml:"viewport"`
+	Liveview string `yaml:"liveview"`
+}
+
+// Patrol sets a PTZ camera's patrol: a slot from 0 to 4, or "off"
+type Patrol struct {
+	Camera string `yaml:"camera"`
+	Patrol string `yaml:"patrol"`
+}
+
+// Light sets a floodlight's mode and, optionally, when it applies
+type Light struct {
+	Light    string `yaml:"light"`
+	Mode     string `yaml:"mode"`
+	EnableAt string `yaml:"enable_at"`
+}
+
+// Change is one difference between the desired and current state
+type Change struct {
+	Kind actions.Kind `json:"kind" yaml:"kind"`
+	Name string       `json:"name" yaml:"name"`
+	ID   string       `json:"id" yaml:"id"`
+	From string       `json:"from" yaml:"from"`
+	To   string       `json:"to" yaml:"to"`
+
+	// Target is the resolved action that makes the change
+	Target actions.Target `json:"-" yaml:"-"`
+}
+
+// Plan is the set of changes needed to reach the desired state
+type Plan struct {
+	Changes []Change
+	// Unchanged is the number of entries that already match
+	Unchanged int
+}
+
+// Targets returns the actions that carry out the plan
+func (p *Plan) Targets() []actions.Target {
+	targets := make([]actions.Target, 0, len(p.Changes))
+	for _, change := range p.Changes {
+		targets = append(targets, change.Target)
+	}
+	return targets
+}
+
+// Load reads a desired state file. JSON is accepted too, since it is valid
+// YAML.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read desired state: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes and validates a desired state document. Unknown keys are
+// rejected so that typos are not silently ignored.
+func Parse(data []byte) (*State, error) {
+	var s State
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse desired state: %w", err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Validate checks that every entry is complete and that nothing is listed
+// twice
+func (s *State) Validate() error {
+	if len(s.Viewports) == 0 && len(s.Patrols) == 0 && len(s.Lights) == 0 {
+		return fmt.Errorf("desired state has no viewports, patrols or lights")
+	}
+
+	seen := make(map[string]bool)
+	for i, v := range s.Viewports {
+		if v.Viewport == "" || v.Liveview == "" {
+			return fmt.Errorf("viewport %d needs viewport and liveview", i+1)
+		}
+		if seen[v.Viewport] {
+			return fmt.Errorf("duplicate viewport: %s", v.Viewport)
+		}
+		seen[v.Viewport] = true
+	}
+
+	seen = make(map[string]bool)
+	for i, p := range s.Patrols {
+		if p.Camera == "" || p.Patrol == "" {
+			return fmt.Errorf("patrol %d needs camera and patrol", i+1)
+		}
+		if seen[p.Camera] {
+			return fmt.Errorf("duplicate patrol camera: %s", p.Camera)
+		}
+		seen[p.Camera] = true
+		if _, err := ParsePatrol(p.Patrol); err != nil {
+			return fmt.Errorf("patrol %s: %w", p.Camera, err)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i, l := range s.Lights {
+		if l.Light == "" || l.Mode == "" {
+			return fmt.Errorf("light %d needs light and mode", i+1)
+		}
+		if seen[l.Light] {
+			return fmt.Errorf("duplicate light: %s", l.Light)
+		}
+		seen[l.Light] = true
+		if err := actions.ValidateLightMode(l.Mode, l.EnableAt); err != nil {
+			return fmt.Errorf("light %s: %w", l.Light, err)
+		}
+	}
+
+	return nil
+}
+
+// ParsePatrol parses a patrol slot, accepting "off" for -1
+func ParsePatrol(s string) (int, error) {
+	if strings.EqualFold(s, "off") {
+		return -1, nil
+	}
+
+	slot, err := strconv.Atoi(s)
+	if err != nil || slot < 0 || slot > 4 {
+		return 0, fmt.Errorf("invalid patrol: %s (must be a slot between 0 and 4, or 'off')", s)
+	}
+
+	return slot, nil
+}
+
+// Compute compares the desired state with the console and returns the
+// changes needed, in file order. All unknown names are reported together.
+func Compute(c *client.Client, s *State) (*Plan, error) {
+	resolver := actions.NewResolver(c)
+	plan := &Plan{}
+
+	var acts []actions.Action
+	var errs []error
+	add := func(change Change, a actions.Action) {
+		plan.Changes = append(plan.Changes, change)
+		acts = append(acts, a)
+	}
+
+	for _, want := range s.Viewports {
+		vp, err := resolver.Viewport(want.Viewport)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lv, err := resolver.Liveview(want.Liveview)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("viewport %s: %w", vp.Name, err))
+			continue
+		}
+
+		if vp.Liveview == lv.ID {
+			plan.Unchanged++
+			continue
+		}
+
+		from := "(none)"
+		if vp.Liveview != "" {
+			from = vp.Liveview
+			if current, err := resolver.Liveview(vp.Liveview); err == nil {
+				from = current.Name
+			}
+		}
+		add(Change{Kind: actions.KindSwitch, Name: vp.Name, ID: vp.ID, From: from, To: lv.Name}, actions.Switch(vp.ID, lv.ID))
+	}
+
+	for _, want := range s.Patrols {
+		cam, err := resolver.Camera(want.Camera)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		slot, _ := ParsePatrol(want.Patrol)
+		current := -1
+		if cam.ActivePatrolSlot != nil {
+			current = *cam.ActivePatrolSlot
+		}
+
+		if slot == current {
+			plan.Unchanged++
+			continue
+		}
+
+		add(Change{Kind: actions.KindPatrol, Name: cam.Name, ID: cam.ID, From: actions.PatrolLabel(current), To: actions.PatrolLabel(slot)}, actions.Patrol(cam.ID, slot))
+	}
+
+	for _, want := range s.Lights {
+		light, err := resolver.Light(want.Light)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		current := light.LightModeSettings
+		if current.Mode == want.Mode && (want.EnableAt == "" || current.EnableAt == want.EnableAt) {
+			plan.Unchanged++
+			continue
+		}
+
+		to := want.EnableAt
+		if to == "" {
+			to = current.EnableAt
+		}
+		add(Change{
+			Kind: actions.KindLight,
+			Name: light.Name,
+			ID:   light.ID,
+			From: actions.LightLabel(current.Mode, current.EnableAt),
+			To:   actions.LightLabel(want.Mode, to),
+		}, actions.LightMode(light.ID, want.Mode, want.EnableAt))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	targets, err := resolver.Resolve(acts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range targets {
+		plan.Changes[i].Target = targets[i]
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,112 @@
+package desired
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+func TestParse(t *testing.T) {
+	valid := `
+viewports:
+  - viewport: Lobby
+    liveview: Entrance
+patrols:
+  - camera: Driveway
+    patrol: "off"
+lights:
+  - light: Garage
+    mode: motion
+    enable_at: dark
+`
+	s, err := Parse([]byte(valid))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(s.Viewports) != 1 || s.Patrols[0].Patrol != "off" || s.Lights[0].EnableAt != "dark" {
+		t.Errorf("Unexpected state: %+v", s)
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "Empty", doc: ``, wantErr: "has no viewports"},
+		{name: "Unknown key", doc: "viewport:\n  - viewport: Lobby\n", wantErr: "field viewport not found"},
+		{name: "Missing liveview", doc: "viewports:\n  - viewport: Lobby\n", wantErr: "needs viewport and liveview"},
+		{name: "Duplicate viewport", doc: "viewports:\n  - {viewport: Lobby, liveview: A}\n  - {viewport: Lobby, liveview: B}\n", wantErr: "duplicate viewport"},
+		{name: "Invalid patrol", doc: "patrols:\n  - {camera: Driveway, patrol: 7}\n", wantErr: "invalid patrol"},
+		{name: "Invalid light mode", doc: "lights:\n  - {light: Garage, mode: sometimes}\n", wantErr: "invalid light mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newTestServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Lobby","liveview":"lv1"},{"id":"vp2","name":"Tower","liveview":"lv2"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"Parking"},{"id":"lv2","name":"Entrance"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Driveway","activePatrolSlot":null},{"id":"cam2","name":"Yard","modelKey":"camera","activePatrolSlot":1}]`)
+	server.Set("lights", `[{"id":"light1","name":"Garage","lightModeSettings":{"mode":"motion","enableAt":"dark"}}]`)
+	return server
+}
+
+func TestCompute(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	s := &State{
+		Viewports: []Viewport{{Viewport: "Lobby", Liveview: "Entrance"}, {Viewport: "Tower", Liveview: "Entrance"}},
+		Patrols:   []Patrol{{Camera: "Driveway", Patrol: "2"}, {Camera: "Yard", Patrol: "1"}},
+		Lights:    []Light{{Light: "Garage", Mode: "always"}},
+	}
+
+	plan, err := Compute(c, s)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	var got []string
+	for _, change := range plan.Changes {
+		got = append(got, string(change.Kind)+" "+change.Name+": "+change.From+" -> "+change.To)
+	}
+	want := []string{
+		"switch Lobby: Parking -> Entrance",
+		"patrol Driveway: no patrol -> patrol 2",
+		"light Garage: motion (when dark) -> always (when dark)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if plan.Unchanged != 2 {
+		t.Errorf("Unchanged = %d, want 2", plan.Unchanged)
+	}
+
+	targets := plan.Targets()
+	if len(targets) != 3 || targets[0].ViewportID != "vp1" || targets[0].LiveviewID != "lv2" || targets[2].Kind != actions.KindLight || targets[2].LightID != "light1" {
+		t.Errorf("Unexpected targets: %+v", targets)
+	}
+}
+
+func TestComputeUnknownNames(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	_, err := Compute(c, &State{
+		Viewports: []Viewport{{Viewport: "Nowhere", Liveview: "Entrance"}},
+		Lights:    []Light{{Light: "Porch", Mode: "off"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "viewport not found: Nowhere") || !strings.Contains(err.Error(), "light not found: Porch") {
+		t.Errorf("Expected every unknown name to be reported, got %v", err)
+	}
+}
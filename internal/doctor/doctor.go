@@ -0,0 +1,555 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+)
+
+// Status is the outcome of a check
+type Status string
+
+// Check outcomes
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// DialTimeout bounds each DNS lookup, TCP connect and TLS handshake
+const DialTimeout = 5 * time.Second
+
+// certExpiryWarning is how close to expiry a certificate is reported
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// apiKeyFix tells the user where to get a new API key
+const apiKeyFix = "create a new API key under Settings → Control Plane → Integrations in UniFi Protect and set api_token (or PROTECT_API_TOKEN, or --token)"
+
+// Check is the result of one diagnostic step
+type Check struct {
+	Name   string `json:"name" yaml:"name"`
+	Status Status `json:"status" yaml:"status"`
+	Detail string `json:"detail" yaml:"detail"`
+	Fix    string `json:"fix,omitempty" yaml:"fix,omitempty"`
+}
+
+// Options describes where the configuration came from and how to reach the
+// console
+type Options struct {
+	// ConfigFile is the config file that was read, "" if none was found
+	ConfigFile string
+	// ConfigError is the error from loading the config file, if any
+	ConfigError error
+	// SearchPaths are the directories searched for config.yaml
+	SearchPaths []string
+	// EnvOverrides and FlagOverrides name the settings overridden by
+	// environment variables and command-line flags
+	EnvOverrides  []string
+	FlagOverrides []string
+
+	URL   string
+	Token string
+
+	// Client talks to the console; its HTTPClient's TLS settings are also
+	// used for the TLS check
+	Client *client.Client
+
+	// CheckWrites runs the checks that patch a viewer and a camera. They
+	// write to the console, so they only run when asked for.
+	CheckWrites bool
+	// DryRun skips the patch checks even when CheckWrites is set
+	DryRun bool
+}
+
+// checkNames are the checks in the order Run performs them
+var checkNames = []string{
+	"Config file", "Overrides", "Settings", "URL", "DNS", "TCP", "TLS",
+	"API key", "API version", "Read viewers", "Patch viewers", "Read cameras", "Patch cameras",
+}
+
+// Run performs every check in order. Once a check fails, the checks that
+// depend on it are skipped.
+func Run(ctx context.Context, opts Options) []Check {
+	var checks []Check
+	add := func(c Check) bool {
+		checks = append(checks, c)
+		return c.Status != Fail
+	}
+	skipRest := func(reason string) []Check {
+		for _, name := range checkNames[len(checks):] {
+			checks = append(checks, Check{Name: name, Status: Skip, Detail: "skipped: " + reason})
+		}
+		return checks
+	}
+
+	add(checkConfigFile(opts))
+	add(checkOverrides(opts))
+	if !add(checkSettings(opts)) {
+		return skipRest("settings are incomplete")
+	}
+
+	u, check := checkURL(opts.URL)
+	if !add(check) {
+		return skipRest("protect_url is invalid")
+	}
+
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	if !add(checkDNS(ctx, host)) {
+		return skipRest("the host name did not resolve")
+	}
+	if !add(checkTCP(ctx, host, port)) {
+		return skipRest("the console is unreachable")
+	}
+	if !add(checkTLS(ctx, host, port, tlsConfig(opts.Client))) {
+		return skipRest("the TLS handshake failed")
+	}
+
+	info, check := checkAPIKey(opts.Client)
+	if !add(check) {
+		return skipRest("the API key was rejected")
+	}
+	add(checkVersion(info))
+
+	skipWrites := ""
+	switch {
+	case opts.DryRun:
+		skipWrites = "skipped with --dry-run"
+	case !opts.CheckWrites:
+		skipWrites = "skipped: writes to the console (use --check-writes)"
+	}
+
+	viewers, check := checkReadViewers(opts.Client)
+	if add(check) {
+		add(checkPatchViewers(opts.Client, viewers, skipWrites))
+	} else {
+		add(Check{Name: "Patch viewers", Status: Skip, Detail: "skipped: viewers could not be read"})
+	}
+
+	cameras, check := checkReadCameras(opts.Client)
+	if add(check) {
+		add(checkPatchCameras(opts.Client, cameras, skipWrites))
+	} else {
+		add(Check{Name: "Patch cameras", Status: Skip, Detail: "skipped: cameras could not be read"})
+	}
+
+	return checks
+}
+
+// Failed returns how many checks failed
+func Failed(checks []Check) int {
+	n := 0
+	for _, c := range checks {
+		if c.Status == Fail {
+			n++
+		}
+	}
+	return n
+}
+
+func checkConfigFile(opts Options) Check {
+	c := Check{Name: "Config file"}
+
+	switch {
+	case opts.ConfigError != nil:
+		c.Status = Fail
+		c.Detail = opts.ConfigError.Error()
+		c.Fix = "fix the YAML in " + orDefault(opts.ConfigFile, "config.yaml") + " (check indentation and quoting)"
+	case opts.ConfigFile != "":
+		c.Status = Pass
+		c.Detail = opts.ConfigFile
+	default:
+		c.Status = Warn
+		c.Detail = "no config.yaml found in " + strings.Join(opts.SearchPaths, ", ")
+		c.Fix = "create ~/.config/protect/config.yaml with protect_url and api_token, unless you use environment variables or flags"
+	}
+
+	return c
+}
+
+func checkOverrides(opts Options) Check {
+	var sources []string
+	for _, name := range opts.EnvOverrides {
+		sources = append(sources, "environment "+name)
+	}
+	for _, name := range opts.FlagOverrides {
+		sources = append(sources, "flag --"+name)
+	}
+
+	detail := "none"
+	if len(sources) > 0 {
+		detail = strings.Join(sources, ", ")
+	}
+	return Check{Name: "Overrides", Status: Pass, Detail: detail}
+}
+
+func checkSettings(opts Options) Check {
+	c := Check{Name: "Settings"}
+
+	var missing []string
+	if opts.URL == "" {
+		missing = append(missing, "protect_url")
+	}
+	if opts.Token == "" {
+		missing = append(missing, "api_token")
+	}
+
+	switch {
+	case opts.ConfigError != nil:
+		c.Status = Fail
+		c.Detail = "the config file could not be read"
+		c.Fix = "fix the config file first"
+	case len(missing) > 0:
+		c.Status = Fail
+		c.Detail = strings.Join(missing, " and ") + " not set"
+		c.Fix = "set " + strings.Join(missing, " and ") + " in config.yaml, or use PROTECT_PROTECT_URL / PROTECT_API_TOKEN or --url / --token"
+	default:
+		c.Status = Pass
+		c.Detail = "protect_url and api_token are set"
+	}
+
+	return c
+}
+
+// checkURL parses protect_url, which must be just the console's HTTPS
+// address
+func checkURL(raw string) (*url.URL, Check) {
+	c := Check{Name: "URL"}
+
+	u, err := url.Parse(raw)
+	switch {
+	case !strings.Contains(raw, "://"):
+		c.Status = Fail
+		c.Detail = fmt.Sprintf("%q has no scheme", raw)
+		c.Fix = "set protect_url to https://" + strings.TrimPrefix(raw, "//")
+		return nil, c
+	case err != nil:
+		c.Status = Fail
+		c.Detail = err.Error()
+		c.Fix = "set protect_url to the console address, e.g. https://192.168.1.1"
+		return nil, c
+	case u.Host == "":
+		c.Status = Fail
+		c.Detail = fmt.Sprintf("%q has no host", raw)
+		c.Fix = "set protect_url to the console address, e.g. https://192.168.1.1"
+		return nil, c
+	case u.Scheme == "http":
+		c.Status = Fail
+		c.Detail = fmt.Sprintf("%q uses http; the console only serves the API over HTTPS", raw)
+		c.Fix = "set protect_url to https://" + u.Host
+		return nil, c
+	case u.Scheme != "https":
+		c.Status = Fail
+		c.Detail = fmt.Sprintf("unsupported scheme %q", u.Scheme)
+		c.Fix = "set protect_url to https://" + u.Host
+		return nil, c
+	case strings.Trim(u.Path, "/") != "" || u.RawQuery != "":
+		c.Status = Warn
+		c.Detail = fmt.Sprintf("%q has a path; API paths are appended to it", raw)
+		c.Fix = "set protect_url to https://" + u.Host
+		return u, c
+	}
+
+	c.Status = Pass
+	c.Detail = raw
+	return u, c
+}
+
+func checkDNS(ctx context.Context, host string) Check {
+	c := Check{Name: "DNS"}
+
+	if net.ParseIP(host) != nil {
+		c.Status = Pass
+		c.Detail = host + " is an IP address"
+		return c
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DialTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		c.Status = Fail
+		c.Detail = err.Error()
+		c.Fix = "check the host name in protect_url, or use the console's IP address"
+		return c
+	}
+
+	c.Status = Pass
+	c.Detail = host + " resolves to " + strings.Join(addrs, ", ")
+	return c
+}
+
+func checkTCP(ctx context.Context, host, port string) Check {
+	c := Check{Name: "TCP"}
+	addr := net.JoinHostPort(host, port)
+
+	dialer := net.Dialer{Timeout: DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		c.Status = Fail
+		c.Detail = err.Error()
+		c.Fix = fmt.Sprintf("check that the console is on and that port %s is reachable from this machine (firewall, VPN or VLAN rules)", port)
+		return c
+	}
+	conn.Close()
+
+	c.Status = Pass
+	c.Detail = "connected to " + addr
+	return c
+}
+
+// tlsConfig returns the TLS settings the client uses for requests
+func tlsConfig(c *client.Client) *tls.Config {
+	if c != nil && c.HTTPClient != nil {
+		if transport, ok := c.HTTPClient.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+			return transport.TLSClientConfig.Clone()
+		}
+	}
+	return &tls.Config{}
+}
+
+// checkTLS performs the handshake the client would, and describes the
+// certificate whether or not it verifies
+func checkTLS(ctx context.Context, host, port string, cfg *tls.Config) Check {
+	c := Check{Name: "TLS"}
+	addr := net.JoinHostPort(host, port)
+
+	ctx, cancel := context.WithTimeout(ctx, DialTimeout)
+	defer cancel()
+
+	cfg.ServerName = host
+	state, err := handshake(ctx, addr, cfg)
+	if err == nil {
+		cert := state.PeerCertificates[0]
+		c.Status = Pass
+		c.Detail = fmt.Sprintf("%s, %s", tls.VersionName(state.Version), describeCert(cert))
+		if remaining := time.Until(cert.NotAfter); remaining < certExpiryWarning {
+			c.Status = Warn
+			c.Fix = "renew the console's certificate before it expires"
+		}
+		return c
+	}
+
+	c.Status = Fail
+	c.Detail = err.Error()
+
+	// Handshake again without verification to describe the certificate
+	insecure := cfg.Clone()
+	insecure.InsecureSkipVerify = true
+	if state, insecureErr := handshake(ctx, addr, insecure); insecureErr == nil && len(state.PeerCertificates) > 0 {
+		c.Detail += "; certificate: " + describeCert(state.PeerCertificates[0])
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		c.Fix = "the console uses a self-signed certificate: add it to this machine's trust store, or install a certificate from a trusted CA on the console"
+	case errors.As(err, &hostname):
+		c.Fix = "set protect_url to a name the certificate covers, or install a certificate for " + host + " on the console"
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		c.Fix = "renew the console's certificate, and check this machine's clock"
+	default:
+		c.Fix = "check that " + addr + " is the console's HTTPS port"
+	}
+	return c
+}
+
+func handshake(ctx context.Context, addr string, cfg *tls.Config) (tls.ConnectionState, error) {
+	dialer := tls.Dialer{NetDialer: &net.Dialer{Timeout: DialTimeout}, Config: cfg}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
+
+// describeCert summarises a certificate's subject, issuer and validity
+func describeCert(cert *x509.Certificate) string {
+	names := cert.DNSNames
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	desc := fmt.Sprintf("subject %q, issuer %q", cert.Subject.CommonName, cert.Issuer.CommonName)
+	if len(names) > 0 {
+		desc += ", names " + strings.Join(names, " ")
+	}
+
+	days := int(time.Until(cert.NotAfter).Hours() / 24)
+	if days < 0 {
+		return desc + fmt.Sprintf(", expired %s", cert.NotAfter.Format("2006-01-02"))
+	}
+	return desc + fmt.Sprintf(", expires %s (%d days)", cert.NotAfter.Format("2006-01-02"), days)
+}
+
+func checkAPIKey(c *client.Client) (*client.MetaInfo, Check) {
+	check := Check{Name: "API key"}
+
+	info, err := c.GetMetaInfo()
+	if err != nil {
+		check.Status = Fail
+		check.Detail = err.Error()
+
+		var statusErr *client.StatusError
+		switch {
+		case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+			check.Detail = fmt.Sprintf("the console rejected the API key (status %d)", statusErr.StatusCode)
+			check.Fix = "the key is wrong, revoked or expired: " + apiKeyFix
+		case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+			check.Detail = "the integration API was not found (status 404)"
+			check.Fix = "check that protect_url points at the UniFi console and that UniFi Protect is up to date"
+		default:
+			check.Fix = "check that protect_url points at the UniFi console"
+		}
+		return nil, check
+	}
+
+	check.Status = Pass
+	check.Detail = "accepted"
+	return info, check
+}
+
+func checkVersion(info *client.MetaInfo) Check {
+	if info.ApplicationVersion == "" {
+		return Check{Name: "API version", Status: Warn, Detail: "the console did not report a version", Fix: "update UniFi Protect"}
+	}
+	return Check{Name: "API version", Status: Pass, Detail: "UniFi Protect " + info.ApplicationVersion}
+}
+
+// permissionFix explains an API error from a read or patch check
+func permissionFix(err error, what string) string {
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden) {
+		return "the API key cannot " + what + ": " + apiKeyFix + " from an account with full Protect access"
+	}
+	return "check the console's logs; the request failed"
+}
+
+func checkReadViewers(c *client.Client) ([]client.Viewer, Check) {
+	viewers, err := c.ListViewports()
+	if err != nil {
+		return nil, Check{Name: "Read viewers", Status: Fail, Detail: err.Error(), Fix: permissionFix(err, "read viewers")}
+	}
+	return viewers, Check{Name: "Read viewers", Status: Pass, Detail: fmt.Sprintf("%d viewers", len(viewers))}
+}
+
+// checkPatchViewers sets a viewer to the liveview it already shows. The
+// viewer is read again just before, so a switch made since viewers were
+// read is not reverted; skip, if set, is why the check was skipped.
+func checkPatchViewers(c *client.Client, viewers []client.Viewer, skip string) Check {
+	check := Check{Name: "Patch viewers", Status: Skip}
+
+	var target *client.Viewer
+	for i := range viewers {
+		if viewers[i].Liveview != "" {
+			target = &viewers[i]
+			break
+		}
+	}
+
+	switch {
+	case skip != "":
+		check.Detail = skip
+		return check
+	case target == nil:
+		check.Detail = "skipped: no viewer shows a liveview"
+		return check
+	}
+
+	current, err := c.ListViewports()
+	if err != nil {
+		check.Status = Fail
+		check.Detail = err.Error()
+		check.Fix = permissionFix(err, "read viewers")
+		return check
+	}
+	if !slices.ContainsFunc(current, func(v client.Viewer) bool { return v.ID == target.ID && v.Liveview == target.Liveview }) {
+		check.Detail = fmt.Sprintf("skipped: %s was switched since it was read", target.Name)
+		return check
+	}
+
+	if err := c.SwitchViewport(target.ID, target.Liveview); err != nil {
+		check.Status = Fail
+		check.Detail = err.Error()
+		check.Fix = permissionFix(err, "change viewers")
+		return check
+	}
+
+	check.Status = Pass
+	check.Detail = fmt.Sprintf("re-applied %s's current liveview", target.Name)
+	return check
+}
+
+func checkReadCameras(c *client.Client) ([]client.PTZCamera, Check) {
+	cameras, err := c.ListPTZCameras()
+	if err != nil {
+		return nil, Check{Name: "Read cameras", Status: Fail, Detail: err.Error(), Fix: permissionFix(err, "read cameras")}
+	}
+	return cameras, Check{Name: "Read cameras", Status: Pass, Detail: fmt.Sprintf("%d cameras", len(cameras))}
+}
+
+// checkPatchCameras sets a camera's name to the name it already has, after
+// reading it again like checkPatchViewers; skip, if set, is why the check
+// was skipped
+func checkPatchCameras(c *client.Client, cameras []client.PTZCamera, skip string) Check {
+	check := Check{Name: "Patch cameras", Status: Skip}
+
+	switch {
+	case skip != "":
+		check.Detail = skip
+		return check
+	case len(cameras) == 0:
+		check.Detail = "skipped: no cameras"
+		return check
+	}
+
+	cam := cameras[0]
+	current, err := c.ListPTZCameras()
+	if err != nil {
+		check.Status = Fail
+		check.Detail = err.Error()
+		check.Fix = permissionFix(err, "read cameras")
+		return check
+	}
+	if !slices.ContainsFunc(current, func(cc client.PTZCamera) bool { return cc.ID == cam.ID && cc.Name == cam.Name }) {
+		check.Detail = fmt.Sprintf("skipped: %s was renamed since it was read", cam.Name)
+		return check
+	}
+
+	if err := c.RenameCamera(cam.ID, cam.Name); err != nil {
+		check.Status = Fail
+		check.Detail = err.Error()
+		check.Fix = permissionFix(err, "change cameras")
+		return check
+	}
+
+	check.Status = Pass
+	check.Detail = fmt.Sprintf("re-applied %s's current name", cam.Name)
+	return check
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newConsole serves the endpoints the doctor uses over TLS. The API key
+// "good" is accepted; anything else gets a 401.
+func newConsole(t *testing.T) *protecttest.Server {
+	t.Helper()
+
+	server := protecttest.NewTLS(t)
+	server.RequireToken("good")
+	server.Set("meta/info", `{"applicationVersion":"6.1.79"}`)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"}]`)
+	server.Fail("cameras/cam1", http.StatusForbidden)
+	return server
+}
+
+// statuses summarises checks as "name=status" pairs
+func statuses(checks []Check) string {
+	var parts []string
+	for _, c := range checks {
+		parts = append(parts, c.Name+"="+string(c.Status))
+	}
+	return strings.Join(parts, " ")
+}
+
+func find(checks []Check, name string) Check {
+	for _, c := range checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return Check{}
+}
+
+func TestRun(t *testing.T) {
+	server := newConsole(t)
+
+	newOptions := func(url, token string, trusted bool) Options {
+		c := client.NewClient(url, token)
+		if trusted {
+			c.HTTPClient = server.Client()
+		}
+		return Options{ConfigFile: "/etc/protect/config.yaml", URL: url, Token: token, Client: c, FlagOverrides: []string{"token"}}
+	}
+
+	t.Run("Healthy console with a read-only camera", func(t *testing.T) {
+		opts := newOptions(server.URL, "good", true)
+		opts.CheckWrites = true
+		checks := Run(context.Background(), opts)
+
+		want := "Config file=pass Overrides=pass Settings=pass URL=pass DNS=pass TCP=pass TLS=pass " +
+			"API key=pass API version=pass Read viewers=pass Patch viewers=pass Read cameras=pass Patch cameras=fail"
+		if got := statuses(checks); got != want {
+			t.Errorf("Checks = %s\nwant %s", got, want)
+		}
+		if got := find(checks, "API version").Detail; got != "UniFi Protect 6.1.79" {
+			t.Errorf("API version detail = %q", got)
+		}
+		if got := find(checks, "Overrides").Detail; got != "flag --token" {
+			t.Errorf("Overrides detail = %q", got)
+		}
+		if fix := find(checks, "Patch cameras").Fix; !strings.Contains(fix, "cannot change cameras") {
+			t.Errorf("Unexpected fix: %q", fix)
+		}
+		if Failed(checks) != 1 {
+			t.Errorf("Failed() = %d, want 1", Failed(checks))
+		}
+	})
+
+	t.Run("Writes are opt-in", func(t *testing.T) {
+		checks := Run(context.Background(), newOptions(server.URL, "good", true))
+
+		patch := find(checks, "Patch viewers")
+		if patch.Status != Skip || !strings.Contains(patch.Detail, "--check-writes") || find(checks, "Patch cameras").Status != Skip || Failed(checks) != 0 {
+			t.Errorf("Unexpected checks: %s (%s)", statuses(checks), patch.Detail)
+		}
+	})
+
+	t.Run("Dry run skips writes", func(t *testing.T) {
+		opts := newOptions(server.URL, "good", true)
+		opts.CheckWrites = true
+		opts.DryRun = true
+		checks := Run(context.Background(), opts)
+
+		if find(checks, "Patch viewers").Status != Skip || find(checks, "Patch cameras").Status != Skip || Failed(checks) != 0 {
+			t.Errorf("Unexpected checks: %s", statuses(checks))
+		}
+	})
+
+	t.Run("Rejected API key", func(t *testing.T) {
+		checks := Run(context.Background(), newOptions(server.URL, "expired", true))
+
+		key := find(checks, "API key")
+		if key.Status != Fail || !strings.Contains(key.Detail, "401") || !strings.Contains(key.Fix, "Integrations") {
+			t.Errorf("Unexpected API key check: %+v", key)
+		}
+		if find(checks, "Read viewers").Status != Skip {
+			t.Errorf("Expected later checks to be skipped: %s", statuses(checks))
+		}
+	})
+
+	t.Run("Untrusted certificate", func(t *testing.T) {
+		checks := Run(context.Background(), newOptions(server.URL, "good", false))
+
+		tlsCheck := find(checks, "TLS")
+		if tlsCheck.Status != Fail || !strings.Contains(tlsCheck.Fix, "self-signed") || !strings.Contains(tlsCheck.Detail, "certificate: subject") {
+			t.Errorf("Unexpected TLS check: %+v", tlsCheck)
+		}
+		if find(checks, "API key").Status != Skip {
+			t.Errorf("Expected API checks to be skipped: %s", statuses(checks))
+		}
+	})
+
+	t.Run("Missing settings", func(t *testing.T) {
+		checks := Run(context.Background(), Options{SearchPaths: []string{"/a", "/b"}, Token: "good"})
+
+		if got := find(checks, "Config file"); got.Status != Warn || !strings.Contains(got.Detail, "/a, /b") {
+			t.Errorf("Unexpected config file check: %+v", got)
+		}
+		if got := find(checks, "Settings"); got.Status != Fail || got.Detail != "protect_url not set" {
+			t.Errorf("Unexpected settings check: %+v", got)
+		}
+		if len(checks) != len(checkNames) || checks[len(checks)-1].Status != Skip {
+			t.Errorf("Expected every remaining check to be skipped: %s", statuses(checks))
+		}
+	})
+
+	t.Run("Unreadable config file", func(t *testing.T) {
+		checks := Run(context.Background(), Options{ConfigFile: "/etc/protect/config.yaml", ConfigError: errors.New("yaml: line 3: did not find expected key")})
+
+		if got := find(checks, "Config file"); got.Status != Fail || !strings.Contains(got.Fix, "/etc/protect/config.yaml") {
+			t.Errorf("Unexpected config file check: %+v", got)
+		}
+	})
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		status  Status
+		wantFix string
+	}{
+		{url: "https://192.168.1.1", status: Pass},
+		{url: "https://protect.example.com:7443/", status: Pass},
+		{url: "192.168.1.1", status: Fail, wantFix: "https://192.168.1.1"},
+		{url: "192.168.1.1:443", status: Fail, wantFix: "https://192.168.1.1:443"},
+		{url: "http://192.168.1.1", status: Fail, wantFix: "https://192.168.1.1"},
+		{url: "ftp://192.168.1.1", status: Fail, wantFix: "https://192.168.1.1"},
+		{url: "https://192.168.1.1/protect", status: Warn, wantFix: "https://192.168.1.1"},
+	}
+
+	for _, tt := range tests {
+		_, c := checkURL(tt.url)
+		if c.Status != tt.status {
+			t.Errorf("checkURL(%q) status = %s, want %s (%s)", tt.url, c.Status, tt.status, c.Detail)
+		}
+		if tt.wantFix != "" && !strings.HasSuffix(c.Fix, tt.wantFix) {
+			t.Errorf("checkURL(%q) fix = %q, want it to end with %q", tt.url, c.Fix, tt.wantFix)
+		}
+	}
+}
+
+func TestCheckPatchViewersChanged(t *testing.T) {
+	// The viewer is switched between the read check and the patch
+	reads := 0
+	patched := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			reads++
+			liveview := "lv1"
+			if reads > 1 {
+				liveview = "lv2"
+			}
+			w.Write([]byte(`[{"id":"vp1","name":"Tower","liveview":"` + liveview + `"}]`))
+		default:
+			patched = true
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.URL, "good")
+	viewers, check := checkReadViewers(c)
+	if check.Status != Pass {
+		t.Fatalf("Unexpected read check: %+v", check)
+	}
+
+	check = checkPatchViewers(c, viewers, "")
+	if check.Status != Skip || !strings.Contains(check.Detail, "switched since it was read") {
+		t.Errorf("Unexpected patch check: %+v", check)
+	}
+	if patched {
+		t.Error("Expected the viewer not to be patched")
+	}
+}
@@ -0,0 +1,343 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/inventory"
+)
+
+// connectedState is the device state reported while a device is online
+const connectedState = "CONNECTED"
+
+// apiPrefix is stripped from request paths to name the endpoint
+const apiPrefix = "/proxy/protect/integration/v1/"
+
+// Retry bounds for the first inventory load, so the exporter keeps serving
+// protect_up 0 while the console is unreachable
+var (
+	loadRetryMin = 1 * time.Second
+	loadRetryMax = 30 * time.Second
+)
+
+// durationBuckets are the upper bounds of the request latency histogram
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey labels API request metrics
+type requestKey struct {
+	method   string
+	endpoint string
+}
+
+// histogram is a cumulative latency histogram
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Exporter serves device state and API request metrics in the Prometheus
+// text format
+type Exporter struct {
+	inv *inventory.Inventory
+
+	mu        sync.Mutex
+	up        bool
+	updated   time.Time
+	requests  map[requestKey]map[int]uint64
+	errors    map[requestKey]uint64
+	durations map[requestKey]*histogram
+}
+
+// New creates an exporter with an empty inventory
+func New() *Exporter {
+	return &Exporter{
+		inv:       inventory.New(),
+		requests:  make(map[requestKey]map[int]uint64),
+		errors:    make(map[requestKey]uint64),
+		durations: make(map[requestKey]*histogram),
+	}
+}
+
+// Run loads the inventory and keeps it current from the devices WebSocket
+// until ctx is cancelled. Requests made by c are counted. The exporter is
+// up after every successful load and down after every stream or load
+// error; a failed first load is retried with backoff.
+func (e *Exporter) Run(ctx context.Context, c *client.Client, onError func(error)) error {
+	c.OnRequest = e.ObserveRequest
+
+	unregister := e.inv.OnChange(func(change client.DeviceChange) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if change.Action == client.DeviceResync {
+			e.up = true
+		}
+		e.updated = time.Now()
+	})
+	defer unregister()
+
+	reportError := func(err error) {
+		e.setDown()
+		if onError != nil {
+			onError(err)
+		}
+	}
+
+	backoff := loadRetryMin
+	for {
+		err := e.inv.Run(ctx, c, reportError)
+		if e.inv.Loaded() || ctx.Err() != nil {
+			return err
+		}
+
+		// The first load failed before the stream was followed
+		reportError(fmt.Errorf("failed to load inventory: %w", err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, loadRetryMax)
+	}
+}
+
+// setDown marks the inventory as no longer current
+func (e *Exporter) setDown() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.up = false
+}
+
+// ObserveRequest records one API request
+func (e *Exporter) ObserveRequest(method, path string, status int, elapsed time.Duration, err error) {
+	key := requestKey{method: method, endpoint: endpoint(path)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.requests[key] == nil {
+		e.requests[key] = make(map[int]uint64)
+	}
+	e.requests[key][status]++
+
+	if err != nil {
+		e.errors[key]++
+	}
+
+	h := e.durations[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		e.durations[key] = h
+	}
+	seconds := elapsed.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// endpoint names the API resource a path belongs to, keeping device IDs
+// out of the labels
+func endpoint(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	rest, ok := strings.CutPrefix(path, apiPrefix)
+	if !ok {
+		return path
+	}
+	resource, _, _ := strings.Cut(rest, "/")
+	return resource
+}
+
+// Handler serves /metrics
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		e.Write(w)
+	})
+	return mux
+}
+
+// Write writes every metric in the Prometheus text format
+func (e *Exporter) Write(w io.Writer) {
+	m := &metricWriter{w: w}
+
+	e.mu.Lock()
+	up, updated := e.up, e.updated
+	e.mu.Unlock()
+
+	m.family("protect_up", "gauge", "Whether the device inventory is loaded and the devices stream is healthy.")
+	m.sample("protect_up", nil, boolValue(up))
+	if !updated.IsZero() {
+		m.family("protect_last_update_timestamp_seconds", "gauge", "When the device inventory last loaded or changed.")
+		m.sample("protect_last_update_timestamp_seconds", nil, float64(updated.Unix()))
+	}
+
+	snap := e.inv.Snapshot()
+	e.writeDevices(m, snap)
+	e.writeRequests(m)
+}
+
+// writeDevices writes the per-device gauges
+func (e *Exporter) writeDevices(m *metricWriter, snap inventory.Snapshot) {
+	m.family("protect_viewer_info", "gauge", "Viewer and the liveview it is showing.")
+	for _, vp := range snap.Viewers {
+		m.sample("protect_viewer_info", []string{"id", vp.ID, "name", vp.Name, "liveview_id", vp.Liveview, "liveview", snap.LiveviewName(vp.Liveview)}, 1)
+	}
+
+	m.family("protect_viewer_online", "gauge", "Whether the viewer is connected.")
+	for _, vp := range snap.Viewers {
+		m.sample("protect_viewer_online", []string{"id", vp.ID, "name", vp.Name}, boolValue(vp.State == connectedState))
+	}
+
+	m.family("protect_camera_connected", "gauge", "Whether the camera is connected.")
+	for _, cam := range snap.Cameras {
+		m.sample("protect_camera_connected", []string{"id", cam.ID, "name", cam.Name}, boolValue(cam.State == connectedState))
+	}
+
+	m.family("protect_light_connected", "gauge", "Whether the floodlight is connected.")
+	for _, l := range snap.Lights {
+		m.sample("protect_light_connected", []string{"id", l.ID, "name", l.Name}, boolValue(l.State == connectedState))
+	}
+
+	m.family("protect_light_on", "gauge", "Whether the floodlight is on.")
+	for _, l := range snap.Lights {
+		m.sample("protect_light_on", []string{"id", l.ID, "name", l.Name}, boolValue(l.IsLightOn))
+	}
+
+	m.family("protect_sensor_connected", "gauge", "Whether the sensor is connected.")
+	for _, s := range snap.Sensors {
+		m.sample("protect_sensor_connected", []string{"id", s.ID, "name", s.Name}, boolValue(s.State == connectedState))
+	}
+
+	m.family("protect_sensor_opened", "gauge", "Whether the sensor's door or window is open.")
+	for _, s := range snap.Sensors {
+		m.sample("protect_sensor_opened", []string{"id", s.ID, "name", s.Name}, boolValue(s.IsOpened))
+	}
+
+	m.family("protect_sensor_battery_low", "gauge", "Whether the sensor reports a low battery.")
+	for _, s := range snap.Sensors {
+		m.sample("protect_sensor_battery_low", []string{"id", s.ID, "name", s.Name}, boolValue(s.BatteryStatus.IsLow))
+	}
+
+	m.family("protect_sensor_battery_percent", "gauge", "Sensor battery level.")
+	for _, s := range snap.Sensors {
+		if s.BatteryStatus.Percentage != nil {
+			m.sample("protect_sensor_battery_percent", []string{"id", s.ID, "name", s.Name}, float64(*s.BatteryStatus.Percentage))
+		}
+	}
+
+	for _, reading := range []struct {
+		name, help string
+		value      func(client.Sensor) *float64
+	}{
+		{"protect_sensor_temperature_celsius", "Sensor temperature.", func(s client.Sensor) *float64 { return s.Stats.Temperature.Value }},
+		{"protect_sensor_humidity_percent", "Sensor relative humidity.", func(s client.Sensor) *float64 { return s.Stats.Humidity.Value }},
+		{"protect_sensor_light_lux", "Sensor ambient light.", func(s client.Sensor) *float64 { return s.Stats.Light.Value }},
+	} {
+		m.family(reading.name, "gauge", reading.help)
+		for _, s := range snap.Sensors {
+			if v := reading.value(s); v != nil {
+				m.sample(reading.name, []string{"id", s.ID, "name", s.Name}, *v)
+			}
+		}
+	}
+}
+
+// writeRequests writes the exporter's own API request metrics
+func (e *Exporter) writeRequests(m *metricWriter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]requestKey, 0, len(e.durations))
+	for k := range e.durations {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	m.family("protect_api_requests_total", "counter", "API requests by response status (0 if no response arrived).")
+	for _, k := range keys {
+		codes := make([]int, 0, len(e.requests[k]))
+		for code := range e.requests[k] {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			m.sample("protect_api_requests_total", []string{"method", k.method, "endpoint", k.endpoint, "code", strconv.Itoa(code)}, float64(e.requests[k][code]))
+		}
+	}
+
+	m.family("protect_api_request_errors_total", "counter", "API requests that failed.")
+	for _, k := range keys {
+		m.sample("protect_api_request_errors_total", []string{"method", k.method, "endpoint", k.endpoint}, float64(e.errors[k]))
+	}
+
+	m.family("protect_api_request_duration_seconds", "histogram", "API request latency.")
+	for _, k := range keys {
+		h := e.durations[k]
+		for i, bound := range durationBuckets {
+			m.sample("protect_api_request_duration_seconds_bucket", []string{"method", k.method, "endpoint", k.endpoint, "le", formatValue(bound)}, float64(h.counts[i]))
+		}
+		m.sample("protect_api_request_duration_seconds_bucket", []string{"method", k.method, "endpoint", k.endpoint, "le", "+Inf"}, float64(h.count))
+		m.sample("protect_api_request_duration_seconds_sum", []string{"method", k.method, "endpoint", k.endpoint}, h.sum)
+		m.sample("protect_api_request_duration_seconds_count", []string{"method", k.method, "endpoint", k.endpoint}, float64(h.count))
+	}
+}
+
+// metricWriter writes the Prometheus text exposition format
+type metricWriter struct {
+	w io.Writer
+}
+
+// family writes the HELP and TYPE lines that precede a metric's samples
+func (m *metricWriter) family(name, kind, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one value; labels alternate between names and values
+func (m *metricWriter) sample(name string, labels []string, value float64) {
+	if len(labels) == 0 {
+		fmt.Fprintf(m.w, "%s %s\n", name, formatValue(value))
+		return
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], escapeLabel(labels[i+1])))
+	}
+	fmt.Fprintf(m.w, "%s{%s} %s\n", name, strings.Join(pairs, ","), formatValue(value))
+}
+
+// labelEscaper escapes label values as the text format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
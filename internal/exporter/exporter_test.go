@@ -0,0 +1,251 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// viewers are the viewers served by newTestServer
+const viewers = `[{"id":"vp1","name":"Tower","liveview":"lv1","state":"CONNECTED"},{"id":"vp2","name":"Lobby \"East\"","liveview":"lv9","state":"DISCONNECTED"}]`
+
+func newTestServer(t *testing.T) *protecttest.Server {
+	t.Helper()
+
+	server := protecttest.New(t)
+	server.Set("viewers", viewers)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door","state":"CONNECTED"}]`)
+	server.Set("lights", `[{"id":"light1","name":"Garage Light","state":"CONNECTED","isLightOn":true}]`)
+	server.Set("sensors", `[{"id":"s1","name":"Back Door","state":"CONNECTED","isOpened":true,"batteryStatus":{"percentage":80,"isLow":false},"stats":{"temperature":{"value":21.5},"humidity":{"value":null}}}]`)
+	return server
+}
+
+func TestWrite(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	e := New()
+	c.OnRequest = e.ObserveRequest
+	if err := e.inv.Load(c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	e.up, e.updated = true, time.Unix(1700000000, 0)
+	e.ObserveRequest("PATCH", "/proxy/protect/integration/v1/viewers/vp1", 0, 3*time.Second, errors.New("timeout"))
+
+	rec := httptest.NewRecorder()
+	e.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+
+	for _, want := range []string{
+		"protect_up 1\n",
+		"protect_last_update_timestamp_seconds 1.7e+09\n",
+		"# TYPE protect_viewer_online gauge\n",
+		`protect_viewer_info{id="vp1",name="Tower",liveview_id="lv1",liveview="All Cameras"} 1`,
+		`protect_viewer_online{id="vp1",name="Tower"} 1`,
+		`protect_viewer_online{id="vp2",name="Lobby \"East\""} 0`,
+		`protect_viewer_info{id="vp2",name="Lobby \"East\"",liveview_id="lv9",liveview="lv9"} 1`,
+		`protect_camera_connected{id="cam1",name="Front Door"} 1`,
+		`protect_light_on{id="light1",name="Garage Light"} 1`,
+		`protect_sensor_opened{id="s1",name="Back Door"} 1`,
+		`protect_sensor_battery_percent{id="s1",name="Back Door"} 80`,
+		`protect_sensor_temperature_celsius{id="s1",name="Back Door"} 21.5`,
+		`protect_api_requests_total{method="GET",endpoint="viewers",code="200"} 1`,
+		`protect_api_requests_total{method="PATCH",endpoint="viewers",code="0"} 1`,
+		`protect_api_request_errors_total{method="GET",endpoint="sensors"} 0`,
+		`protect_api_request_errors_total{method="PATCH",endpoint="viewers"} 1`,
+		`protect_api_request_duration_seconds_bucket{method="PATCH",endpoint="viewers",le="2.5"} 0`,
+		`protect_api_request_duration_seconds_bucket{method="PATCH",endpoint="viewers",le="5"} 1`,
+		`protect_api_request_duration_seconds_count{method="PATCH",endpoint="viewers"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics to contain %q", want)
+		}
+	}
+
+	if strings.Contains(out, "protect_sensor_humidity_percent{") {
+		t.Error("Expected no humidity sample for a sensor without a reading")
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %s", ct)
+	}
+}
+
+func TestWriteBeforeLoad(t *testing.T) {
+	var b strings.Builder
+	New().Write(&b)
+	if !strings.Contains(b.String(), "protect_up 0\n") {
+		t.Errorf("Expected protect_up 0 before the inventory loads, got:\n%s", b.String())
+	}
+}
+
+// newStreamServer serves the device lists, failing the first viewers
+// request when failFirst is set. The devices stream is dropped when drop is
+// closed, and connects after that are refused.
+func newStreamServer(t *testing.T, failFirst bool, drop <-chan struct{}) *protecttest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	loads, dropped := 0, false
+	upgrader := websocket.Upgrader{}
+
+	server := newTestServer(t)
+	server.Handle("subscribe/devices", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if dropped {
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Unlock()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		select {
+		case <-drop:
+			mu.Lock()
+			dropped = true
+			mu.Unlock()
+		case <-r.Context().Done():
+		}
+		conn.Close()
+	})
+	server.Handle("viewers", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		loads++
+		failed := failFirst && loads == 1
+		mu.Unlock()
+
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(viewers))
+	})
+	return server
+}
+
+// scrape returns the exporter's current metrics
+func scrape(e *Exporter) string {
+	var b strings.Builder
+	e.Write(&b)
+	return b.String()
+}
+
+// waitForMetric waits until the exporter's metrics contain want
+func waitForMetric(t *testing.T, e *Exporter, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(e), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %q, got:\n%s", want, scrape(e))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunStreamDropped(t *testing.T) {
+	drop := make(chan struct{})
+	server := newStreamServer(t, false, drop)
+
+	e := New()
+	resyncs := make(chan struct{}, 4)
+	e.inv.OnChange(func(change client.DeviceChange) {
+		if change.Action == client.DeviceResync {
+			resyncs <- struct{}{}
+		}
+	})
+	errs := make(chan error, 8)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Run(ctx, server.APIClient(), func(err error) { errs <- err })
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Loaded once at start and again when the stream connects
+	for i := 0; i < 2; i++ {
+		select {
+		case <-resyncs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for load %d", i+1)
+		}
+	}
+	waitForMetric(t, e, "protect_up 1\n")
+	if out := scrape(e); !strings.Contains(out, "protect_last_update_timestamp_seconds ") {
+		t.Errorf("Expected a last update timestamp, got:\n%s", out)
+	}
+
+	close(drop)
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the stream error")
+	}
+	if out := scrape(e); !strings.Contains(out, "protect_up 0\n") {
+		t.Errorf("Expected protect_up 0 after the stream dropped, got:\n%s", out)
+	}
+}
+
+func TestRunRetriesFirstLoad(t *testing.T) {
+	defer func(d time.Duration) { loadRetryMin = d }(loadRetryMin)
+	loadRetryMin = 10 * time.Millisecond
+
+	server := newStreamServer(t, true, nil)
+
+	e := New()
+	errs := make(chan error, 8)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Run(ctx, server.APIClient(), func(err error) { errs <- err })
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "failed to load inventory") {
+			t.Errorf("Expected a load error, got %v", err)
+		}
+	case err := <-done:
+		t.Fatalf("Run() returned after a failed first load: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the load error")
+	}
+
+	waitForMetric(t, e, "protect_up 1\n")
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"/proxy/protect/integration/v1/viewers":                 "viewers",
+		"/proxy/protect/integration/v1/cameras/cam1/ptz/goto/2": "cameras",
+		"/proxy/protect/integration/v1/files/animations?x=1":    "files",
+		"/api/other": "/api/other",
+	}
+	for path, want := range tests {
+		if got := endpoint(path); got != want {
+			t.Errorf("endpoint(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
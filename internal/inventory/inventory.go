@@ -0,0 +1,352 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Model keys for the device kinds tracked by the inventory
+const (
+	KindViewer = "viewer"
+	KindCamera = "camera"
+	KindLight  = "light"
+	KindSensor = "sensor"
+)
+
+// Snapshot is a consistent, point-in-time copy of the inventory
+type Snapshot struct {
+	Viewers   []client.Viewer
+	Cameras   []client.PTZCamera
+	Lights    []client.Light
+	Sensors   []client.Sensor
+	Liveviews []client.Liveview
+}
+
+// LiveviewName returns the name of a liveview, or the ID if it is unknown
+func (s Snapshot) LiveviewName(id string) string {
+	for _, lv := range s.Liveviews {
+		if lv.ID == id {
+			return lv.Name
+		}
+	}
+	return id
+}
+
+// ChangeFunc is called after a device change has been applied
+type ChangeFunc func(change client.DeviceChange)
+
+// devices holds the raw properties of one kind of device in arrival order
+type devices struct {
+	order []string
+	items map[string]map[string]json.RawMessage
+}
+
+// Inventory is an in-memory mirror of the console's devices, kept current
+// by applying changes from the devices WebSocket
+type Inventory struct {
+	mu        sync.RWMutex
+	kinds     map[string]*devices
+	liveviews []client.Liveview
+	loaded    bool
+
+	cbMu      sync.Mutex
+	callbacks map[int]ChangeFunc
+	nextID    int
+}
+
+// New creates an empty inventory
+func New() *Inventory {
+	return &Inventory{
+		kinds:     make(map[string]*devices),
+		callbacks: make(map[int]ChangeFunc),
+	}
+}
+
+// Load replaces the inventory contents with the current state from the API
+func (inv *Inventory) Load(c *client.Client) error {
+	viewers, err := c.ListViewports()
+	if err != nil {
+		return err
+	}
+
+	cameras, err := c.ListPTZCameras()
+	if err != nil {
+		return err
+	}
+
+	lights, err := c.ListLights()
+	if err != nil {
+		return err
+	}
+
+	sensors, err := c.ListSensors()
+	if err != nil {
+		return err
+	}
+
+	liveviews, err := c.ListCameras()
+	if err != nil {
+		return err
+	}
+
+	kinds := make(map[string]*devices)
+	for kind, list := range map[string]interface{}{
+		KindViewer: viewers,
+		KindCamera: cameras,
+		KindLight:  lights,
+		KindSensor: sensors,
+	} {
+		d, err := toDevices(list)
+		if err != nil {
+			return fmt.Errorf("failed to load %s inventory: %w", kind, err)
+		}
+		kinds[kind] = d
+	}
+
+	inv.mu.Lock()
+	inv.kinds = kinds
+	inv.liveviews = liveviews
+	inv.loaded = true
+	inv.mu.Unlock()
+
+	inv.notify(client.DeviceChange{Action: client.DeviceResync})
+	return nil
+}
+
+// toDevices converts a typed device list into raw property maps
+func toDevices(list interface{}) (*devices, error) {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	d := &devices{items: make(map[string]map[string]json.RawMessage)}
+	for _, item := range items {
+		var id string
+		json.Unmarshal(item["id"], &id)
+		d.order = append(d.order, id)
+		d.items[id] = item
+	}
+
+	return d, nil
+}
+
+// Loaded returns true once the inventory has been populated
+func (inv *Inventory) Loaded() bool {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return inv.loaded
+}
+
+// Apply applies a single device change and notifies registered callbacks
+func (inv *Inventory) Apply(change client.DeviceChange) {
+	if change.Action == client.DeviceResync {
+		return
+	}
+
+	inv.mu.Lock()
+	kind := change.ModelKey
+	if kind == "" {
+		kind = inv.kindOf(change.ID)
+	}
+	if kind == "" {
+		inv.mu.Unlock()
+		return
+	}
+
+	d, ok := inv.kinds[kind]
+	if !ok {
+		d = &devices{items: make(map[string]map[string]json.RawMessage)}
+		inv.kinds[kind] = d
+	}
+
+	switch change.Action {
+	case client.DeviceAdd:
+		if _, exists := d.items[change.ID]; !exists {
+			d.order = append(d.order, change.ID)
+		}
+		d.items[change.ID] = copyFields(change.Fields)
+
+	case client.DeviceUpdate:
+		item, exists := d.items[change.ID]
+		if !exists {
+			item = make(map[string]json.RawMessage)
+			d.items[change.ID] = item
+			d.order = append(d.order, change.ID)
+		}
+		for k, v := range change.Fields {
+			item[k] = v
+		}
+
+	case client.DeviceRemove:
+		delete(d.items, change.ID)
+		for i, id := range d.order {
+			if id == change.ID {
+				d.order = append(d.order[:i], d.order[i+1:]...)
+				break
+			}
+		}
+	}
+	inv.mu.Unlock()
+
+	change.ModelKey = kind
+	inv.notify(change)
+}
+
+// kindOf finds the kind of a known device; callers must hold inv.mu
+func (inv *Inventory) kindOf(id string) string {
+	for kind, d := range inv.kinds {
+		if _, ok := d.items[id]; ok {
+			return kind
+		}
+	}
+	return ""
+}
+
+// copyFields returns a shallow copy of a device's raw properties
+func copyFields(fields map[string]json.RawMessage) map[string]json.RawMessage {
+	item := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		item[k] = v
+	}
+	return item
+}
+
+// Snapshot returns a consistent copy of the current inventory
+func (inv *Inventory) Snapshot() Snapshot {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	var s Snapshot
+	decodeKind(inv.kinds[KindViewer], &s.Viewers)
+	decodeKind(inv.kinds[KindCamera], &s.Cameras)
+	decodeKind(inv.kinds[KindLight], &s.Lights)
+	decodeKind(inv.kinds[KindSensor], &s.Sensors)
+	s.Liveviews = append([]client.Liveview(nil), inv.liveviews...)
+
+	return s
+}
+
+// decodeKind decodes the raw devices of one kind into out
+func decodeKind(d *devices, out interface{}) {
+	if d == nil {
+		return
+	}
+
+	items := make([]map[string]json.RawMessage, 0, len(d.order))
+	for _, id := range d.order {
+		items = append(items, d.items[id])
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		logger.Get().Warnw("Failed to decode inventory", "error", err)
+	}
+}
+
+// OnChange registers fn to be called after every applied change and
+// returns a function that unregisters it
+func (inv *Inventory) OnChange(fn ChangeFunc) func() {
+	inv.cbMu.Lock()
+	defer inv.cbMu.Unlock()
+
+	id := inv.nextID
+	inv.nextID++
+	inv.callbacks[id] = fn
+
+	return func() {
+		inv.cbMu.Lock()
+		defer inv.cbMu.Unlock()
+		delete(inv.callbacks, id)
+	}
+}
+
+// notify calls every registered callback with change
+func (inv *Inventory) notify(change client.DeviceChange) {
+	inv.cbMu.Lock()
+	callbacks := make([]ChangeFunc, 0, len(inv.callbacks))
+	for _, fn := range inv.callbacks {
+		callbacks = append(callbacks, fn)
+	}
+	inv.cbMu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(change)
+	}
+}
+
+// Run loads the inventory and keeps it current from the devices WebSocket
+// until ctx is cancelled. The inventory is reloaded after every connect,
+// including the first, so nothing that changed before the stream was up is
+// missed; changes that arrive during a reload wait in the stream and are
+// applied after it. The stream carries no liveview changes, so liveviews
+// are only refreshed by those reloads. Stream errors are passed to onError
+// when it is set.
+func (inv *Inventory) Run(ctx context.Context, c *client.Client, onError func(error)) error {
+	log := logger.Get()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe first so the initial load and connect overlap
+	sub := c.SubscribeDevices(ctx)
+	if err := inv.Load(c); err != nil {
+		return err
+	}
+
+	errs := sub.Errors
+	var lastErr error
+
+	for {
+		select {
+		case change, ok := <-sub.Changes:
+			if !ok {
+				if errs != nil {
+					for err := range errs {
+						lastErr = err
+						if onError != nil {
+							onError(err)
+						}
+					}
+				}
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return lastErr
+			}
+
+			if change.Action != client.DeviceResync {
+				inv.Apply(change)
+				continue
+			}
+
+			log.Debug("Devices stream connected, reloading inventory")
+			if err := inv.Load(c); err != nil && onError != nil {
+				onError(err)
+			}
+
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			lastErr = err
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
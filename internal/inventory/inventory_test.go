@@ -0,0 +1,211 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newTestServer serves fixed device lists for the integration API
+func newTestServer(t *testing.T) *protecttest.Server {
+	t.Helper()
+
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Lobby","liveview":"lv1"},{"id":"vp2","name":"Office","liveview":"lv2"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door","modelKey":"camera"}]`)
+	server.Set("lights", `[{"id":"light1","name":"Garage","modelKey":"light","isLightOn":false}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"Entrance"},{"id":"lv2","name":"Parking"}]`)
+	return server
+}
+
+func rawFields(t *testing.T, v interface{}) map[string]json.RawMessage {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestLoad(t *testing.T) {
+	server := newTestServer(t)
+
+	inv := New()
+	if inv.Loaded() {
+		t.Error("Expected new inventory not to be loaded")
+	}
+
+	if err := inv.Load(server.APIClient()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !inv.Loaded() {
+		t.Error("Expected inventory to be loaded")
+	}
+
+	snap := inv.Snapshot()
+	if len(snap.Viewers) != 2 || snap.Viewers[0].Name != "Lobby" || snap.Viewers[1].Name != "Office" {
+		t.Errorf("Unexpected viewers: %+v", snap.Viewers)
+	}
+
+	if len(snap.Cameras) != 1 || len(snap.Lights) != 1 || len(snap.Sensors) != 0 {
+		t.Errorf("Unexpected device counts: %+v", snap)
+	}
+
+	if snap.LiveviewName("lv2") != "Parking" {
+		t.Errorf("Expected liveview name 'Parking', got '%s'", snap.LiveviewName("lv2"))
+	}
+
+	if snap.LiveviewName("unknown") != "unknown" {
+		t.Error("Expected unknown liveview ID to be returned as-is")
+	}
+}
+
+func TestApply(t *testing.T) {
+	server := newTestServer(t)
+
+	inv := New()
+	if err := inv.Load(server.APIClient()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Partial update without a model key changes only the given fields
+	inv.Apply(client.DeviceChange{
+		Action: client.DeviceUpdate,
+		ID:     "vp1",
+		Fields: rawFields(t, map[string]string{"liveview": "lv2"}),
+	})
+
+	snap := inv.Snapshot()
+	if snap.Viewers[0].Liveview != "lv2" || snap.Viewers[0].Name != "Lobby" {
+		t.Errorf("Expected Lobby to show lv2, got %+v", snap.Viewers[0])
+	}
+
+	inv.Apply(client.DeviceChange{
+		Action:   client.DeviceAdd,
+		ModelKey: KindViewer,
+		ID:       "vp3",
+		Fields:   rawFields(t, client.Viewer{ID: "vp3", Name: "Gate", Liveview: "lv1"}),
+	})
+
+	inv.Apply(client.DeviceChange{
+		Action:   client.DeviceRemove,
+		ModelKey: KindViewer,
+		ID:       "vp2",
+	})
+
+	snap = inv.Snapshot()
+	if len(snap.Viewers) != 2 || snap.Viewers[1].Name != "Gate" {
+		t.Errorf("Unexpected viewers after add/remove: %+v", snap.Viewers)
+	}
+
+	// Updates for unknown devices without a model key are ignored
+	inv.Apply(client.DeviceChange{Action: client.DeviceUpdate, ID: "missing"})
+	if len(inv.Snapshot().Viewers) != 2 {
+		t.Error("Expected unknown device update to be ignored")
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	inv := New()
+
+	var changes []client.DeviceChange
+	unregister := inv.OnChange(func(change client.DeviceChange) {
+		changes = append(changes, change)
+	})
+
+	inv.Apply(client.DeviceChange{
+		Action:   client.DeviceAdd,
+		ModelKey: KindViewer,
+		ID:       "vp1",
+		Fields:   rawFields(t, client.Viewer{ID: "vp1", Name: "Lobby"}),
+	})
+
+	if len(changes) != 1 || changes[0].ID != "vp1" || changes[0].ModelKey != KindViewer {
+		t.Fatalf("Expected one viewer change, got %+v", changes)
+	}
+
+	unregister()
+
+	inv.Apply(client.DeviceChange{Action: client.DeviceRemove, ID: "vp1"})
+	if len(changes) != 1 {
+		t.Errorf("Expected no callbacks after unregister, got %d", len(changes))
+	}
+}
+
+func TestRunReloadsOnConnect(t *testing.T) {
+	// The viewer switches after the first load but before the WebSocket
+	// connects, so only a reload on connect can see it
+	var mu sync.Mutex
+	loads := 0
+	connected := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+
+	server := protecttest.New(t)
+	server.Handle("subscribe/devices", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		close(connected)
+		<-r.Context().Done()
+	})
+	server.Handle("viewers", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		loads++
+		liveview := "lv1"
+		if loads > 1 {
+			liveview = "lv2"
+		}
+		mu.Unlock()
+		w.Write([]byte(`[{"id":"vp1","name":"Lobby","liveview":"` + liveview + `"}]`))
+	})
+
+	inv := New()
+	reloaded := make(chan struct{}, 4)
+	inv.OnChange(func(change client.DeviceChange) {
+		if change.Action == client.DeviceResync {
+			reloaded <- struct{}{}
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- inv.Run(ctx, server.APIClient(), nil) }()
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the devices stream")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-reloaded:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for load %d", i+1)
+		}
+	}
+
+	if viewers := inv.Snapshot().Viewers; len(viewers) != 1 || viewers[0].Liveview != "lv2" {
+		t.Errorf("Expected the reload on connect to pick up lv2, got %+v", viewers)
+	}
+
+	cancel()
+	if err := <-done; err != nil && err != context.Canceled {
+		t.Errorf("Run() error = %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Defaults for settings left out of the configuration
+const (
+	DefaultTopic           = "protect"
+	DefaultDiscoveryPrefix = "homeassistant"
+)
+
+// DefaultPresets are the PTZ presets given a button when none are configured
+var DefaultPresets = []string{"home", "1", "2", "3", "4"}
+
+// publishTimeout bounds how long a publish waits for the broker
+var publishTimeout = 10 * time.Second
+
+// Availability payloads; offline is also the last will
+const (
+	online  = "online"
+	offline = "offline"
+)
+
+// Command is a command received over MQTT and its outcome
+type Command struct {
+	Topic   string          `json:"topic"`
+	Payload string          `json:"payload"`
+	Result  *actions.Result `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Bridge publishes viewer state to an MQTT broker and carries out commands
+// received from it
+type Bridge struct {
+	client   *client.Client
+	settings config.MQTT
+	presets  []int
+	inv      *inventory.Inventory
+
+	// OnCommand, if set, is called after each command
+	OnCommand func(Command)
+
+	conn    paho.Client
+	mu      sync.Mutex
+	cameras []client.PTZCamera
+	states  map[string]string
+}
+
+// New creates a bridge, filling in defaults and checking the presets
+func New(c *client.Client, settings config.MQTT) (*Bridge, error) {
+	if settings.Broker == "" {
+		return nil, fmt.Errorf("no MQTT broker configured")
+	}
+	if settings.Topic == "" {
+		settings.Topic = DefaultTopic
+	}
+	settings.Topic = strings.TrimSuffix(settings.Topic, "/")
+	if settings.DiscoveryPrefix == "" {
+		settings.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if settings.ClientID == "" {
+		host, _ := os.Hostname()
+		settings.ClientID = "protect-" + host
+	}
+	if len(settings.Presets) == 0 {
+		settings.Presets = DefaultPresets
+	}
+
+	b := &Bridge{client: c, settings: settings, inv: inventory.New(), states: make(map[string]string)}
+	for _, p := range settings.Presets {
+		preset, err := actions.ParsePreset(p)
+		if err != nil {
+			return nil, err
+		}
+		if preset < -1 || preset > 9 {
+			return nil, fmt.Errorf("invalid preset value: %d (must be between -1 and 9)", preset)
+		}
+		b.presets = append(b.presets, preset)
+	}
+
+	return b, nil
+}
+
+// statusTopic carries the bridge's availability
+func (b *Bridge) statusTopic() string {
+	return b.settings.Topic + "/status"
+}
+
+// viewerStateTopic carries the liveview a viewer is showing
+func (b *Bridge) viewerStateTopic(id string) string {
+	return b.settings.Topic + "/viewer/" + id + "/liveview"
+}
+
+// viewerCommandTopic switches a viewer to the liveview in the payload
+func (b *Bridge) viewerCommandTopic(id string) string {
+	return b.settings.Topic + "/viewer/" + id + "/set"
+}
+
+// ptzCommandTopic moves a camera to the preset in the payload
+func (b *Bridge) ptzCommandTopic(id string) string {
+	return b.settings.Topic + "/camera/" + id + "/ptz"
+}
+
+// Run connects to the broker and bridges until ctx is cancelled. Viewer
+// state follows the devices WebSocket; after a reconnect to the broker the
+// availability, discovery and state messages are all published again. On
+// exit the bridge marks itself offline; if it dies instead, the broker
+// publishes the same message as its last will.
+func (b *Bridge) Run(ctx context.Context) error {
+	log := logger.Get()
+
+	if err := b.inv.Load(b.client); err != nil {
+		return err
+	}
+
+	cameras, err := b.selectCameras(b.inv.Snapshot().Cameras)
+	if err != nil {
+		return err
+	}
+	b.cameras = cameras
+
+	opts := paho.NewClientOptions().
+		AddBroker(b.settings.Broker).
+		SetClientID(b.settings.ClientID).
+		SetUsername(b.settings.Username).
+		SetPassword(b.settings.Password).
+		SetWill(b.statusTopic(), offline, 1, true).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(30 * time.Second).
+		SetOrderMatters(false).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Warnw("Lost connection to MQTT broker", "error", err)
+		})
+
+	b.conn = paho.NewClient(opts)
+	token := b.conn.Connect()
+	if !token.WaitTimeout(30*time.Second) || token.Error() != nil {
+		err := token.Error()
+		if err == nil {
+			err = fmt.Errorf("timed out")
+		}
+		return fmt.Errorf("failed to connect to MQTT broker %s: %w", b.settings.Broker, err)
+	}
+
+	// Liveviews only change on a reload, so discovery is republished after
+	// every resync as well as when viewers come and go
+	unregister := b.inv.OnChange(func(change client.DeviceChange) {
+		if change.Action == client.DeviceResync || change.ModelKey == inventory.KindViewer {
+			if change.Action != client.DeviceUpdate {
+				b.publishDiscovery()
+			}
+			b.publishStates()
+		}
+	})
+	defer unregister()
+
+	invErrs := make(chan error, 1)
+	go func() {
+		invErrs <- b.inv.Run(ctx, b.client, func(err error) {
+			log.Warnw("Device stream error", "error", err)
+		})
+	}()
+
+	select {
+	case <-ctx.Done():
+		err = nil
+	case err = <-invErrs:
+		err = fmt.Errorf("device stream closed: %w", err)
+	}
+
+	b.publish(b.statusTopic(), offline, true)
+	b.conn.Disconnect(250)
+
+	return err
+}
+
+// selectCameras picks the cameras that get preset buttons
+func (b *Bridge) selectCameras(all []client.PTZCamera) ([]client.PTZCamera, error) {
+	if len(b.settings.Cameras) == 0 {
+		var cameras []client.PTZCamera
+		for _, cam := range all {
+			if cam.HasPTZ() {
+				cameras = append(cameras, cam)
+			}
+		}
+		return cameras, nil
+	}
+
+	var cameras []client.PTZCamera
+	for _, name := range b.settings.Cameras {
+		found := false
+		for _, cam := range all {
+			if cam.ID == name || cam.Name == name {
+				cameras = append(cameras, cam)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("camera not found: %s", name)
+		}
+	}
+	return cameras, nil
+}
+
+// onConnect runs after every connect, including reconnects
+func (b *Bridge) onConnect(conn paho.Client) {
+	log := logger.Get()
+	log.Infow("Connected to MQTT broker", "broker", b.settings.Broker)
+
+	b.publish(b.statusTopic(), online, true)
+
+	subs := map[string]byte{
+		b.viewerCommandTopic("+"): 1,
+		b.ptzCommandTopic("+"):    1,
+	}
+	token := conn.SubscribeMultiple(subs, b.handleMessage)
+	if !token.WaitTimeout(publishTimeout) || token.Error() != nil {
+		log.Warnw("Failed to subscribe to command topics", "error", token.Error())
+	}
+
+	b.publishDiscovery()
+
+	// Retained state may have been lost with the broker, so publish it all
+	b.mu.Lock()
+	b.states = make(map[string]string)
+	b.mu.Unlock()
+	b.publishStates()
+}
+
+// publish sends a message at QoS 1 and waits for the broker to accept it
+func (b *Bridge) publish(topic, payload string, retained bool) {
+	log := logger.Get()
+
+	token := b.conn.Publish(topic, 1, retained, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		log.Warnw("Timed out publishing to MQTT", "topic", topic)
+		return
+	}
+	if err := token.Error(); err != nil {
+		log.Warnw("Failed to publish to MQTT", "topic", topic, "error", err)
+	}
+}
+
+// publishStates publishes the liveview of every viewer that has changed
+func (b *Bridge) publishStates() {
+	if b.conn == nil || !b.conn.IsConnected() {
+		return
+	}
+
+	snap := b.inv.Snapshot()
+	for _, vp := range snap.Viewers {
+		state := snap.LiveviewName(vp.Liveview)
+
+		b.mu.Lock()
+		changed := b.states[vp.ID] != state
+		b.states[vp.ID] = state
+		b.mu.Unlock()
+
+		if changed {
+			b.publish(b.viewerStateTopic(vp.ID), state, true)
+		}
+	}
+}
+
+// discoveryDevice groups entities under a device in Home Assistant
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// selectConfig is the Home Assistant discovery payload for a viewer
+type selectConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	StateTopic        string          `json:"state_topic"`
+	CommandTopic      string          `json:"command_topic"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Options           []string        `json:"options"`
+	Device            discoveryDevice `json:"device"`
+}
+
+// buttonConfig is the Home Assistant discovery payload for a PTZ preset
+type buttonConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	CommandTopic      string          `json:"command_topic"`
+	PayloadPress      string          `json:"payload_press"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Device            discoveryDevice `json:"device"`
+}
+
+// nodeIDPattern matches characters Home Assistant does not allow in IDs
+var nodeIDPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// objectID builds a discovery ID unique to this bridge's topic
+func (b *Bridge) objectID(parts ...string) string {
+	return nodeIDPattern.ReplaceAllString(b.settings.Topic+"_"+strings.Join(parts, "_"), "_")
+}
+
+// publishDiscovery publishes a select entity per viewer and a button per
+// PTZ preset for Home Assistant
+func (b *Bridge) publishDiscovery() {
+	if b.conn == nil || !b.conn.IsConnected() {
+		return
+	}
+
+	snap := b.inv.Snapshot()
+
+	options := make([]string, 0, len(snap.Liveviews))
+	for _, lv := range snap.Liveviews {
+		options = append(options, lv.Name)
+	}
+
+	for _, vp := range snap.Viewers {
+		id := b.objectID("viewer", vp.ID)
+		b.publishJSON(b.settings.DiscoveryPrefix+"/select/"+id+"/config", selectConfig{
+			Name:              "Liveview",
+			UniqueID:          id,
+			StateTopic:        b.viewerStateTopic(vp.ID),
+			CommandTopic:      b.viewerCommandTopic(vp.ID),
+			AvailabilityTopic: b.statusTopic(),
+			Options:           options,
+			Device:            discoveryDevice{Identifiers: []string{b.objectID(vp.ID)}, Name: vp.Name, Manufacturer: "Ubiquiti", Model: "UniFi Protect Viewer"},
+		})
+	}
+
+	for _, cam := range b.cameras {
+		for _, preset := range b.presets {
+			label := "home"
+			name := "Home"
+			if preset != -1 {
+				label = strconv.Itoa(preset)
+				name = "Preset " + label
+			}
+
+			id := b.objectID("camera", cam.ID, "preset", label)
+			b.publishJSON(b.settings.DiscoveryPrefix+"/button/"+id+"/config", buttonConfig{
+				Name:              name,
+				UniqueID:          id,
+				CommandTopic:      b.ptzCommandTopic(cam.ID),
+				PayloadPress:      label,
+				AvailabilityTopic: b.statusTopic(),
+				Device:            discoveryDevice{Identifiers: []string{b.objectID(cam.ID)}, Name: cam.Name, Manufacturer: "Ubiquiti", Model: "UniFi Protect Camera"},
+			})
+		}
+	}
+}
+
+// publishJSON publishes a retained JSON message
+func (b *Bridge) publishJSON(topic string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Get().Warnw("Failed to encode MQTT message", "topic", topic, "error", err)
+		return
+	}
+	b.publish(topic, string(data), true)
+}
+
+// handleMessage carries out a command from a viewer or camera topic
+func (b *Bridge) handleMessage(_ paho.Client, msg paho.Message) {
+	log := logger.Get()
+
+	cmd := Command{Topic: msg.Topic(), Payload: strings.TrimSpace(string(msg.Payload()))}
+	result, err := b.command(cmd.Topic, cmd.Payload)
+	cmd.Result = result
+	if err != nil {
+		cmd.Error = err.Error()
+		log.Warnw("MQTT command failed", "topic", cmd.Topic, "payload", cmd.Payload, "error", err)
+	} else {
+		log.Infow("MQTT command", "topic", cmd.Topic, "payload", cmd.Payload)
+	}
+
+	if b.OnCommand != nil {
+		b.OnCommand(cmd)
+	}
+}
+
+// command resolves and runs the action a command topic asks for
+func (b *Bridge) command(topic, payload string) (*actions.Result, error) {
+	rest, ok := strings.CutPrefix(topic, b.settings.Topic+"/")
+	if !ok {
+		return nil, fmt.Errorf("unexpected topic: %s", topic)
+	}
+
+	var action actions.Action
+	switch parts := strings.Split(rest, "/"); {
+	case len(parts) == 3 && parts[0] == "viewer" && parts[2] == "set":
+		action = actions.Switch(parts[1], payload)
+	case len(parts) == 3 && parts[0] == "camera" && parts[2] == "ptz":
+		preset, err := actions.ParsePreset(payload)
+		if err != nil {
+			return nil, err
+		}
+		action = actions.PTZ(parts[1], preset)
+	default:
+		return nil, fmt.Errorf("unexpected topic: %s", topic)
+	}
+
+	targets, err := actions.NewResolver(b.client).Resolve([]actions.Action{action})
+	if err != nil {
+		return nil, err
+	}
+
+	result := actions.Execute(context.Background(), b.client, targets, 1)[0]
+	if !result.Success {
+		return &result, fmt.Errorf("%s", result.Error)
+	}
+	return &result, nil
+}
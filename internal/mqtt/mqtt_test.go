@@ -0,0 +1,350 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/gorilla/websocket"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+)
+
+// fakeBroker is a minimal MQTT 3.1.1 broker that records what clients
+// connect and publish with, and can send messages to them
+type fakeBroker struct {
+	ln net.Listener
+
+	mu        sync.Mutex
+	conns     []net.Conn
+	writeMu   sync.Mutex
+	connects  []*packets.ConnectPacket
+	published []*packets.PublishPacket
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	b := &fakeBroker{ln: ln}
+	t.Cleanup(func() { b.close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			b.mu.Lock()
+			b.conns = append(b.conns, conn)
+			b.mu.Unlock()
+			go b.serve(conn)
+		}
+	}()
+
+	return b
+}
+
+func (b *fakeBroker) url() string {
+	return "tcp://" + b.ln.Addr().String()
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		cp, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch p := cp.(type) {
+		case *packets.ConnectPacket:
+			b.mu.Lock()
+			b.connects = append(b.connects, p)
+			b.mu.Unlock()
+			b.write(conn, packets.NewControlPacket(packets.Connack))
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = p.Qoss
+			b.write(conn, ack)
+		case *packets.PublishPacket:
+			b.mu.Lock()
+			b.published = append(b.published, p)
+			b.mu.Unlock()
+			if p.Qos == 1 {
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				b.write(conn, ack)
+			}
+		case *packets.PingreqPacket:
+			b.write(conn, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) write(conn net.Conn, p packets.ControlPacket) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	p.Write(conn)
+}
+
+// send publishes a message to the most recent client
+func (b *fakeBroker) send(topic, payload string) {
+	b.mu.Lock()
+	conn := b.conns[len(b.conns)-1]
+	b.mu.Unlock()
+
+	p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	p.TopicName = topic
+	p.Payload = []byte(payload)
+	b.write(conn, p)
+}
+
+// drop closes every client connection, as a broker restart would
+func (b *fakeBroker) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, conn := range b.conns {
+		conn.Close()
+	}
+}
+
+func (b *fakeBroker) close() {
+	b.ln.Close()
+	b.drop()
+}
+
+// waitFor waits until a message matching topic and payload has been
+// published count times
+func (b *fakeBroker) waitFor(t *testing.T, topic, payload string, count int) *packets.PublishPacket {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		var last *packets.PublishPacket
+		n := 0
+		for _, p := range b.published {
+			if p.TopicName == topic && strings.Contains(string(p.Payload), payload) {
+				last = p
+				n++
+			}
+		}
+		b.mu.Unlock()
+
+		if n >= count {
+			return last
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Timed out waiting for %q on %s (x%d)", payload, topic, count)
+	return nil
+}
+
+// fakeProtect serves viewers, liveviews and cameras, records changes and
+// pushes device updates over the devices WebSocket
+type fakeProtect struct {
+	mu        sync.Mutex
+	requests  []string
+	liveviews string
+	updates   chan string
+}
+
+func newFakeProtect(t *testing.T) (*fakeProtect, *client.Client) {
+	t.Helper()
+
+	f := &fakeProtect{
+		liveviews: `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`,
+		updates:   make(chan string, 4),
+	}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/proxy/protect/integration/v1/subscribe/devices":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for {
+				select {
+				case msg := <-f.updates:
+					conn.WriteMessage(websocket.TextMessage, []byte(msg))
+				case <-r.Context().Done():
+					return
+				}
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/viewers":
+			w.Write([]byte(`[{"id":"vp1","name":"Tower","liveview":"lv1"}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/liveviews":
+			f.mu.Lock()
+			liveviews := f.liveviews
+			f.mu.Unlock()
+			w.Write([]byte(liveviews))
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/cameras":
+			w.Write([]byte(`[{"id":"cam1","name":"Front Door","modelKey":"camera"}]`))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`[]`))
+		default:
+			f.mu.Lock()
+			f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+			f.mu.Unlock()
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return f, client.NewClient(server.URL, "test-token")
+}
+
+func (f *fakeProtect) log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(nil, config.MQTT{}); err == nil {
+		t.Error("Expected an error without a broker")
+	}
+
+	if _, err := New(nil, config.MQTT{Broker: "tcp://localhost:1883", Presets: []string{"12"}}); err == nil {
+		t.Error("Expected an error for an out-of-range preset")
+	}
+
+	b, err := New(nil, config.MQTT{Broker: "tcp://localhost:1883", Topic: "home/protect/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if b.settings.Topic != "home/protect" || b.settings.DiscoveryPrefix != DefaultDiscoveryPrefix || len(b.presets) != len(DefaultPresets) || b.presets[0] != -1 {
+		t.Errorf("Unexpected defaults: %+v %v", b.settings, b.presets)
+	}
+	if got := b.objectID("viewer", "vp1"); got != "home_protect_viewer_vp1" {
+		t.Errorf("objectID() = %s", got)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	broker := newFakeBroker(t)
+	protect, c := newFakeProtect(t)
+
+	b, err := New(c, config.MQTT{Broker: broker.url(), ClientID: "test", Presets: []string{"home", "2"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	commands := make(chan Command, 4)
+	b.OnCommand = func(cmd Command) { commands <- cmd }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+
+	broker.waitFor(t, "protect/status", "online", 1)
+	broker.waitFor(t, "protect/viewer/vp1/liveview", "All Cameras", 1)
+
+	broker.mu.Lock()
+	connect := broker.connects[0]
+	broker.mu.Unlock()
+	if !connect.WillFlag || connect.WillTopic != "protect/status" || string(connect.WillMessage) != "offline" || !connect.WillRetain {
+		t.Errorf("Unexpected last will: %+v", connect)
+	}
+
+	// Discovery
+	sel := broker.waitFor(t, "homeassistant/select/protect_viewer_vp1/config", "", 1)
+	var selCfg selectConfig
+	if err := json.Unmarshal(sel.Payload, &selCfg); err != nil {
+		t.Fatalf("Invalid select config %s: %v", sel.Payload, err)
+	}
+	if !sel.Retain || selCfg.CommandTopic != "protect/viewer/vp1/set" || strings.Join(selCfg.Options, ",") != "All Cameras,Driveway" || selCfg.Device.Name != "Tower" {
+		t.Errorf("Unexpected select config: %+v", selCfg)
+	}
+	broker.waitFor(t, "homeassistant/button/protect_camera_cam1_preset_home/config", `"payload_press":"home"`, 1)
+	broker.waitFor(t, "homeassistant/button/protect_camera_cam1_preset_2/config", `"command_topic":"protect/camera/cam1/ptz"`, 1)
+
+	// Commands
+	broker.send("protect/viewer/vp1/set", "Driveway")
+	broker.send("protect/camera/cam1/ptz", "2")
+	broker.send("protect/viewer/vp1/set", "Nowhere")
+	for i := 0; i < 3; i++ {
+		select {
+		case cmd := <-commands:
+			wantErr := cmd.Payload == "Nowhere"
+			if (cmd.Error != "") != wantErr {
+				t.Errorf("Unexpected command outcome: %+v", cmd)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for commands")
+		}
+	}
+	got := strings.Join(protect.log(), ",")
+	if !strings.Contains(got, "PATCH /proxy/protect/integration/v1/viewers/vp1") || !strings.Contains(got, "POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/2") {
+		t.Errorf("Unexpected requests: %s", got)
+	}
+
+	// State follows device updates
+	protect.updates <- `{"type":"update","item":{"id":"vp1","modelKey":"viewer","liveview":"lv2"}}`
+	broker.waitFor(t, "protect/viewer/vp1/liveview", "Driveway", 1)
+
+	// Reconnecting publishes everything again
+	broker.drop()
+	broker.waitFor(t, "protect/status", "online", 2)
+	broker.waitFor(t, "protect/viewer/vp1/liveview", "Driveway", 2)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+	if last := broker.waitFor(t, "protect/status", "offline", 1); !last.Retain {
+		t.Error("Expected the offline status to be retained")
+	}
+}
+
+func TestBridgeRepublishesLiveviews(t *testing.T) {
+	broker := newFakeBroker(t)
+	protect, c := newFakeProtect(t)
+
+	b, err := New(c, config.MQTT{Broker: broker.url(), ClientID: "test"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	topic := "homeassistant/select/protect_viewer_vp1/config"
+	broker.waitFor(t, topic, `"options":["All Cameras","Driveway"]`, 1)
+
+	// A liveview renamed and one added show up after the next reload
+	protect.mu.Lock()
+	protect.liveviews = `[{"id":"lv1","name":"Everything"},{"id":"lv2","name":"Driveway"},{"id":"lv3","name":"Garage"}]`
+	protect.mu.Unlock()
+	if err := b.inv.Load(c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	broker.waitFor(t, topic, `"options":["Everything","Driveway","Garage"]`, 1)
+}
@@ -0,0 +1,160 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format for listings and results
+type Format string
+
+// Supported output formats
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	CSV   Format = "csv"
+	TSV   Format = "tsv"
+	Name  Format = "name"
+)
+
+// ParseFormat validates an output format name
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case Table, JSON, YAML, CSV, TSV, Name:
+		return f, nil
+	case "":
+		return Table, nil
+	default:
+		return "", fmt.Errorf("invalid output format: %s (use 'table', 'json', 'yaml', 'csv', 'tsv' or 'name')", s)
+	}
+}
+
+// Options controls how output is rendered
+type Options struct {
+	Format    Format
+	Template  string
+	NoHeaders bool
+}
+
+// Structured returns true when the output is meant for machines rather than
+// people, i.e. anything other than the default table
+func (o Options) Structured() bool {
+	return o.Format != Table || o.Template != ""
+}
+
+// Listing holds the same data in the shapes needed by each format
+type Listing struct {
+	// Items is a slice of records (or a single record) for JSON, YAML and
+	// templates
+	Items interface{}
+	// Headers and Rows are used for CSV and TSV
+	Headers []string
+	Rows    [][]string
+	// Names is used for the name format
+	Names []string
+}
+
+// Write renders a listing in a structured format. Table output is left to
+// the caller, since each listing has its own human-readable layout.
+func Write(w io.Writer, opts Options, l Listing) error {
+	if opts.Template != "" {
+		return writeTemplate(w, opts.Template, l.Items)
+	}
+
+	switch opts.Format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(emptySlice(l.Items))
+
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(emptySlice(l.Items)); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	case CSV, TSV:
+		cw := csv.NewWriter(w)
+		if opts.Format == TSV {
+			cw.Comma = '\t'
+		}
+		if !opts.NoHeaders {
+			cw.Write(l.Headers)
+		}
+		cw.WriteAll(l.Rows)
+		return cw.Error()
+
+	case Name:
+		for _, name := range l.Names {
+			fmt.Fprintln(w, name)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("output format %s is not supported here", opts.Format)
+	}
+}
+
+// writeTemplate renders tmpl once per item, each followed by a newline
+func writeTemplate(w io.Writer, tmpl string, items interface{}) error {
+	t, err := template.New("output").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		if err := t.Execute(w, items); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := t.Execute(w, v.Index(i).Interface()); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+// emptySlice makes nil slices encode as [] instead of null
+func emptySlice(items interface{}) interface{} {
+	v := reflect.ValueOf(items)
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return items
+}
+
+// Error is the structured form of a failed command
+type Error struct {
+	Success bool   `json:"success" yaml:"success"`
+	Error   string `json:"error" yaml:"error"`
+}
+
+// WriteError renders err in a structured format. Formats without a natural
+// error shape fall back to JSON.
+func WriteError(w io.Writer, opts Options, err error) error {
+	record := Error{Success: false, Error: err.Error()}
+
+	switch opts.Format {
+	case YAML:
+		return Write(w, Options{Format: YAML}, Listing{Items: record})
+	default:
+		return Write(w, Options{Format: JSON}, Listing{Items: record})
+	}
+}
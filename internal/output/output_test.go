@@ -0,0 +1,163 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testRecord struct {
+	Name string `json:"name" yaml:"name"`
+	ID   string `json:"id" yaml:"id"`
+}
+
+func testListing() Listing {
+	return Listing{
+		Items:   []testRecord{{Name: "Front Door", ID: "cam1"}, {Name: "Driveway", ID: "cam2"}},
+		Headers: []string{"name", "id"},
+		Rows:    [][]string{{"Front Door", "cam1"}, {"Driveway", "cam2"}},
+		Names:   []string{"Front Door", "Driveway"},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"", Table, false},
+		{"table", Table, false},
+		{"JSON", JSON, false},
+		{"yaml", YAML, false},
+		{"csv", CSV, false},
+		{"tsv", TSV, false},
+		{"name", Name, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructured(t *testing.T) {
+	if (Options{Format: Table}).Structured() {
+		t.Error("Expected table output not to be structured")
+	}
+	if !(Options{Format: Table, Template: "{{.Name}}"}).Structured() {
+		t.Error("Expected template output to be structured")
+	}
+	if !(Options{Format: JSON}).Structured() {
+		t.Error("Expected JSON output to be structured")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "JSON",
+			opts: Options{Format: JSON},
+			want: "[\n  {\n    \"name\": \"Front Door\",\n    \"id\": \"cam1\"\n  },\n  {\n    \"name\": \"Driveway\",\n    \"id\": \"cam2\"\n  }\n]\n",
+		},
+		{
+			name: "YAML",
+			opts: Options{Format: YAML},
+			want: "- name: Front Door\n  id: cam1\n- name: Driveway\n  id: cam2\n",
+		},
+		{
+			name: "CSV",
+			opts: Options{Format: CSV},
+			want: "name,id\nFront Door,cam1\nDriveway,cam2\n",
+		},
+		{
+			name: "TSV without headers",
+			opts: Options{Format: TSV, NoHeaders: true},
+			want: "Front Door\tcam1\nDriveway\tcam2\n",
+		},
+		{
+			name: "Name",
+			opts: Options{Format: Name},
+			want: "Front Door\nDriveway\n",
+		},
+		{
+			name: "Template",
+			opts: Options{Format: Table, Template: "{{.ID}}={{.Name}}"},
+			want: "cam1=Front Door\ncam2=Driveway\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := Write(buf, tt.opts, testListing()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Write() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteEmptyJSON(t *testing.T) {
+	var items []testRecord
+
+	buf := new(bytes.Buffer)
+	if err := Write(buf, Options{Format: JSON}, Listing{Items: items}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected empty listing to encode as [], got %q", buf.String())
+	}
+}
+
+func TestWriteInvalidTemplate(t *testing.T) {
+	if err := Write(new(bytes.Buffer), Options{Template: "{{.Name"}, testListing()); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+
+	if err := Write(new(bytes.Buffer), Options{Template: "{{.Missing}}"}, testListing()); err == nil {
+		t.Error("Expected error for unknown template field")
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := WriteError(buf, Options{Format: CSV}, errors.New("viewport not found: Tower")); err != nil {
+		t.Fatalf("WriteError() error = %v", err)
+	}
+
+	var record Error
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected JSON error record, got %q: %v", buf.String(), err)
+	}
+
+	if record.Success || record.Error != "viewport not found: Tower" {
+		t.Errorf("Unexpected error record: %+v", record)
+	}
+
+	buf.Reset()
+	if err := WriteError(buf, Options{Format: YAML}, errors.New("boom")); err != nil {
+		t.Fatalf("WriteError() error = %v", err)
+	}
+
+	if buf.String() != "success: false\nerror: boom\n" {
+		t.Errorf("Unexpected YAML error record: %q", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+// Package protecttest provides a fake Protect console for tests
+package protecttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+)
+
+// APIPath is the prefix of every integration API path
+const APIPath = "/proxy/protect/integration/v1/"
+
+// Server is a fake console. GET requests are answered with the lists set
+// with Set, and viewers, liveviews, cameras, lights and sensors are empty
+// until set. Any other request is recorded and answered with an empty
+// object.
+type Server struct {
+	*httptest.Server
+
+	t        testing.TB
+	mu       sync.Mutex
+	token    string
+	lists    map[string]string
+	handlers map[string]http.HandlerFunc
+	fail     map[string]int
+	lookups  int
+	requests []string
+}
+
+// New starts a fake console that is closed when the test ends
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	s := newServer(t)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewTLS is New over HTTPS with a self-signed certificate
+func NewTLS(t testing.TB) *Server {
+	t.Helper()
+
+	s := newServer(t)
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newServer(t testing.TB) *Server {
+	return &Server{
+		t: t,
+		lists: map[string]string{
+			"viewers":   `[]`,
+			"liveviews": `[]`,
+			"cameras":   `[]`,
+			"lights":    `[]`,
+			"sensors":   `[]`,
+		},
+		handlers: make(map[string]http.HandlerFunc),
+		fail:     make(map[string]int),
+	}
+}
+
+// APIClient returns a client for the console using the key "test-token"
+func (s *Server) APIClient() *client.Client {
+	return client.NewClient(s.URL, "test-token")
+}
+
+// RequireToken answers requests with any other API key with a 401
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// Set answers GET requests for path, relative to APIPath, with body
+func (s *Server) Set(path, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists[path] = body
+}
+
+// Fail answers requests for paths starting with prefix, relative to
+// APIPath, with status
+func (s *Server) Fail(prefix string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail[prefix] = status
+}
+
+// Handle serves pattern with h. The pattern is a path relative to APIPath,
+// optionally preceded by a method and a space, as in "PATCH viewers/vp1".
+// Requests are still counted and recorded.
+func (s *Server) Handle(pattern string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[pattern] = h
+}
+
+// Lookups returns the number of GET requests served
+func (s *Server) Lookups() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookups
+}
+
+// Requests returns every request other than a GET, as "METHOD /path"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, APIPath)
+
+	s.mu.Lock()
+	if s.token != "" && r.Header.Get("X-API-Key") != s.token {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodGet {
+		s.lookups++
+	} else {
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	}
+	h, ok := s.handlers[r.Method+" "+path]
+	if !ok {
+		h, ok = s.handlers[path]
+	}
+	status := 0
+	for prefix, code := range s.fail {
+		if strings.HasPrefix(path, prefix) {
+			status = code
+		}
+	}
+	body, listed := s.lists[path]
+	s.mu.Unlock()
+
+	// Handlers run unlocked, so they may block or call back into s
+	switch {
+	case ok:
+		h(w, r)
+	case status != 0:
+		w.WriteHeader(status)
+	case r.Method != http.MethodGet:
+		w.Write([]byte(`{}`))
+	case listed:
+		w.Write([]byte(body))
+	default:
+		s.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
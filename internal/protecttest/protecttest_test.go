@@ -0,0 +1,47 @@
+package protecttest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	server := New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower"}]`)
+	server.Fail("cameras/cam2/", http.StatusInternalServerError)
+	c := server.APIClient()
+
+	viewports, err := c.ListViewports()
+	if err != nil || len(viewports) != 1 || viewports[0].Name != "Tower" {
+		t.Fatalf("ListViewports() = %+v, %v", viewports, err)
+	}
+
+	if lights, err := c.ListLights(); err != nil || len(lights) != 0 {
+		t.Errorf("Expected no lights by default, got %+v, %v", lights, err)
+	}
+
+	if err := c.SwitchViewport("vp1", "lv1"); err != nil {
+		t.Errorf("SwitchViewport() error = %v", err)
+	}
+	if err := c.MovePTZToPreset("cam2", 1); err == nil {
+		t.Error("Expected the failing path to return an error")
+	}
+
+	want := "PATCH " + APIPath + "viewers/vp1,POST " + APIPath + "cameras/cam2/ptz/goto/1"
+	if got := strings.Join(server.Requests(), ","); got != want {
+		t.Errorf("Requests() = %q, want %q", got, want)
+	}
+	if server.Lookups() != 2 {
+		t.Errorf("Expected 2 lookups, got %d", server.Lookups())
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	server := New(t)
+	server.RequireToken("good")
+
+	if _, err := server.APIClient().ListViewports(); err == nil {
+		t.Error("Expected another API key to be refused")
+	}
+}
@@ -0,0 +1,535 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Version is the JSON-RPC protocol version spoken
+const Version = "2.0"
+
+// Standard JSON-RPC 2.0 error codes, and the server errors used for
+// unknown names and failed actions
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeActionFailed   = -32000
+	CodeNotFound       = -32001
+)
+
+// Notification methods sent by the server
+const (
+	NotifyDevice = "deviceChanged"
+	NotifyEvent  = "eventReceived"
+)
+
+// maxMessageSize limits a single request line
+const maxMessageSize = 1 << 20
+
+// Request is a JSON-RPC request, or a notification when ID is absent
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is the reply to a request with an ID
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a message from the server that expects no reply
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// invalidParams wraps a params error
+func invalidParams(err error) *Error {
+	return &Error{Code: CodeInvalidParams, Message: err.Error()}
+}
+
+// Handler runs one method with its raw params
+type Handler func(ctx context.Context, params json.RawMessage) (any, error)
+
+// Server answers JSON-RPC requests. One server can serve several streams
+// at once.
+type Server struct {
+	client  *client.Client
+	cfg     *config.Config
+	methods map[string]Handler
+
+	// Notify enables device and event notifications
+	Notify bool
+	// Inventory, when set and loaded, is used to look names up instead of
+	// the API
+	Inventory *inventory.Inventory
+}
+
+// stream is the output side of one Serve call
+type stream struct {
+	mu  sync.Mutex
+	out *bufio.Writer
+}
+
+// New creates a server that runs requests with c and finds scenes in cfg
+func New(c *client.Client, cfg *config.Config) *Server {
+	s := &Server{client: c, cfg: cfg, Notify: true}
+	s.methods = map[string]Handler{
+		"listViewports":  s.listViewports,
+		"listLiveviews":  s.listLiveviews,
+		"listCameras":    s.listCameras,
+		"switchViewport": s.switchViewport,
+		"movePTZ":        s.movePTZ,
+		"applyScene":     s.applyScene,
+	}
+	return s
+}
+
+// Handle adds or replaces a method
+func (s *Server) Handle(method string, h Handler) {
+	s.methods[method] = h
+}
+
+// Serve reads one request (or batch) per line from in and writes responses
+// and notifications to out, one per line. Requests run concurrently, so
+// responses may arrive out of order. It returns when in is closed and
+// every request has been answered, or when ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	log := logger.Get()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	st := &stream{out: bufio.NewWriter(out)}
+
+	var wg sync.WaitGroup
+	if s.Notify {
+		wg.Add(2)
+		go func() { defer wg.Done(); s.notifyDevices(ctx, st) }()
+		go func() { defer wg.Done(); s.notifyEvents(ctx, st) }()
+	}
+
+	var requests sync.WaitGroup
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64<<10), maxMessageSize)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lines <- append([]byte(nil), line...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var err error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case err = <-readErr:
+			break loop
+		case line := <-lines:
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				if reply := s.handleMessage(ctx, line); reply != nil {
+					st.write(reply)
+				}
+			}()
+		}
+	}
+
+	requests.Wait()
+	cancel()
+	wg.Wait()
+
+	if err != nil {
+		return fmt.Errorf("failed to read requests: %w", err)
+	}
+	log.Debug("RPC input closed")
+	return nil
+}
+
+// handleMessage handles a single request or a batch and returns the reply,
+// or nil when there is nothing to send back
+func (s *Server) handleMessage(ctx context.Context, data []byte) any {
+	if data[0] != '[' {
+		if resp := s.handleRequest(ctx, data); resp != nil {
+			return resp
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return &Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}}
+	}
+	if len(batch) == 0 {
+		return &Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeInvalidRequest, Message: "empty batch"}}
+	}
+
+	responses := make([]*Response, len(batch))
+	var wg sync.WaitGroup
+	for i, item := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = s.handleRequest(ctx, item)
+		}()
+	}
+	wg.Wait()
+
+	var replies []*Response
+	for _, resp := range responses {
+		if resp != nil {
+			replies = append(replies, resp)
+		}
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+	return replies
+}
+
+// handleRequest runs one request and returns its response, or nil for a
+// notification
+func (s *Server) handleRequest(ctx context.Context, data []byte) *Response {
+	log := logger.Get()
+
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return &Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}}
+		}
+		return &Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeInvalidRequest, Message: err.Error()}}
+	}
+
+	id := req.ID
+	if len(id) == 0 {
+		id = nil
+	}
+	if req.JSONRPC != Version || req.Method == "" {
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		return &Response{JSONRPC: Version, ID: id, Error: &Error{Code: CodeInvalidRequest, Message: `jsonrpc must be "2.0" and method is required`}}
+	}
+
+	var result any
+	var err error
+	if h, ok := s.methods[req.Method]; ok {
+		result, err = h(ctx, req.Params)
+	} else {
+		err = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+	log.Infow("Handled RPC request", "method", req.Method, "error", err)
+
+	// Notifications get no response, even on error
+	if id == nil {
+		return nil
+	}
+
+	resp := &Response{JSONRPC: Version, ID: id}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+
+	resp.Result = result
+	return resp
+}
+
+// write sends one message as a line of JSON
+func (st *stream) write(v any) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Get().Warnw("Failed to encode RPC message", "error", err)
+		return
+	}
+	st.out.Write(data)
+	st.out.WriteByte('\n')
+	st.out.Flush()
+}
+
+// notify sends a notification
+func (st *stream) notify(method string, params any) {
+	st.write(&Notification{JSONRPC: Version, Method: method, Params: params})
+}
+
+// notifyDevices forwards device changes until ctx is cancelled
+func (s *Server) notifyDevices(ctx context.Context, st *stream) {
+	sub := s.client.SubscribeDevices(ctx)
+	errs := sub.Errors
+	for {
+		select {
+		case change, ok := <-sub.Changes:
+			if !ok {
+				return
+			}
+			st.notify(NotifyDevice, change)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			logger.Get().Warnw("Devices stream error", "error", err)
+		}
+	}
+}
+
+// notifyEvents forwards events until ctx is cancelled
+func (s *Server) notifyEvents(ctx context.Context, st *stream) {
+	sub := s.client.SubscribeEvents(ctx)
+	errs := sub.Errors
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			st.notify(NotifyEvent, event)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			logger.Get().Warnw("Events stream error", "error", err)
+		}
+	}
+}
+
+// decodeParams decodes by-name params, rejecting unknown fields
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return invalidParams(errors.New("params are required"))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return invalidParams(fmt.Errorf("invalid params: %w", err))
+	}
+	return nil
+}
+
+// Viewport is a viewport with its liveview name
+type Viewport struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Liveview   string `json:"liveview"`
+	LiveviewID string `json:"liveviewId"`
+}
+
+func (s *Server) listViewports(ctx context.Context, params json.RawMessage) (any, error) {
+	viewports, err := s.client.ListViewports()
+	if err != nil {
+		return nil, err
+	}
+
+	liveviews, err := s.client.ListCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(liveviews))
+	for _, lv := range liveviews {
+		names[lv.ID] = lv.Name
+	}
+
+	result := make([]Viewport, 0, len(viewports))
+	for _, vp := range viewports {
+		result = append(result, Viewport{ID: vp.ID, Name: vp.Name, Liveview: names[vp.Liveview], LiveviewID: vp.Liveview})
+	}
+	return result, nil
+}
+
+func (s *Server) listLiveviews(ctx context.Context, params json.RawMessage) (any, error) {
+	liveviews, err := s.client.ListCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	if liveviews == nil {
+		liveviews = []client.Liveview{}
+	}
+	return liveviews, nil
+}
+
+func (s *Server) listCameras(ctx context.Context, params json.RawMessage) (any, error) {
+	cameras, err := s.client.ListPTZCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	if cameras == nil {
+		cameras = []client.PTZCamera{}
+	}
+	return cameras, nil
+}
+
+// switchParams are the params of switchViewport
+type switchParams struct {
+	Viewport string `json:"viewport"`
+	Liveview string `json:"liveview"`
+}
+
+func (s *Server) switchViewport(ctx context.Context, params json.RawMessage) (any, error) {
+	var p switchParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Viewport == "" || p.Liveview == "" {
+		return nil, invalidParams(errors.New("viewport and liveview are required"))
+	}
+
+	return s.Run(ctx, []actions.Action{actions.Switch(p.Viewport, p.Liveview)})
+}
+
+// ptzParams are the params of movePTZ. The preset may be a number or a
+// string such as "home".
+type ptzParams struct {
+	Camera string          `json:"camera"`
+	Preset json.RawMessage `json:"preset"`
+}
+
+func (s *Server) movePTZ(ctx context.Context, params json.RawMessage) (any, error) {
+	var p ptzParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Camera == "" || len(p.Preset) == 0 {
+		return nil, invalidParams(errors.New("camera and preset are required"))
+	}
+
+	presetArg := string(p.Preset)
+	if unquoted, err := strconv.Unquote(presetArg); err == nil {
+		presetArg = unquoted
+	}
+
+	preset, err := actions.ParsePreset(presetArg)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+
+	return s.Run(ctx, []actions.Action{actions.PTZ(p.Camera, preset)})
+}
+
+// sceneParams are the params of applyScene
+type sceneParams struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) applyScene(ctx context.Context, params json.RawMessage) (any, error) {
+	var p sceneParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	scene, err := s.cfg.Scene(p.Name)
+	if err != nil {
+		return nil, &Error{Code: CodeNotFound, Message: err.Error()}
+	}
+
+	acts, err := actions.FromScene(*scene)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+
+	return s.Run(ctx, acts)
+}
+
+// ActionResult reports the outcome of a switch, PTZ move or scene
+type ActionResult struct {
+	Success bool             `json:"success"`
+	DryRun  bool             `json:"dryRun,omitempty"`
+	Results []actions.Result `json:"results"`
+}
+
+// Run resolves and runs actions. Unknown names are reported as not found;
+// failed actions as an error carrying every result.
+func (s *Server) Run(ctx context.Context, acts []actions.Action) (*ActionResult, error) {
+	targets, err := s.resolver().Resolve(acts)
+	if err != nil && s.Inventory != nil {
+		// Liveviews are not streamed, so a new one may be missing
+		targets, err = actions.NewResolver(s.client).Resolve(acts)
+	}
+	if err != nil {
+		return nil, &Error{Code: CodeNotFound, Message: err.Error()}
+	}
+
+	results := actions.Execute(ctx, s.client, targets, actions.DefaultWorkers)
+	result := ActionResult{Success: true, DryRun: s.client.DryRun, Results: results}
+
+	if failed := actions.Failed(results); failed > 0 {
+		result.Success = false
+		return nil, &Error{
+			Code:    CodeActionFailed,
+			Message: fmt.Sprintf("%d of %d actions failed", failed, len(results)),
+			Data:    result,
+		}
+	}
+
+	return &result, nil
+}
+
+// resolver looks names up in the inventory once it is loaded, and with the
+// API otherwise
+func (s *Server) resolver() *actions.Resolver {
+	if s.Inventory == nil || !s.Inventory.Loaded() {
+		return actions.NewResolver(s.client)
+	}
+	snap := s.Inventory.Snapshot()
+	return actions.NewCachedResolver(s.client, snap.Viewers, snap.Liveviews, snap.Cameras, snap.Lights)
+}
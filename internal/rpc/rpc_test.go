@@ -0,0 +1,244 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+)
+
+// fakeProtect serves lookups, fails PTZ moves for cam2, records changes and
+// pushes messages over the devices and events WebSockets
+type fakeProtect struct {
+	mu      sync.Mutex
+	changes []string
+	devices chan string
+	events  chan string
+}
+
+func newFakeProtect(t *testing.T) (*fakeProtect, *client.Client) {
+	t.Helper()
+
+	f := &fakeProtect{devices: make(chan string, 4), events: make(chan string, 4)}
+	upgrader := websocket.Upgrader{}
+	stream := func(w http.ResponseWriter, r *http.Request, msgs chan string) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			select {
+			case msg := <-msgs:
+				conn.WriteMessage(websocket.TextMessage, []byte(msg))
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/proxy/protect/integration/v1/subscribe/devices":
+			stream(w, r, f.devices)
+		case r.URL.Path == "/proxy/protect/integration/v1/subscribe/events":
+			stream(w, r, f.events)
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/viewers":
+			w.Write([]byte(`[{"id":"vp1","name":"Tower","liveview":"lv1"}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/liveviews":
+			w.Write([]byte(`[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/cameras":
+			w.Write([]byte(`[{"id":"cam1","name":"Front Door","modelKey":"camera"},{"id":"cam2","name":"Garage","modelKey":"camera"}]`))
+		case strings.HasPrefix(r.URL.Path, "/proxy/protect/integration/v1/cameras/cam2/"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			f.mu.Lock()
+			f.changes = append(f.changes, r.Method+" "+r.URL.Path)
+			f.mu.Unlock()
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return f, client.NewClient(server.URL, "test-token")
+}
+
+// response is a decoded reply with the result left raw
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+func TestServe(t *testing.T) {
+	f, c := newFakeProtect(t)
+	cfg := &config.Config{Scenes: []config.Scene{{
+		Name:     "night",
+		Switches: []config.SceneSwitch{{Viewport: "Tower", Liveview: "Driveway"}},
+		PTZ:      []config.ScenePTZ{{Camera: "Front Door", Preset: "home"}},
+	}}}
+
+	s := New(c, cfg)
+	s.Notify = false
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"listViewports"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"switchViewport","params":{"viewport":"Tower","liveview":"Driveway"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"movePTZ","params":{"camera":"Porch","preset":1}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"reboot"}`,
+		`{"jsonrpc":"2.0","method":"movePTZ","params":{"camera":"Front Door","preset":"home"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"movePTZ","params":{"camera":"Garage","preset":2}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"switchViewport","params":{"viewport":"Tower","screen":"x"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"applyScene","params":{"name":"night"}}`,
+		`{"id":8,"method":"listLiveviews"}`,
+		`{not json`,
+		`[{"jsonrpc":"2.0","id":9,"method":"listLiveviews"},{"jsonrpc":"2.0","method":"listCameras"}]`,
+	}, "\n") + "\n"
+
+	out := new(bytes.Buffer)
+	if err := s.Serve(context.Background(), strings.NewReader(input), out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	byID := make(map[string]response)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if strings.HasPrefix(line, "[") {
+			var batch []response
+			if err := json.Unmarshal([]byte(line), &batch); err != nil {
+				t.Fatalf("Invalid batch reply %q: %v", line, err)
+			}
+			if len(batch) != 1 {
+				t.Errorf("Expected one reply in the batch, got %q", line)
+			}
+			for _, r := range batch {
+				byID["batch "+string(r.ID)] = r
+			}
+			continue
+		}
+
+		var r response
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Invalid reply %q: %v", line, err)
+		}
+		if r.JSONRPC != Version {
+			t.Errorf("Reply without jsonrpc 2.0: %q", line)
+		}
+		byID[string(r.ID)] = r
+	}
+
+	if len(byID) != 10 {
+		t.Errorf("Expected 10 replies, got %d: %s", len(byID), out.String())
+	}
+
+	var viewports []Viewport
+	if err := json.Unmarshal(byID["1"].Result, &viewports); err != nil || len(viewports) != 1 || viewports[0].Liveview != "All Cameras" {
+		t.Errorf("Unexpected listViewports result: %s (%v)", byID["1"].Result, err)
+	}
+
+	var switched ActionResult
+	if err := json.Unmarshal(byID[`"two"`].Result, &switched); err != nil || !switched.Success || switched.Results[0].ValueID != "lv2" {
+		t.Errorf("Unexpected switchViewport result: %s (%v)", byID[`"two"`].Result, err)
+	}
+
+	wantErrors := map[string]int{
+		"3":    CodeNotFound,
+		"4":    CodeMethodNotFound,
+		"5":    CodeActionFailed,
+		"6":    CodeInvalidParams,
+		"8":    CodeInvalidRequest,
+		"null": CodeParseError,
+	}
+	for id, code := range wantErrors {
+		if r := byID[id]; r.Error == nil || r.Error.Code != code {
+			t.Errorf("Reply %s: expected error %d, got %+v", id, code, r.Error)
+		}
+	}
+	if byID["5"].Error != nil && byID["5"].Error.Data == nil {
+		t.Error("Expected failed actions to carry their results")
+	}
+
+	var scene ActionResult
+	if err := json.Unmarshal(byID["7"].Result, &scene); err != nil || len(scene.Results) != 2 {
+		t.Errorf("Unexpected applyScene result: %s (%v)", byID["7"].Result, err)
+	}
+	if r := byID["batch 9"]; r.Error != nil || len(r.Result) == 0 {
+		t.Errorf("Unexpected batch reply: %+v", r)
+	}
+
+	// The notification still ran: two switches and two home moves in all
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.changes) != 4 {
+		t.Errorf("Expected 4 changes, got %v", f.changes)
+	}
+}
+
+func TestServeNotifications(t *testing.T) {
+	f, c := newFakeProtect(t)
+	s := New(c, &config.Config{})
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+
+	f.devices <- `{"type":"update","item":{"id":"vp1","modelKey":"viewer","liveview":"lv2"}}`
+	f.events <- `{"type":"add","item":{"id":"ev1","type":"ring","device":"cam1"}}`
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// A resync notification after each connect tells plugins to reload
+	seen := make(map[string]string)
+	timeout := time.After(5 * time.Second)
+	for !strings.Contains(seen[NotifyDevice], `"id":"vp1"`) || seen[NotifyEvent] == "" {
+		select {
+		case line := <-lines:
+			var n struct {
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			if err := json.Unmarshal([]byte(line), &n); err != nil {
+				t.Fatalf("Invalid notification %q: %v", line, err)
+			}
+			seen[n.Method] = string(n.Params)
+		case <-timeout:
+			t.Fatalf("Timed out waiting for notifications, got %v", seen)
+		}
+	}
+
+	if !strings.Contains(seen[NotifyEvent], `"type":"ring"`) {
+		t.Errorf("Unexpected event notification: %s", seen[NotifyEvent])
+	}
+
+	inW.Close()
+	go func() {
+		for range lines {
+		}
+	}()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
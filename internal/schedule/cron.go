@@ -0,0 +1,224 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week
+type Cron struct {
+	expr     string
+	minutes  uint64
+	hours    uint64
+	dom      uint64
+	months   uint64
+	dow      uint64
+	domStar  bool
+	dowStar  bool
+	location *time.Location
+}
+
+// field describes the range and names of a cron field
+type field struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	dowField    = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// macros are the supported @ shorthands
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression evaluated in loc. Fields accept *,
+// numbers, ranges (1-5), lists (1,3,5), steps (*/15, 8-18/2) and month and
+// weekday names; day of week 0 and 7 are both Sunday. As in cron, when both
+// day of month and day of week are restricted, a day matching either runs.
+func ParseCron(expr string, loc *time.Location) (*Cron, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	spec := strings.TrimSpace(expr)
+	if m, ok := macros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression: %q (expected 5 fields)", expr)
+	}
+
+	c := &Cron{expr: expr, location: loc}
+	var err error
+	if c.minutes, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// Sunday may be written as 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return c, nil
+}
+
+// parseField parses one cron field into a bit set
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(s, ",") {
+		rangeArg, stepArg, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepArg)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step: %s", f.name, part)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeArg == "*":
+		case strings.Contains(rangeArg, "-"):
+			loArg, hiArg, _ := strings.Cut(rangeArg, "-")
+			var err error
+			if lo, err = f.value(loArg); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiArg); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range: %s", f.name, rangeArg)
+			}
+		default:
+			v, err := f.value(rangeArg)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a number or name within the field's range
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s: %s (must be between %d and %d)", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression as written
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Location returns the time zone the expression is evaluated in
+func (c *Cron) Location() *time.Location {
+	return c.location
+}
+
+// dayMatches applies cron's rule for combining day of month and day of week
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Matches returns true if the expression matches the minute containing t
+func (c *Cron) Matches(t time.Time) bool {
+	t = t.In(c.location)
+	return c.months&(1<<uint(t.Month())) != 0 && c.dayMatches(t) && c.hours&(1<<uint(t.Hour())) != 0 && c.minutes&(1<<uint(t.Minute())) != 0
+}
+
+// Next returns the first time after t that the expression matches, or the
+// zero time if it never matches (e.g. "0 0 30 2 *")
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.In(c.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.location)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.location)
+		case c.hours&(1<<uint(t.Hour())) == 0:
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.location)
+			if !next.After(t) {
+				// The clocks went back and the next hour repeats
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+			t = next
+		case c.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
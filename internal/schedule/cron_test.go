@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		if _, err := ParseCron(expr, time.UTC); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday 15 January 2025, 10:30 UTC
+	from := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 22 * * *", want: time.Date(2025, 1, 15, 22, 0, 0, 0, time.UTC)},
+		{expr: "0 7 * * mon-fri", want: time.Date(2025, 1, 16, 7, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * sat,sun", want: time.Date(2025, 1, 18, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * 7", want: time.Date(2025, 1, 19, 9, 0, 0, 0, time.UTC)},
+		{expr: "30 8-18/2 * * *", want: time.Date(2025, 1, 15, 12, 30, 0, 0, time.UTC)},
+		{expr: "0 0 1 mar *", want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		// Day of month or day of week: the 20th, or the next Friday
+		{expr: "0 0 20 * fri", want: time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCron(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+
+	c, err := ParseCron("0 22 * * *", loc)
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+
+	// 22:00 in New York is 03:00 UTC the next day in winter
+	got := c.Next(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got.UTC(), want)
+	}
+
+	// 02:30 does not exist on the day the clocks go forward
+	c, err = ParseCron("30 2 * * *", loc)
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	got = c.Next(time.Date(2025, 3, 9, 0, 0, 0, 0, loc))
+	if got.IsZero() || got.Before(time.Date(2025, 3, 9, 0, 0, 0, 0, loc)) {
+		t.Errorf("Expected a run after the clocks change, got %v", got)
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	c, err := ParseCron("* 8-17 * * mon-fri", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{t: time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC), want: true},
+		{t: time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC), want: false},
+		{t: time.Date(2025, 1, 18, 9, 30, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := c.Matches(tt.t); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
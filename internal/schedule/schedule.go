@@ -0,0 +1,271 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Entry is a schedule ready to run
+type Entry struct {
+	Name    string
+	Cron    *Cron
+	Actions []actions.Action
+}
+
+// FromConfig builds entries from the configured schedules. Scenes are
+// expanded and every time expression and time zone is checked; all problems
+// are reported together.
+func FromConfig(cfg *config.Config) ([]Entry, error) {
+	var entries []Entry
+	var errs []error
+
+	for _, s := range cfg.Schedules {
+		entry, err := fromSchedule(cfg, s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", s.Name, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return entries, nil
+}
+
+func fromSchedule(cfg *config.Config, s config.Schedule) (Entry, error) {
+	entry := Entry{Name: s.Name}
+
+	loc := time.Local
+	if s.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return entry, fmt.Errorf("invalid timezone: %s", s.Timezone)
+		}
+	}
+
+	expr := s.Cron
+	if s.At != "" {
+		var err error
+		if expr, err = atToCron(s.At, s.Days); err != nil {
+			return entry, err
+		}
+	}
+
+	cron, err := ParseCron(expr, loc)
+	if err != nil {
+		return entry, err
+	}
+	entry.Cron = cron
+
+	if s.Scene != "" {
+		scene, err := cfg.Scene(s.Scene)
+		if err != nil {
+			return entry, err
+		}
+		acts, err := actions.FromScene(*scene)
+		if err != nil {
+			return entry, err
+		}
+		entry.Actions = append(entry.Actions, acts...)
+	}
+
+	for _, sw := range s.Switches {
+		entry.Actions = append(entry.Actions, actions.Switch(sw.Viewport, sw.Liveview))
+	}
+
+	for _, move := range s.PTZ {
+		preset, err := actions.ParsePreset(move.Preset)
+		if err != nil {
+			return entry, err
+		}
+		entry.Actions = append(entry.Actions, actions.PTZ(move.Camera, preset))
+	}
+
+	return entry, nil
+}
+
+// atToCron converts a time of day ("22:00") and optional days ("mon",
+// "weekdays", "weekends") into a cron expression
+func atToCron(at string, days []string) (string, error) {
+	hourArg, minuteArg, ok := strings.Cut(at, ":")
+	hour, hourErr := strconv.Atoi(hourArg)
+	minute, minuteErr := strconv.Atoi(minuteArg)
+	if !ok || hourErr != nil || minuteErr != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return "", fmt.Errorf("invalid time: %s (expected format: HH:MM)", at)
+	}
+
+	dow, err := DaysToCron(days)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d %d * * %s", minute, hour, dow), nil
+}
+
+// DaysToCron converts day names ("mon", "Monday", "weekdays", "weekends")
+// into a cron day-of-week field, "*" when days is empty
+func DaysToCron(days []string) (string, error) {
+	if len(days) == 0 {
+		return "*", nil
+	}
+
+	var parts []string
+	for _, d := range days {
+		switch strings.ToLower(d) {
+		case "weekdays":
+			parts = append(parts, "mon-fri")
+		case "weekends":
+			parts = append(parts, "sat,sun")
+		default:
+			day, ok := dayName(d)
+			if !ok {
+				return "", fmt.Errorf("invalid day: %s", d)
+			}
+			parts = append(parts, day)
+		}
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+// dayName returns the cron name for a short or full English day name
+func dayName(d string) (string, bool) {
+	for _, name := range []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"} {
+		if strings.EqualFold(d, name) || strings.EqualFold(d, name[:3]) {
+			return name[:3], true
+		}
+	}
+	return "", false
+}
+
+// Upcoming is a future run of a schedule
+type Upcoming struct {
+	Entry Entry
+	Time  time.Time
+}
+
+// Next returns the next n runs across all entries after t, in order
+func Next(entries []Entry, t time.Time, n int) []Upcoming {
+	var runs []Upcoming
+	for _, e := range entries {
+		next := t
+		for i := 0; i < n; i++ {
+			next = e.Cron.Next(next)
+			if next.IsZero() {
+				break
+			}
+			runs = append(runs, Upcoming{Entry: e, Time: next})
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	if len(runs) > n {
+		runs = runs[:n]
+	}
+	return runs
+}
+
+// Options controls how schedules run
+type Options struct {
+	// OnRun, if set, is called after each schedule runs
+	OnRun func(entry Entry, results []actions.Result, err error)
+	// Now and After replace the clock, for tests
+	Now   func() time.Time
+	After func(time.Duration) <-chan time.Time
+}
+
+// Run waits for each schedule to come due and runs its actions, until ctx
+// is cancelled. Names are resolved afresh on every run so that renamed or
+// replaced liveviews are picked up without a restart; a failed run is
+// reported and the daemon carries on.
+func Run(ctx context.Context, c *client.Client, entries []Entry, opts Options) error {
+	log := logger.Get()
+
+	now, after := opts.Now, opts.After
+	if now == nil {
+		now = time.Now
+	}
+	if after == nil {
+		after = time.After
+	}
+
+	if len(entries) == 0 {
+		return fmt.Errorf("no schedules configured")
+	}
+
+	last := now()
+	for {
+		var due []Entry
+		var at time.Time
+		for _, e := range entries {
+			next := e.Cron.Next(last)
+			switch {
+			case next.IsZero():
+			case at.IsZero() || next.Before(at):
+				at, due = next, []Entry{e}
+			case next.Equal(at):
+				due = append(due, e)
+			}
+		}
+
+		if at.IsZero() {
+			return fmt.Errorf("no schedule will run again")
+		}
+
+		log.Infow("Waiting for next schedule", "at", at, "schedules", len(due))
+
+		// Check the wall clock at least once a minute, so a suspended host or
+		// a clock change does not leave the daemon sleeping past a run
+		for d := at.Sub(now()); d > 0; d = at.Sub(now()) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-after(min(d, time.Minute)):
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for _, e := range due {
+			results, err := runEntry(ctx, c, e)
+			if err != nil {
+				log.Warnw("Schedule failed", "schedule", e.Name, "error", err)
+			}
+			if opts.OnRun != nil {
+				opts.OnRun(e, results, err)
+			}
+		}
+
+		last = at
+	}
+}
+
+// runEntry resolves and runs the actions of one schedule
+func runEntry(ctx context.Context, c *client.Client, e Entry) ([]actions.Result, error) {
+	targets, err := actions.NewResolver(c).Resolve(e.Actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve schedule %s: %w", e.Name, err)
+	}
+
+	results := actions.Execute(ctx, c, targets, actions.DefaultWorkers)
+	if failed := actions.Failed(results); failed > 0 {
+		return results, fmt.Errorf("schedule %s: %d of %d actions failed", e.Name, failed, len(results))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,159 @@
+package schedule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+)
+
+func TestAtToCron(t *testing.T) {
+	tests := []struct {
+		at      string
+		days    []string
+		want    string
+		wantErr bool
+	}{
+		{at: "22:00", want: "0 22 * * *"},
+		{at: "7:05", days: []string{"Monday", "wed"}, want: "5 7 * * mon,wed"},
+		{at: "06:30", days: []string{"weekdays"}, want: "30 6 * * mon-fri"},
+		{at: "09:00", days: []string{"weekends"}, want: "0 9 * * sat,sun"},
+		{at: "24:00", wantErr: true},
+		{at: "noon", wantErr: true},
+		{at: "08:00", days: []string{"someday"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			got, err := atToCron(tt.at, tt.days)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("atToCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("atToCron() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		Scenes: []config.Scene{{Name: "night", PTZ: []config.ScenePTZ{{Camera: "Front Door", Preset: "home"}}}},
+		Schedules: []config.Schedule{
+			{Name: "evening", At: "22:00", Days: []string{"weekdays"}, Timezone: "UTC", Scene: "night", Switches: []config.SceneSwitch{{Viewport: "Tower", Liveview: "Driveway"}}},
+			{Name: "morning", Cron: "0 7 * * *", PTZ: []config.ScenePTZ{{Camera: "Garage", Preset: "2"}}},
+		},
+	}
+
+	entries, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig() error = %v", err)
+	}
+
+	if len(entries) != 2 || entries[0].Cron.String() != "0 22 * * mon-fri" || entries[0].Cron.Location() != time.UTC {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+
+	want := []actions.Action{actions.PTZ("Front Door", -1), actions.Switch("Tower", "Driveway")}
+	if !reflect.DeepEqual(entries[0].Actions, want) {
+		t.Errorf("Actions = %+v, want %+v", entries[0].Actions, want)
+	}
+
+	cfg.Schedules = []config.Schedule{
+		{Name: "bad-cron", Cron: "0 25 * * *", PTZ: cfg.Schedules[1].PTZ},
+		{Name: "bad-zone", At: "07:00", Timezone: "Mars/Olympus", PTZ: cfg.Schedules[1].PTZ},
+	}
+	_, err = FromConfig(cfg)
+	if err == nil || !strings.Contains(err.Error(), "schedule bad-cron: invalid hour") || !strings.Contains(err.Error(), "schedule bad-zone: invalid timezone") {
+		t.Errorf("Expected both errors, got %v", err)
+	}
+}
+
+func TestNext(t *testing.T) {
+	hourly, _ := ParseCron("0 * * * *", time.UTC)
+	daily, _ := ParseCron("30 12 * * *", time.UTC)
+	entries := []Entry{{Name: "hourly", Cron: hourly}, {Name: "daily", Cron: daily}}
+
+	runs := Next(entries, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC), 3)
+
+	var got []string
+	for _, r := range runs {
+		got = append(got, r.Entry.Name+"@"+r.Time.Format("15:04"))
+	}
+	if want := "hourly@12:00,daily@12:30,hourly@13:00"; strings.Join(got, ",") != want {
+		t.Errorf("Next() = %v, want %s", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/cameras":
+			w.Write([]byte(`[{"id":"cam1","name":"Front Door"}]`))
+		default:
+			mu.Lock()
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	c := client.NewClient(server.URL, "test-token")
+
+	every, _ := ParseCron("*/10 * * * *", time.UTC)
+	missing, _ := ParseCron("*/20 * * * *", time.UTC)
+	entries := []Entry{
+		{Name: "patrol", Cron: every, Actions: []actions.Action{actions.PTZ("Front Door", 1)}},
+		{Name: "broken", Cron: missing, Actions: []actions.Action{actions.PTZ("Attic", 1)}},
+	}
+
+	// A fake clock that jumps forward by however long Run waits
+	clock := time.Date(2025, 1, 15, 10, 5, 0, 0, time.UTC)
+	after := func(d time.Duration) <-chan time.Time {
+		clock = clock.Add(d)
+		ch := make(chan time.Time, 1)
+		ch <- clock
+		return ch
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs []string
+	err := Run(ctx, c, entries, Options{
+		Now:   func() time.Time { return clock },
+		After: after,
+		OnRun: func(e Entry, results []actions.Result, err error) {
+			status := "ok"
+			if err != nil {
+				status = "failed"
+			}
+			runs = append(runs, clock.Format("15:04")+" "+e.Name+" "+status)
+			if len(runs) == 4 {
+				cancel()
+			}
+		},
+	})
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	want := []string{"10:10 patrol ok", "10:20 patrol ok", "10:20 broken failed", "10:30 patrol ok"}
+	if !reflect.DeepEqual(runs, want) {
+		t.Errorf("Runs = %v, want %v", runs, want)
+	}
+
+	if len(requests) != 3 {
+		t.Errorf("Expected 3 PTZ moves, got %v", requests)
+	}
+}
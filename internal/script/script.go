@@ -0,0 +1,408 @@
+package script
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Op is a script statement type
+type Op string
+
+// Statement types
+const (
+	OpSwitch Op = "switch"
+	OpPTZ    Op = "ptz"
+	OpSleep  Op = "sleep"
+	OpWait   Op = "wait"
+)
+
+// Statement is a single parsed script line
+type Statement struct {
+	Line       int
+	Op         Op
+	Action     actions.Action
+	Duration   time.Duration
+	Background bool
+}
+
+// Parse reads a script. Each line holds one statement:
+//
+//	switch <viewport> <liveview>   (or switch <viewport>:<liveview>)
+//	ptz <camera> <preset|home>     (or ptz <camera>:<preset>)
+//	sleep <duration>               (e.g. 500ms, 5s, or plain seconds)
+//	wait                           (wait for actions started with &)
+//	set <name> <value>             (referenced later as $name or ${name})
+//
+// Arguments may be in single or double quotes. Variables are expanded outside
+// single quotes, a trailing & runs a switch or ptz in the background, and
+// # starts a comment. vars holds variables defined before the script runs.
+// All syntax errors are reported together.
+func Parse(r io.Reader, vars map[string]string) ([]Statement, error) {
+	scope := make(map[string]string, len(vars))
+	for k, v := range vars {
+		scope[k] = v
+	}
+
+	var statements []Statement
+	var errs []error
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		words, err := split(scanner.Text(), scope)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		if words[0] == "set" {
+			if len(words) != 3 || !validName(words[1]) {
+				errs = append(errs, fmt.Errorf("line %d: expected: set <name> <value>", line))
+				continue
+			}
+			scope[words[1]] = words[2]
+			continue
+		}
+
+		stmt, err := parseStatement(words)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		stmt.Line = line
+		statements = append(statements, stmt)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return statements, nil
+}
+
+// parseStatement builds a statement from the words of a line
+func parseStatement(words []string) (Statement, error) {
+	stmt := Statement{Op: Op(words[0])}
+	args := words[1:]
+
+	if len(args) > 0 && args[len(args)-1] == "&" {
+		if stmt.Op != OpSwitch && stmt.Op != OpPTZ {
+			return stmt, fmt.Errorf("only switch and ptz can run in the background")
+		}
+		stmt.Background = true
+		args = args[:len(args)-1]
+	}
+
+	switch stmt.Op {
+	case OpSwitch:
+		args = splitPair(args)
+		if len(args) != 2 || args[0] == "" || args[1] == "" {
+			return stmt, fmt.Errorf("expected: switch <viewport> <liveview>")
+		}
+		stmt.Action = actions.Switch(args[0], args[1])
+
+	case OpPTZ:
+		args = splitPair(args)
+		if len(args) != 2 || args[0] == "" {
+			return stmt, fmt.Errorf("expected: ptz <camera> <preset>")
+		}
+		preset, err := actions.ParsePreset(args[1])
+		if err != nil {
+			return stmt, err
+		}
+		stmt.Action = actions.PTZ(args[0], preset)
+
+	case OpSleep:
+		if len(args) != 1 {
+			return stmt, fmt.Errorf("expected: sleep <duration>")
+		}
+		d, err := parseDuration(args[0])
+		if err != nil {
+			return stmt, err
+		}
+		stmt.Duration = d
+
+	case OpWait:
+		if len(args) != 0 {
+			return stmt, fmt.Errorf("expected: wait")
+		}
+
+	default:
+		return stmt, fmt.Errorf("unknown statement: %s", words[0])
+	}
+
+	return stmt, nil
+}
+
+// splitPair accepts the single-argument <a>:<b> form
+func splitPair(args []string) []string {
+	if len(args) == 1 {
+		if a, b, ok := strings.Cut(args[0], ":"); ok {
+			return []string{strings.TrimSpace(a), strings.TrimSpace(b)}
+		}
+	}
+	return args
+}
+
+// parseDuration accepts Go durations and plain seconds
+func parseDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return d, nil
+}
+
+// split breaks a line into words, handling quotes, escapes, variables and
+// comments
+func split(line string, vars map[string]string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+
+		case r == '$':
+			value, n, err := expand(runes[i+1:], vars)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(value)
+			i += n
+			inWord = true
+
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+
+		case r == '#' && !inWord:
+			i = len(runes)
+
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// expand reads a variable reference ($name or ${name}) from the runes
+// following a $, returning its value and the number of runes consumed
+func expand(runes []rune, vars map[string]string) (string, int, error) {
+	if len(runes) > 0 && runes[0] == '{' {
+		end := slices.Index(runes, '}')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated ${")
+		}
+		name := string(runes[1:end])
+		value, ok := vars[name]
+		if !ok {
+			return "", 0, fmt.Errorf("undefined variable: %s", name)
+		}
+		return value, end + 1, nil
+	}
+
+	n := 0
+	for n < len(runes) && isNameRune(runes[n], n == 0) {
+		n++
+	}
+	if n == 0 {
+		// A lone $ is kept as is
+		return "$", 0, nil
+	}
+
+	name := string(runes[:n])
+	value, ok := vars[name]
+	if !ok {
+		return "", 0, fmt.Errorf("undefined variable: %s", name)
+	}
+	return value, n, nil
+}
+
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isNameRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameRune(r rune, first bool) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (!first && r >= '0' && r <= '9')
+}
+
+// Options controls how a script runs
+type Options struct {
+	// ContinueOnError keeps going after a failed action instead of stopping
+	ContinueOnError bool
+	// OnResult, if set, is called as each action finishes
+	OnResult func(stmt Statement, result actions.Result)
+}
+
+// Run resolves every switch and ptz target up front, so names are looked up
+// once and a typo stops the script before anything changes, then executes
+// the statements in order. Results are returned in statement order.
+func Run(ctx context.Context, c *client.Client, statements []Statement, opts Options) ([]actions.Result, error) {
+	log := logger.Get()
+
+	resolver := actions.NewResolver(c)
+	targets := make(map[int]actions.Target)
+	var errs []error
+	for i, stmt := range statements {
+		if stmt.Op != OpSwitch && stmt.Op != OpPTZ {
+			continue
+		}
+		resolved, err := resolver.Resolve([]actions.Action{stmt.Action})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", stmt.Line, err))
+			continue
+		}
+		targets[i] = resolved[0]
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make([]*actions.Result, len(statements))
+	var firstErr error
+
+	record := func(i int, result actions.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = &result
+		if !result.Success && firstErr == nil {
+			firstErr = fmt.Errorf("line %d: %s", statements[i].Line, result.Error)
+		}
+		if opts.OnResult != nil {
+			opts.OnResult(statements[i], result)
+		}
+	}
+
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil && !opts.ContinueOnError
+	}
+
+	for i, stmt := range statements {
+		if failed() || ctx.Err() != nil {
+			break
+		}
+
+		switch stmt.Op {
+		case OpSleep:
+			log.Debugw("Sleeping", "line", stmt.Line, "duration", stmt.Duration)
+			select {
+			case <-time.After(stmt.Duration):
+			case <-ctx.Done():
+			}
+
+		case OpWait:
+			wg.Wait()
+
+		case OpSwitch, OpPTZ:
+			target := []actions.Target{targets[i]}
+			if stmt.Background {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					record(i, actions.Execute(ctx, c, target, 1)[0])
+				}(i)
+				continue
+			}
+			record(i, actions.Execute(ctx, c, target, 1)[0])
+		}
+	}
+
+	wg.Wait()
+
+	var ordered []actions.Result
+	for _, r := range results {
+		if r != nil {
+			ordered = append(ordered, *r)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return ordered, err
+	}
+
+	if n := actions.Failed(ordered); n > 0 {
+		if opts.ContinueOnError {
+			return ordered, fmt.Errorf("%d of %d actions failed", n, len(ordered))
+		}
+		return ordered, firstErr
+	}
+
+	return ordered, nil
+}
@@ -0,0 +1,209 @@
+package script
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newTestServer serves lookups and fails moves for cam2
+func newTestServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Lobby Screen"},{"id":"vp2","name":"Tower"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Garage"}]`)
+	server.Fail("cameras/cam2/", http.StatusInternalServerError)
+	return server
+}
+
+func TestSplit(t *testing.T) {
+	vars := map[string]string{"VP": "Lobby Screen", "n": "5", "CAMERA_ÉTÉ": "Porch"}
+
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "", want: nil},
+		{line: "   # only a comment", want: nil},
+		{line: `switch "Lobby Screen" Driveway # trailing comment`, want: []string{"switch", "Lobby Screen", "Driveway"}},
+		{line: `switch $VP 'All Cameras'`, want: []string{"switch", "Lobby Screen", "All Cameras"}},
+		{line: `ptz Front\ Door ${n}`, want: []string{"ptz", "Front Door", "5"}},
+		{line: `switch '$VP' "x#y"`, want: []string{"switch", "$VP", "x#y"}},
+		{line: `set empty ""`, want: []string{"set", "empty", ""}},
+		{line: `ptz ${CAMERA_ÉTÉ} home`, want: []string{"ptz", "Porch", "home"}},
+		{line: `switch Café ${VP}`, want: []string{"switch", "Café", "Lobby Screen"}},
+		{line: `switch $MISSING Driveway`, wantErr: true},
+		{line: `switch "Lobby Driveway`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := split(tt.line, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("split() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `# Morning routine
+set LOBBY "Lobby Screen"
+switch $LOBBY "All Cameras"
+switch Tower:Driveway &
+ptz "Front Door" home
+sleep 1.5
+sleep 250ms
+wait
+`
+
+	statements, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(statements) != 6 {
+		t.Fatalf("Expected 6 statements, got %d: %+v", len(statements), statements)
+	}
+
+	if statements[0].Line != 3 || statements[0].Action != actions.Switch("Lobby Screen", "All Cameras") {
+		t.Errorf("Unexpected first statement: %+v", statements[0])
+	}
+
+	if !statements[1].Background || statements[1].Action != actions.Switch("Tower", "Driveway") {
+		t.Errorf("Unexpected background statement: %+v", statements[1])
+	}
+
+	if statements[2].Action != actions.PTZ("Front Door", -1) {
+		t.Errorf("Unexpected ptz statement: %+v", statements[2])
+	}
+
+	if statements[3].Duration != 1500*time.Millisecond || statements[4].Duration != 250*time.Millisecond {
+		t.Errorf("Unexpected sleep durations: %v, %v", statements[3].Duration, statements[4].Duration)
+	}
+
+	if statements[5].Op != OpWait {
+		t.Errorf("Expected wait statement, got %+v", statements[5])
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	statements, err := Parse(strings.NewReader("ptz $CAM $PRESET\n"), map[string]string{"CAM": "Garage", "PRESET": "4"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if statements[0].Action != actions.PTZ("Garage", 4) {
+		t.Errorf("Unexpected statement: %+v", statements[0])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	src := `switch OnlyOne
+ptz "Front Door" up
+sleep forever
+wait now
+dance
+sleep 1 &
+`
+
+	_, err := Parse(strings.NewReader(src), nil)
+	if err == nil {
+		t.Fatal("Expected parse errors")
+	}
+
+	for _, want := range []string{"line 1:", "line 2:", "line 3:", "line 4:", "line 5: unknown statement: dance", "line 6:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain '%s', got '%v'", want, err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	src := `switch "Lobby Screen" Driveway &
+switch Tower "All Cameras" &
+wait
+ptz "Front Door" 2
+`
+	statements, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var lines []int
+	results, err := Run(context.Background(), c, statements, Options{
+		OnResult: func(stmt Statement, r actions.Result) { lines = append(lines, stmt.Line) },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Viewers, liveviews and cameras are each fetched once
+	requests := server.Requests()
+	if len(results) != 3 || server.Lookups() != 3 || len(requests) != 3 || len(lines) != 3 {
+		t.Fatalf("Expected 3 lookups and 3 actions, got results %+v, requests %v", results, requests)
+	}
+
+	if requests[2] != "POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/2" {
+		t.Errorf("Expected PTZ move after wait, got %v", requests)
+	}
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	src := "ptz Garage 1\nswitch Tower Driveway\n"
+	statements, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	results, err := Run(context.Background(), c, statements, Options{})
+	if err == nil || !strings.Contains(err.Error(), "line 1:") {
+		t.Fatalf("Expected line 1 error, got %v", err)
+	}
+	requests := server.Requests()
+	if len(results) != 1 || requests[len(requests)-1] != "POST /proxy/protect/integration/v1/cameras/cam2/ptz/goto/1" {
+		t.Errorf("Expected script to stop after the failure, got %+v", results)
+	}
+
+	results, err = Run(context.Background(), c, statements, Options{ContinueOnError: true})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 actions failed") {
+		t.Fatalf("Expected summary error, got %v", err)
+	}
+	if len(results) != 2 || !results[1].Success {
+		t.Errorf("Expected script to continue after the failure, got %+v", results)
+	}
+}
+
+func TestRunResolvesFirst(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	src := "switch Tower Driveway\nswitch Attic Driveway\n"
+	statements, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if _, err := Run(context.Background(), c, statements, Options{}); err == nil || !strings.Contains(err.Error(), "line 2: viewport not found: Attic") {
+		t.Fatalf("Expected resolution error, got %v", err)
+	}
+
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("Expected nothing to run, got %v", requests)
+	}
+}
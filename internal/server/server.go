@@ -0,0 +1,252 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// maxBodySize limits request bodies; every request is a few names
+const maxBodySize = 64 << 10
+
+// Server is the REST control API
+type Server struct {
+	client *client.Client
+	cfg    *config.Config
+	tokens [][]byte
+}
+
+// New creates a server that accepts any of the bearer tokens
+func New(c *client.Client, cfg *config.Config, tokens []string) (*Server, error) {
+	s := &Server{client: c, cfg: cfg}
+	for _, t := range tokens {
+		if t = strings.TrimSpace(t); t != "" {
+			s.tokens = append(s.tokens, []byte(t))
+		}
+	}
+
+	if len(s.tokens) == 0 {
+		return nil, fmt.Errorf("at least one bearer token is required")
+	}
+
+	return s, nil
+}
+
+// Handler returns the routes, all behind bearer token authentication
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /viewports", s.listViewports)
+	mux.HandleFunc("GET /liveviews", s.listLiveviews)
+	mux.HandleFunc("POST /switch", s.switchViewport)
+	mux.HandleFunc("POST /ptz", s.movePTZ)
+	mux.HandleFunc("POST /scene/{name}", s.applyScene)
+
+	return s.authenticate(mux)
+}
+
+// authenticate rejects requests without a valid bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !s.validToken(token) {
+			logger.Get().Warnw("Rejected request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="protect"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken compares against every token in constant time
+func (s *Server) validToken(token string) bool {
+	valid := false
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), t) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// viewportResponse is a viewport with its liveview name
+type viewportResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Liveview   string `json:"liveview"`
+	LiveviewID string `json:"liveviewId"`
+}
+
+func (s *Server) listViewports(w http.ResponseWriter, r *http.Request) {
+	viewports, err := s.client.ListViewports()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	liveviews, err := s.client.ListCameras()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	names := make(map[string]string, len(liveviews))
+	for _, lv := range liveviews {
+		names[lv.ID] = lv.Name
+	}
+
+	resp := make([]viewportResponse, 0, len(viewports))
+	for _, vp := range viewports {
+		resp = append(resp, viewportResponse{ID: vp.ID, Name: vp.Name, Liveview: names[vp.Liveview], LiveviewID: vp.Liveview})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) listLiveviews(w http.ResponseWriter, r *http.Request) {
+	liveviews, err := s.client.ListCameras()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	if liveviews == nil {
+		liveviews = []client.Liveview{}
+	}
+	writeJSON(w, http.StatusOK, liveviews)
+}
+
+// switchRequest is the body of POST /switch
+type switchRequest struct {
+	Viewport string `json:"viewport"`
+	Liveview string `json:"liveview"`
+}
+
+func (s *Server) switchViewport(w http.ResponseWriter, r *http.Request) {
+	var req switchRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Viewport == "" || req.Liveview == "" {
+		writeError(w, http.StatusBadRequest, errors.New("viewport and liveview are required"))
+		return
+	}
+
+	s.run(w, r, []actions.Action{actions.Switch(req.Viewport, req.Liveview)})
+}
+
+// ptzRequest is the body of POST /ptz. The preset may be a number or a
+// string such as "home".
+type ptzRequest struct {
+	Camera string          `json:"camera"`
+	Preset json.RawMessage `json:"preset"`
+}
+
+func (s *Server) movePTZ(w http.ResponseWriter, r *http.Request) {
+	var req ptzRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Camera == "" || len(req.Preset) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("camera and preset are required"))
+		return
+	}
+
+	presetArg := string(req.Preset)
+	if unquoted, err := strconv.Unquote(presetArg); err == nil {
+		presetArg = unquoted
+	}
+
+	preset, err := actions.ParsePreset(presetArg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.run(w, r, []actions.Action{actions.PTZ(req.Camera, preset)})
+}
+
+func (s *Server) applyScene(w http.ResponseWriter, r *http.Request) {
+	scene, err := s.cfg.Scene(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	acts, err := actions.FromScene(*scene)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.run(w, r, acts)
+}
+
+// actionResponse reports the outcome of a POST
+type actionResponse struct {
+	Success bool             `json:"success"`
+	DryRun  bool             `json:"dryRun,omitempty"`
+	Results []actions.Result `json:"results"`
+}
+
+// run resolves and runs actions, reporting each result. Unknown names are
+// the caller's mistake (404); failed actions are Protect's (502).
+func (s *Server) run(w http.ResponseWriter, r *http.Request, acts []actions.Action) {
+	log := logger.Get()
+
+	targets, err := actions.NewResolver(s.client).Resolve(acts)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	results := actions.Execute(r.Context(), s.client, targets, actions.DefaultWorkers)
+	failed := actions.Failed(results)
+	log.Infow("Handled request", "method", r.Method, "path", r.URL.Path, "actions", len(results), "failed", failed)
+
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusBadGateway
+	}
+
+	writeJSON(w, status, actionResponse{Success: failed == 0, DryRun: s.client.DryRun, Results: results})
+}
+
+// decodeBody decodes a JSON request body, rejecting unknown fields
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// errorResponse is the body of every error
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newProtect serves viewers, liveviews and cameras and fails moves to
+// preset 3
+func newProtect(t *testing.T) *protecttest.Server {
+	t.Helper()
+
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"}]`)
+	server.Fail("cameras/cam1/ptz/goto/3", http.StatusInternalServerError)
+	return server
+}
+
+func TestNewRequiresToken(t *testing.T) {
+	if _, err := New(nil, &config.Config{}, []string{" "}); err == nil {
+		t.Error("Expected an error without tokens")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	protect := newProtect(t)
+	cfg := &config.Config{Scenes: []config.Scene{{Name: "Night", Switches: []config.SceneSwitch{{Viewport: "Tower", Liveview: "Driveway"}}, PTZ: []config.ScenePTZ{{Camera: "Front Door", Preset: "home"}}}}}
+
+	s, err := New(protect.APIClient(), cfg, []string{"one", "two"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	handler := s.Handler()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "no token", method: "GET", path: "/viewports", wantStatus: http.StatusUnauthorized, wantBody: `"error":"missing or invalid bearer token"`},
+		{name: "wrong token", method: "GET", path: "/viewports", token: "three", wantStatus: http.StatusUnauthorized},
+		{name: "viewports", method: "GET", path: "/viewports", token: "one", wantStatus: http.StatusOK, wantBody: `[{"id":"vp1","name":"Tower","liveview":"All Cameras","liveviewId":"lv1"}]`},
+		{name: "liveviews", method: "GET", path: "/liveviews", token: "two", wantStatus: http.StatusOK, wantBody: `{"id":"lv2","name":"Driveway"}`},
+		{name: "switch", method: "POST", path: "/switch", token: "one", body: `{"viewport":"Tower","liveview":"Driveway"}`, wantStatus: http.StatusOK, wantBody: `"success":true`},
+		{name: "switch unknown liveview", method: "POST", path: "/switch", token: "one", body: `{"viewport":"Tower","liveview":"Attic"}`, wantStatus: http.StatusNotFound, wantBody: "liveview not found: Attic"},
+		{name: "switch missing field", method: "POST", path: "/switch", token: "one", body: `{"viewport":"Tower"}`, wantStatus: http.StatusBadRequest},
+		{name: "switch bad JSON", method: "POST", path: "/switch", token: "one", body: `{"viewport":`, wantStatus: http.StatusBadRequest, wantBody: "invalid request body"},
+		{name: "ptz number", method: "POST", path: "/ptz", token: "one", body: `{"camera":"Front Door","preset":2}`, wantStatus: http.StatusOK},
+		{name: "ptz home", method: "POST", path: "/ptz", token: "one", body: `{"camera":"Front Door","preset":"home"}`, wantStatus: http.StatusOK},
+		{name: "ptz bad preset", method: "POST", path: "/ptz", token: "one", body: `{"camera":"Front Door","preset":"up"}`, wantStatus: http.StatusBadRequest},
+		{name: "ptz failure", method: "POST", path: "/ptz", token: "one", body: `{"camera":"Front Door","preset":3}`, wantStatus: http.StatusBadGateway, wantBody: `"success":false`},
+		{name: "scene", method: "POST", path: "/scene/Night", token: "one", wantStatus: http.StatusOK},
+		{name: "unknown scene", method: "POST", path: "/scene/Day", token: "one", wantStatus: http.StatusNotFound, wantBody: "scene not found: Day"},
+		{name: "wrong method", method: "GET", path: "/switch", token: "one", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("Body = %s, want it to contain %s", rec.Body, tt.wantBody)
+			}
+		})
+	}
+
+	want := []string{
+		"PATCH /proxy/protect/integration/v1/viewers/vp1",
+		"POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/2",
+		"POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/-1",
+		"POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/3",
+		"PATCH /proxy/protect/integration/v1/viewers/vp1",
+		"POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/-1",
+	}
+	got := protect.Requests()
+	if len(got) != len(want) {
+		t.Fatalf("Requests = %v, want %v", got, want)
+	}
+	// The scene's actions run concurrently, so compare the last two as a set
+	if strings.Join(got[:4], ",") != strings.Join(want[:4], ",") || !sameSet(got[4:], want[4:]) {
+		t.Errorf("Requests = %v, want %v", got, want)
+	}
+}
+
+func TestActionResponse(t *testing.T) {
+	protect := newProtect(t)
+	c := protect.APIClient()
+	c.DryRun, c.DryRunOutput = true, new(strings.Builder)
+
+	s, _ := New(c, &config.Config{}, []string{"one"})
+	req := httptest.NewRequest("POST", "/switch", strings.NewReader(`{"viewport":"vp1","liveview":"Driveway"}`))
+	req.Header.Set("Authorization", "Bearer one")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	var resp actionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Expected JSON, got %s: %v", rec.Body, err)
+	}
+	if !resp.Success || !resp.DryRun || len(resp.Results) != 1 || resp.Results[0].Target != "Tower" || resp.Results[0].ValueID != "lv2" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+}
+
+func sameSet(a, b []string) bool {
+	counts := make(map[string]int)
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+	}
+	for _, n := range counts {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/methridge/protect/internal/logger"
+)
+
+// MaxHistory is the number of lines kept in the history file
+const MaxHistory = 1000
+
+// History is the shell's line history, appended to a file as lines are
+// entered so it survives between sessions
+type History struct {
+	path    string
+	entries []string
+}
+
+// DefaultHistoryPath returns the history location in the user state
+// directory ($XDG_STATE_HOME, or ~/.local/state)
+func DefaultHistoryPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find state directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "protect", "shell_history"), nil
+}
+
+// LoadHistory reads the history at path, which need not exist yet. Only
+// the last MaxHistory lines are kept, and the file is rewritten when it has
+// grown past twice that.
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if len(h.entries) > 2*MaxHistory {
+		h.entries = h.entries[len(h.entries)-MaxHistory:]
+		data := strings.Join(h.entries, "\n") + "\n"
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write history: %w", err)
+		}
+	} else if len(h.entries) > MaxHistory {
+		h.entries = h.entries[len(h.entries)-MaxHistory:]
+	}
+
+	return h, nil
+}
+
+// Add records a line, skipping blank lines and repeats of the last one
+func (h *History) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > MaxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if err := h.append(entry); err != nil {
+		logger.Get().Warnw("Failed to save shell history", "error", err)
+	}
+}
+
+// append writes one line to the end of the history file
+func (h *History) append(entry string) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, entry); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Len returns the number of entries
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// At returns an entry, where 0 is the most recent
+func (h *History) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
@@ -0,0 +1,449 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/inventory"
+)
+
+// ErrExit is returned by Exec when the user asks to leave the shell
+var ErrExit = errors.New("exit")
+
+// command is a shell command and the completions for its arguments
+type command struct {
+	usage string
+	help  string
+	run   func(s *Shell, ctx context.Context, args []string) error
+	// args returns the candidates for each argument position
+	args func(snap inventory.Snapshot, i int) []string
+}
+
+// listKinds are the things ls can list
+var listKinds = []string{"viewports", "liveviews", "cameras"}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"switch": {
+			usage: "switch <viewport> <liveview>",
+			help:  "Switch a viewport to a liveview",
+			run:   (*Shell).runSwitch,
+			args: func(snap inventory.Snapshot, i int) []string {
+				switch i {
+				case 0:
+					return viewerNames(snap.Viewers)
+				case 1:
+					return liveviewNames(snap.Liveviews)
+				}
+				return nil
+			},
+		},
+		"ptz": {
+			usage: "ptz <camera> <preset>",
+			help:  "Move a PTZ camera to a preset (0-9, or home)",
+			run:   (*Shell).runPTZ,
+			args: func(snap inventory.Snapshot, i int) []string {
+				switch i {
+				case 0:
+					return cameraNames(snap.Cameras)
+				case 1:
+					return []string{"home", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+				}
+				return nil
+			},
+		},
+		"ls": {
+			usage: "ls <viewports|liveviews|cameras>",
+			help:  "List viewports and their liveview, liveviews or cameras",
+			run:   (*Shell).runList,
+			args: func(snap inventory.Snapshot, i int) []string {
+				if i == 0 {
+					return listKinds
+				}
+				return nil
+			},
+		},
+		"refresh": {
+			usage: "refresh",
+			help:  "Reload names from the console",
+			run:   (*Shell).runRefresh,
+		},
+		"help": {
+			usage: "help",
+			help:  "Show this help",
+			run:   (*Shell).runHelp,
+		},
+		"exit": {
+			usage: "exit",
+			help:  "Leave the shell (or press Ctrl-D)",
+			run:   func(*Shell, context.Context, []string) error { return ErrExit },
+		},
+	}
+}
+
+// aliases are alternative names for commands
+var aliases = map[string]string{
+	"list": "ls",
+	"quit": "exit",
+	"?":    "help",
+}
+
+// Shell runs commands against one client, resolving names from an
+// inventory that is kept current in the background
+type Shell struct {
+	client *client.Client
+	inv    *inventory.Inventory
+	out    io.Writer
+}
+
+// New creates a shell that writes command output to out
+func New(c *client.Client, inv *inventory.Inventory, out io.Writer) *Shell {
+	return &Shell{client: c, inv: inv, out: out}
+}
+
+// Exec parses and runs one line of input. Blank lines and comments are
+// ignored; ErrExit is returned for exit and quit.
+func (s *Shell) Exec(ctx context.Context, line string) error {
+	args, err := Split(line)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || strings.HasPrefix(args[0], "#") {
+		return nil
+	}
+
+	name := args[0]
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command: %s (type 'help' for a list)", args[0])
+	}
+
+	return cmd.run(s, ctx, args[1:])
+}
+
+// resolver resolves names from the inventory, falling back to the API for
+// anything not loaded yet
+func (s *Shell) resolver() *actions.Resolver {
+	if !s.inv.Loaded() {
+		return actions.NewResolver(s.client)
+	}
+	snap := s.inv.Snapshot()
+	return actions.NewCachedResolver(s.client, snap.Viewers, snap.Liveviews, snap.Cameras, snap.Lights)
+}
+
+// run resolves and runs a single action
+func (s *Shell) run(ctx context.Context, a actions.Action) (actions.Result, error) {
+	targets, err := s.resolver().Resolve([]actions.Action{a})
+	if err != nil {
+		return actions.Result{}, err
+	}
+
+	result := actions.Execute(ctx, s.client, targets, 1)[0]
+	if !result.Success {
+		return result, errors.New(result.Error)
+	}
+	return result, nil
+}
+
+func (s *Shell) runSwitch(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: %s", commands["switch"].usage)
+	}
+
+	r, err := s.run(ctx, actions.Switch(args[0], args[1]))
+	if err != nil {
+		return fmt.Errorf("failed to switch viewport: %w", err)
+	}
+
+	if r.DryRun {
+		fmt.Fprintf(s.out, "Dry run: would switch viewport %s to liveview %s\n", r.Target, r.Value)
+		return nil
+	}
+	fmt.Fprintf(s.out, "Switched viewport %s to liveview %s\n", r.Target, r.Value)
+	return nil
+}
+
+func (s *Shell) runPTZ(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: %s", commands["ptz"].usage)
+	}
+
+	preset, err := actions.ParsePreset(args[1])
+	if err != nil {
+		return err
+	}
+
+	r, err := s.run(ctx, actions.PTZ(args[0], preset))
+	if err != nil {
+		return fmt.Errorf("failed to move camera: %w", err)
+	}
+
+	if r.DryRun {
+		fmt.Fprintf(s.out, "Dry run: would move camera %s to %s\n", r.Target, r.Value)
+		return nil
+	}
+	fmt.Fprintf(s.out, "Moved camera %s to %s\n", r.Target, r.Value)
+	return nil
+}
+
+func (s *Shell) runList(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", commands["ls"].usage)
+	}
+
+	if !s.inv.Loaded() {
+		if err := s.inv.Load(s.client); err != nil {
+			return fmt.Errorf("failed to load inventory: %w", err)
+		}
+	}
+	snap := s.inv.Snapshot()
+
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	switch args[0] {
+	case "viewports":
+		fmt.Fprintln(w, "NAME\tCURRENT LIVEVIEW")
+		for _, vp := range snap.Viewers {
+			fmt.Fprintf(w, "%s\t%s\n", vp.Name, snap.LiveviewName(vp.Liveview))
+		}
+	case "liveviews":
+		fmt.Fprintln(w, "NAME")
+		for _, lv := range snap.Liveviews {
+			fmt.Fprintln(w, lv.Name)
+		}
+	case "cameras":
+		fmt.Fprintln(w, "NAME\tSTATE")
+		for _, cam := range snap.Cameras {
+			fmt.Fprintf(w, "%s\t%s\n", cam.Name, cam.State)
+		}
+	default:
+		return fmt.Errorf("invalid list type: %s (use 'viewports', 'liveviews' or 'cameras')", args[0])
+	}
+
+	return nil
+}
+
+func (s *Shell) runRefresh(ctx context.Context, args []string) error {
+	if err := s.inv.Load(s.client); err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	snap := s.inv.Snapshot()
+	fmt.Fprintf(s.out, "Loaded %d viewports, %d liveviews and %d cameras\n", len(snap.Viewers), len(snap.Liveviews), len(snap.Cameras))
+	return nil
+}
+
+func (s *Shell) runHelp(ctx context.Context, args []string) error {
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	for _, name := range commandNames() {
+		fmt.Fprintf(w, "%s\t%s\n", commands[name].usage, commands[name].help)
+	}
+	w.Flush()
+
+	fmt.Fprintln(s.out, "\nQuote names with spaces; Tab completes commands and names.")
+	return nil
+}
+
+// Complete completes the word before pos. It returns the new line and
+// cursor position, and every matching candidate when the word is ambiguous.
+func (s *Shell) Complete(line string, pos int) (string, int, []string) {
+	args, word, start := splitPartial(line[:pos])
+
+	var candidates []string
+	if len(args) == 0 {
+		candidates = commandNames()
+	} else {
+		name := args[0]
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		if cmd, ok := commands[name]; ok && cmd.args != nil {
+			candidates = cmd.args(s.inv.Snapshot(), len(args)-1)
+		}
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+			matches = append(matches, c)
+		}
+	}
+
+	var replacement string
+	switch {
+	case len(matches) == 0:
+		return line, pos, nil
+	case len(matches) == 1:
+		replacement = Quote(matches[0])
+		if !strings.HasPrefix(line[pos:], " ") {
+			replacement += " "
+		}
+	default:
+		prefix := commonPrefix(matches)
+		if len(prefix) <= len(word) {
+			return line, pos, matches
+		}
+		replacement = prefix
+		if Quote(prefix) != prefix {
+			replacement = `"` + prefix
+		}
+	}
+
+	newLine := line[:start] + replacement + line[pos:]
+	return newLine, start + len(replacement), nil
+}
+
+// commandNames returns the command names in alphabetical order
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func viewerNames(viewers []client.Viewer) []string {
+	names := make([]string, 0, len(viewers))
+	for _, v := range viewers {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func liveviewNames(liveviews []client.Liveview) []string {
+	names := make([]string, 0, len(liveviews))
+	for _, lv := range liveviews {
+		names = append(names, lv.Name)
+	}
+	return names
+}
+
+func cameraNames(cameras []client.PTZCamera) []string {
+	names := make([]string, 0, len(cameras))
+	for _, cam := range cameras {
+		names = append(names, cam.Name)
+	}
+	return names
+}
+
+// commonPrefix returns the longest prefix shared by every string
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// Split splits a line into words. Single and double quotes group words
+// with spaces, and a backslash escapes the next character outside single
+// quotes.
+func Split(line string) ([]string, error) {
+	args, word, _, quote := split(line)
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if word != nil {
+		args = append(args, *word)
+	}
+	return args, nil
+}
+
+// splitPartial splits the text before the cursor into the complete words
+// and the word being typed, with the offset where that word starts
+func splitPartial(prefix string) ([]string, string, int) {
+	args, word, start, _ := split(prefix)
+	if word == nil {
+		return args, "", len(prefix)
+	}
+	return args, *word, start
+}
+
+// split returns the complete words, the unfinished last word (nil if the
+// line ends between words), its start offset and any unclosed quote
+func split(line string) ([]string, *string, int, rune) {
+	var args []string
+	var word *strings.Builder
+	start := 0
+	var quote rune
+	escaped := false
+
+	for i, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+			continue
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+			continue
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+			continue
+		}
+
+		if r == ' ' || r == '\t' {
+			if word != nil {
+				args = append(args, word.String())
+				word = nil
+			}
+			continue
+		}
+
+		if word == nil {
+			word = new(strings.Builder)
+			start = i
+		}
+		switch r {
+		case '\'', '"':
+			quote = r
+		case '\\':
+			escaped = true
+		default:
+			word.WriteRune(r)
+		}
+	}
+
+	if word == nil {
+		return args, nil, len(line), quote
+	}
+	w := word.String()
+	return args, &w, start, quote
+}
+
+// Quote quotes a word for the shell if it contains spaces or quotes
+func Quote(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t'\"\\") {
+		return word
+	}
+	return strconv.Quote(word)
+}
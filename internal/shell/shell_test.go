@@ -0,0 +1,188 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newShell returns a shell over a loaded inventory of a fake console
+func newShell(t *testing.T) (*Shell, *protecttest.Server, *bytes.Buffer) {
+	t.Helper()
+
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Lobby","liveview":"lv1"},{"id":"vp2","name":"Front Office","liveview":"lv2"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door","modelKey":"camera","state":"CONNECTED"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"Entrance"},{"id":"lv2","name":"Parking"}]`)
+
+	c := server.APIClient()
+	inv := inventory.New()
+	if err := inv.Load(c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	return New(c, inv, buf), server, buf
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`switch Lobby Entrance`, []string{"switch", "Lobby", "Entrance"}},
+		{`  ptz  "Front Door"   home `, []string{"ptz", "Front Door", "home"}},
+		{`ptz 'Front Door' 5`, []string{"ptz", "Front Door", "5"}},
+		{`switch Front\ Office "say \"hi\""`, []string{"switch", "Front Office", `say "hi"`}},
+		{`switch "" x`, []string{"switch", "", "x"}},
+		{``, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := Split(tt.line)
+		if err != nil {
+			t.Errorf("Split(%q) error = %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	if _, err := Split(`ptz "Front Door`); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+}
+
+func TestExec(t *testing.T) {
+	sh, ts, buf := newShell(t)
+	ctx := context.Background()
+	lookups := ts.Lookups()
+
+	if err := sh.Exec(ctx, `switch "Front Office" Entrance`); err != nil {
+		t.Fatalf("Exec(switch) error = %v", err)
+	}
+	if err := sh.Exec(ctx, `ptz "Front Door" home`); err != nil {
+		t.Fatalf("Exec(ptz) error = %v", err)
+	}
+
+	want := "Switched viewport Front Office to liveview Entrance\nMoved camera Front Door to home position\n"
+	if buf.String() != want {
+		t.Errorf("Output = %q, want %q", buf.String(), want)
+	}
+
+	if n := ts.Lookups(); n != lookups {
+		t.Errorf("Expected names to come from the inventory, got %d more lookups", n-lookups)
+	}
+	if changes := ts.Requests(); len(changes) != 2 || changes[0] != "PATCH /proxy/protect/integration/v1/viewers/vp2" {
+		t.Errorf("Unexpected changes: %v", changes)
+	}
+
+	buf.Reset()
+	if err := sh.Exec(ctx, "ls viewports"); err != nil {
+		t.Fatalf("Exec(ls) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Front Office  Parking") {
+		t.Errorf("Unexpected listing: %q", buf.String())
+	}
+
+	for _, line := range []string{"switch Lobby", "switch Lobby Nowhere", "ptz Lobby up", "dance", "ls sensors"} {
+		if err := sh.Exec(ctx, line); err == nil {
+			t.Errorf("Exec(%q) expected an error", line)
+		}
+	}
+
+	if err := sh.Exec(ctx, "  # comment"); err != nil {
+		t.Errorf("Expected comments to be ignored, got %v", err)
+	}
+	if err := sh.Exec(ctx, "quit"); !errors.Is(err, ErrExit) {
+		t.Errorf("Exec(quit) = %v, want ErrExit", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	sh, _, _ := newShell(t)
+
+	tests := []struct {
+		line       string
+		want       string
+		candidates []string
+	}{
+		{"sw", "switch ", nil},
+		{"switch fr", `switch "Front Office" `, nil},
+		{`switch "Front Office" P`, `switch "Front Office" Parking `, nil},
+		{"ptz Front", `ptz "Front Door" `, nil},
+		{`ptz "Front Door" h`, `ptz "Front Door" home `, nil},
+		{"ls l", "ls liveviews ", nil},
+		{"switch ", "switch ", []string{"Lobby", "Front Office"}},
+		{"e", "exit ", nil},
+		{"switch Lobby Entrance x", "switch Lobby Entrance x", nil},
+	}
+
+	for _, tt := range tests {
+		got, pos, candidates := sh.Complete(tt.line, len(tt.line))
+		if got != tt.want || pos != len(tt.want) || !reflect.DeepEqual(candidates, tt.candidates) {
+			t.Errorf("Complete(%q) = %q, %d, %q; want %q, %q", tt.line, got, pos, candidates, tt.want, tt.candidates)
+		}
+	}
+
+	// Text after the cursor is kept
+	got, pos, _ := sh.Complete("switch Lo Entrance", len("switch Lo"))
+	if got != "switch Lobby Entrance" || pos != len("switch Lobby") {
+		t.Errorf("Complete() mid-line = %q, %d", got, pos)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "protect", "shell_history")
+
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	h.Add("ls viewports")
+	h.Add("ls viewports")
+	h.Add("  ")
+	h.Add("switch Lobby Entrance")
+
+	h, err = LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if h.Len() != 2 || h.At(0) != "switch Lobby Entrance" || h.At(1) != "ls viewports" {
+		t.Errorf("Unexpected history: %+v", h.entries)
+	}
+
+	// Long histories are trimmed when loaded
+	lines := strings.Repeat("ls cameras\n", 2*MaxHistory+1)
+	if err := os.WriteFile(path, []byte(lines), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if h, err = LoadHistory(path); err != nil || h.Len() != MaxHistory {
+		t.Fatalf("LoadHistory() = %d entries, %v", h.Len(), err)
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != MaxHistory {
+		t.Errorf("Expected the file to be trimmed to %d lines, got %d", MaxHistory, n)
+	}
+}
+
+func TestDefaultHistoryPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+
+	path, err := DefaultHistoryPath()
+	if err != nil {
+		t.Fatalf("DefaultHistoryPath() error = %v", err)
+	}
+	if path != filepath.Join("/tmp/state", "protect", "shell_history") {
+		t.Errorf("DefaultHistoryPath() = %q", path)
+	}
+}
@@ -0,0 +1,248 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the current snapshot format version
+const Version = 1
+
+// Snapshot is the saved state of every viewer and PTZ patrol
+type Snapshot struct {
+	Version int       `json:"version" yaml:"version"`
+	SavedAt time.Time `json:"savedAt" yaml:"savedAt"`
+	URL     string    `json:"url" yaml:"url"`
+	Viewers []Viewer  `json:"viewers" yaml:"viewers"`
+	Patrols []Patrol  `json:"patrols" yaml:"patrols"`
+}
+
+// Viewer is a viewer and the liveview it was showing. Names are kept so a
+// snapshot is readable and can still be restored if IDs change.
+type Viewer struct {
+	ID         string `json:"id" yaml:"id"`
+	Name       string `json:"name" yaml:"name"`
+	LiveviewID string `json:"liveviewId" yaml:"liveviewId"`
+	Liveview   string `json:"liveview" yaml:"liveview"`
+}
+
+// Patrol is a PTZ camera and its active patrol slot, nil when not patrolling
+type Patrol struct {
+	CameraID string `json:"cameraId" yaml:"cameraId"`
+	Camera   string `json:"camera" yaml:"camera"`
+	Slot     *int   `json:"slot" yaml:"slot"`
+}
+
+// Capture reads the current liveview of every viewer and the patrol slot of
+// every PTZ camera
+func Capture(c *client.Client) (*Snapshot, error) {
+	viewports, err := c.ListViewports()
+	if err != nil {
+		return nil, err
+	}
+
+	liveviews, err := c.ListCameras()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list liveviews: %w", err)
+	}
+
+	cameras, err := c.ListPTZCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(liveviews))
+	for _, lv := range liveviews {
+		names[lv.ID] = lv.Name
+	}
+
+	snap := &Snapshot{
+		Version: Version,
+		SavedAt: time.Now().UTC().Truncate(time.Second),
+		URL:     c.BaseURL,
+		Viewers: []Viewer{},
+		Patrols: []Patrol{},
+	}
+
+	for _, vp := range viewports {
+		snap.Viewers = append(snap.Viewers, Viewer{ID: vp.ID, Name: vp.Name, LiveviewID: vp.Liveview, Liveview: names[vp.Liveview]})
+	}
+
+	for _, cam := range cameras {
+		if cam.HasPTZ() {
+			snap.Patrols = append(snap.Patrols, Patrol{CameraID: cam.ID, Camera: cam.Name, Slot: cam.ActivePatrolSlot})
+		}
+	}
+
+	return snap, nil
+}
+
+// Plan compares a snapshot with the current state and returns the actions
+// needed to restore it, along with the number of viewers and cameras that
+// already match. Everything is resolved before returning, and all missing
+// viewers, liveviews and cameras are reported together.
+func Plan(c *client.Client, snap *Snapshot) ([]actions.Target, int, error) {
+	log := logger.Get()
+	resolver := actions.NewResolver(c)
+
+	var acts []actions.Action
+	var errs []error
+	matched := 0
+
+	for _, v := range snap.Viewers {
+		if v.LiveviewID == "" {
+			// Nothing was assigned, and there is no way to unassign
+			continue
+		}
+
+		vp, err := lookup(resolver.Viewport, v.ID, v.Name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		lv, err := lookup(resolver.Liveview, v.LiveviewID, v.Liveview)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("viewer %s: %w", v.Name, err))
+			continue
+		}
+
+		if vp.Liveview == lv.ID {
+			log.Debugw("Viewer already matches", "viewer", vp.Name, "liveview", lv.Name)
+			matched++
+			continue
+		}
+
+		acts = append(acts, actions.Switch(vp.ID, lv.ID))
+	}
+
+	for _, p := range snap.Patrols {
+		cam, err := lookup(resolver.Camera, p.CameraID, p.Camera)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		want, current := slot(p.Slot), slot(cam.ActivePatrolSlot)
+		if want == current {
+			matched++
+			continue
+		}
+
+		acts = append(acts, actions.Patrol(cam.ID, want))
+	}
+
+	if len(errs) > 0 {
+		return nil, matched, errors.Join(errs...)
+	}
+
+	targets, err := resolver.Resolve(acts)
+	if err != nil {
+		return nil, matched, err
+	}
+
+	return targets, matched, nil
+}
+
+// lookup finds a resource by ID, falling back to its saved name
+func lookup[T any](find func(string) (*T, error), id, name string) (*T, error) {
+	v, err := find(id)
+	if err != nil && name != "" {
+		return find(name)
+	}
+	return v, err
+}
+
+// slot returns a patrol slot, or -1 when not patrolling
+func slot(s *int) int {
+	if s == nil {
+		return -1
+	}
+	return *s
+}
+
+// DefaultPath returns the default snapshot location in the user state
+// directory ($XDG_STATE_HOME, or ~/.local/state)
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find state directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "protect", "state.yaml"), nil
+}
+
+// isJSON returns true if path should be written as JSON rather than YAML
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// Save writes a snapshot to path as JSON for .json files and YAML otherwise,
+// creating the directory if needed
+func Save(path string, snap *Snapshot) error {
+	var data []byte
+	var err error
+	if isJSON(path) {
+		data, err = json.MarshalIndent(snap, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(snap)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted save never replaces
+	// a good snapshot with a partial one
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a snapshot written by Save
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap Snapshot
+	if isJSON(path) {
+		err = json.Unmarshal(data, &snap)
+	} else {
+		err = yaml.Unmarshal(data, &snap)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	if snap.Version < 1 || snap.Version > Version {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+
+	return &snap, nil
+}
@@ -0,0 +1,162 @@
+package state
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newTestServer serves two viewers, two liveviews and two PTZ cameras, one
+// of which is patrolling slot 1
+func newTestServer(t *testing.T) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"},{"id":"vp2","name":"Lobby","liveview":"lv2"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"}]`)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door","modelKey":"camera","activePatrolSlot":1},{"id":"cam2","name":"Garage","modelKey":"camera","activePatrolSlot":null}]`)
+	return server
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestCapture(t *testing.T) {
+	server := newTestServer(t)
+
+	snap, err := Capture(server.APIClient())
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+
+	wantViewers := []Viewer{
+		{ID: "vp1", Name: "Tower", LiveviewID: "lv1", Liveview: "All Cameras"},
+		{ID: "vp2", Name: "Lobby", LiveviewID: "lv2", Liveview: "Driveway"},
+	}
+	if !reflect.DeepEqual(snap.Viewers, wantViewers) {
+		t.Errorf("Viewers = %+v, want %+v", snap.Viewers, wantViewers)
+	}
+
+	if len(snap.Patrols) != 2 || snap.Patrols[0].Slot == nil || *snap.Patrols[0].Slot != 1 || snap.Patrols[1].Slot != nil {
+		t.Errorf("Unexpected patrols: %+v", snap.Patrols)
+	}
+
+	if snap.Version != Version || snap.URL != server.URL {
+		t.Errorf("Unexpected snapshot header: %+v", snap)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	snap := &Snapshot{
+		Version: Version,
+		Viewers: []Viewer{
+			// Already matches
+			{ID: "vp1", Name: "Tower", LiveviewID: "lv1", Liveview: "All Cameras"},
+			// IDs changed; found by name
+			{ID: "old-vp", Name: "Lobby", LiveviewID: "old-lv", Liveview: "All Cameras"},
+			// Nothing assigned
+			{ID: "vp3", Name: "Attic"},
+		},
+		Patrols: []Patrol{
+			{CameraID: "cam1", Camera: "Front Door"},
+			{CameraID: "cam2", Camera: "Garage", Slot: intPtr(3)},
+		},
+	}
+
+	targets, matched, err := Plan(c, snap)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	if matched != 1 {
+		t.Errorf("Expected 1 match, got %d", matched)
+	}
+
+	var got []actions.Action
+	for _, target := range targets {
+		got = append(got, target.Action)
+	}
+	want := []actions.Action{actions.Switch("vp2", "lv1"), actions.Patrol("cam1", -1), actions.Patrol("cam2", 3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanMissing(t *testing.T) {
+	server := newTestServer(t)
+	c := server.APIClient()
+
+	snap := &Snapshot{
+		Version: Version,
+		Viewers: []Viewer{{ID: "vp9", Name: "Gone", LiveviewID: "lv1"}, {ID: "vp1", Name: "Tower", LiveviewID: "lv9", Liveview: "Deleted"}},
+		Patrols: []Patrol{{CameraID: "cam9", Camera: "Removed"}},
+	}
+
+	_, _, err := Plan(c, snap)
+	if err == nil {
+		t.Fatal("Expected error for missing resources")
+	}
+
+	for _, want := range []string{"viewport not found: Gone", "viewer Tower: liveview not found: Deleted", "camera not found: Removed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain '%s', got '%v'", want, err)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	snap := &Snapshot{
+		Version: Version,
+		URL:     "https://protect.example.com",
+		Viewers: []Viewer{{ID: "vp1", Name: "Tower", LiveviewID: "lv1", Liveview: "All Cameras"}},
+		Patrols: []Patrol{{CameraID: "cam1", Camera: "Front Door", Slot: intPtr(2)}, {CameraID: "cam2", Camera: "Garage"}},
+	}
+
+	for _, name := range []string{"state.yaml", "state.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nested", name)
+			if err := Save(path, snap); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(loaded, snap) {
+				t.Errorf("Load() = %+v, want %+v", loaded, snap)
+			}
+		})
+	}
+}
+
+func TestLoadVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	if err := Save(path, &Snapshot{Version: Version + 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "unsupported snapshot version") {
+		t.Errorf("Expected version error, got %v", err)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error = %v", err)
+	}
+
+	if path != "/tmp/state/protect/state.yaml" {
+		t.Errorf("Expected '/tmp/state/protect/state.yaml', got '%s'", path)
+	}
+}
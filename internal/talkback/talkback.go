@@ -0,0 +1,258 @@
+package talkback
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// FrameDuration is the amount of audio sent per packet
+var FrameDuration = 20 * time.Millisecond
+
+// codec describes how a session codec maps onto WAV audio
+type codec struct {
+	format      uint16
+	payloadType uint8
+}
+
+var codecs = map[string]codec{
+	"pcmu": {format: FormatULaw, payloadType: 0},
+	"pcma": {format: FormatALaw, payloadType: 8},
+	"pcm":  {format: FormatPCM, payloadType: 96},
+	"l16":  {format: FormatPCM, payloadType: 96},
+}
+
+// lookupCodec returns the codec for a session, normalising its name.
+// Compressed codecs such as opus and aac would need an encoder, so cameras
+// that ask for them are not supported.
+func lookupCodec(name string) (codec, error) {
+	c, ok := codecs[strings.ToLower(name)]
+	if !ok {
+		return codec{}, fmt.Errorf("camera requested talkback codec %q, which is not supported (only pcmu, pcma and pcm/l16 sessions can be played)", name)
+	}
+	return c, nil
+}
+
+// Validate checks that the WAV audio matches the session parameters
+func Validate(session *client.TalkbackSession, w *WAV) error {
+	c, err := lookupCodec(session.Codec)
+	if err != nil {
+		return err
+	}
+
+	if w.Format != c.format {
+		return fmt.Errorf("WAV format %d does not match session codec %s (expected format %d)", w.Format, session.Codec, c.format)
+	}
+
+	if err := ValidateAudio(w); err != nil {
+		return err
+	}
+
+	if int(w.SampleRate) != session.SamplingRate {
+		return fmt.Errorf("WAV sample rate %d Hz does not match session rate %d Hz", w.SampleRate, session.SamplingRate)
+	}
+
+	if session.BitsPerSample > 0 && int(w.BitsPerSample) != session.BitsPerSample {
+		return fmt.Errorf("WAV bit depth %d does not match session bit depth %d", w.BitsPerSample, session.BitsPerSample)
+	}
+
+	return nil
+}
+
+// ValidateAudio runs the checks that do not depend on a session: the
+// audio is mono, not empty and in a format some codec can carry
+func ValidateAudio(w *WAV) error {
+	supported := false
+	for _, c := range codecs {
+		supported = supported || c.format == w.Format
+	}
+	if !supported {
+		return fmt.Errorf("WAV format %d is not supported for talkback (use PCM, mu-law or A-law)", w.Format)
+	}
+
+	if w.Channels != 1 {
+		return fmt.Errorf("WAV has %d channels, talkback requires mono audio", w.Channels)
+	}
+
+	if len(w.Data) == 0 {
+		return fmt.Errorf("WAV file contains no audio")
+	}
+
+	return nil
+}
+
+// Stream validates the audio and sends it to the session endpoint in real
+// time. rtp:// and udp:// endpoints receive RTP packets or raw datagrams,
+// http:// and https:// endpoints receive a streamed WAV upload.
+func Stream(ctx context.Context, session *client.TalkbackSession, w *WAV) error {
+	if err := Validate(session, w); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(session.URL)
+	if err != nil {
+		return fmt.Errorf("invalid talkback URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "rtp", "udp":
+		return streamUDP(ctx, u, session, w)
+	case "http", "https":
+		return streamHTTP(ctx, session, w)
+	default:
+		return fmt.Errorf("unsupported talkback URL scheme: %s", u.Scheme)
+	}
+}
+
+// frames splits the audio into packets of FrameDuration each
+func frames(w *WAV) [][]byte {
+	size := int(w.SampleRate) * w.FrameSize() * int(FrameDuration/time.Millisecond) / 1000
+	if size <= 0 {
+		size = w.FrameSize()
+	}
+
+	var out [][]byte
+	for start := 0; start < len(w.Data); start += size {
+		end := start + size
+		if end > len(w.Data) {
+			end = len(w.Data)
+		}
+		out = append(out, w.Data[start:end])
+	}
+	return out
+}
+
+// pace calls send for every frame, one frame per FrameDuration
+func pace(ctx context.Context, w *WAV, send func(i int, frame []byte) error) error {
+	ticker := time.NewTicker(FrameDuration)
+	defer ticker.Stop()
+
+	for i, frame := range frames(w) {
+		if err := send(i, frame); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// streamUDP sends the audio as RTP packets (rtp://) or raw datagrams (udp://)
+func streamUDP(ctx context.Context, u *url.URL, session *client.TalkbackSession, w *WAV) error {
+	log := logger.Get()
+
+	c, _ := lookupCodec(session.Codec)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", u.Host)
+	if err != nil {
+		return fmt.Errorf("failed to connect to talkback endpoint: %w", err)
+	}
+	defer conn.Close()
+
+	log.Infow("Streaming talkback audio", "url", session.URL, "duration", w.Duration())
+
+	ssrc := rand.Uint32()
+	seq := uint16(rand.Intn(1 << 16))
+	timestamp := rand.Uint32()
+	samplesSent := uint32(0)
+	frameSize := w.FrameSize()
+
+	return pace(ctx, w, func(i int, frame []byte) error {
+		packet := frame
+		if u.Scheme == "rtp" {
+			payload := frame
+			if c.format == FormatPCM && w.BitsPerSample == 16 {
+				// RTP carries linear PCM in network byte order
+				payload = swap16(frame)
+			}
+			packet = rtpPacket(c.payloadType, i == 0, seq+uint16(i), timestamp+samplesSent, ssrc, payload)
+			samplesSent += uint32(len(frame) / frameSize)
+		}
+
+		if _, err := conn.Write(packet); err != nil {
+			return fmt.Errorf("failed to send talkback audio: %w", err)
+		}
+		return nil
+	})
+}
+
+// rtpPacket builds an RTP packet with a fixed 12 byte header
+func rtpPacket(payloadType uint8, marker bool, seq uint16, timestamp, ssrc uint32, payload []byte) []byte {
+	packet := make([]byte, 12+len(payload))
+	packet[0] = 0x80 // version 2
+	packet[1] = payloadType & 0x7F
+	if marker {
+		packet[1] |= 0x80
+	}
+	binary.BigEndian.PutUint16(packet[2:4], seq)
+	binary.BigEndian.PutUint32(packet[4:8], timestamp)
+	binary.BigEndian.PutUint32(packet[8:12], ssrc)
+	copy(packet[12:], payload)
+	return packet
+}
+
+// swap16 converts 16-bit little-endian samples to big-endian
+func swap16(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i := 0; i+1 < len(data); i += 2 {
+		out[i], out[i+1] = data[i+1], data[i]
+	}
+	return out
+}
+
+// streamHTTP uploads the audio as a WAV stream, paced in real time
+func streamHTTP(ctx context.Context, session *client.TalkbackSession, w *WAV) error {
+	log := logger.Get()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	go func() {
+		if _, err := pw.Write(w.header()); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(pace(ctx, w, func(_ int, frame []byte) error {
+			_, err := pw.Write(frame)
+			return err
+		}))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", session.URL, pr)
+	if err != nil {
+		return fmt.Errorf("failed to create talkback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "audio/wav")
+
+	log.Infow("Streaming talkback audio", "url", session.URL, "duration", w.Duration())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send talkback audio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("talkback endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package talkback
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/client"
+)
+
+// testWAV builds mono audio of the given length
+func testWAV(format uint16, rate uint32, bits uint16, duration time.Duration) *WAV {
+	w := &WAV{Format: format, Channels: 1, SampleRate: rate, BitsPerSample: bits}
+	w.Data = make([]byte, int(duration/time.Millisecond)*int(rate)/1000*w.FrameSize())
+	for i := range w.Data {
+		w.Data[i] = byte(i)
+	}
+	return w
+}
+
+func TestParseWAV(t *testing.T) {
+	original := testWAV(FormatPCM, 16000, 16, 100*time.Millisecond)
+
+	// Insert an unknown chunk between the format and data chunks
+	header := original.header()
+	buf := new(bytes.Buffer)
+	buf.Write(header[:36])
+	buf.WriteString("LIST")
+	binary.Write(buf, binary.LittleEndian, uint32(3))
+	buf.Write([]byte{1, 2, 3, 0})
+	buf.Write(header[36:])
+	buf.Write(original.Data)
+
+	w, err := ParseWAV(buf)
+	if err != nil {
+		t.Fatalf("ParseWAV() error = %v", err)
+	}
+
+	if w.Format != FormatPCM || w.Channels != 1 || w.SampleRate != 16000 || w.BitsPerSample != 16 {
+		t.Errorf("Unexpected WAV format: %+v", w)
+	}
+
+	if !bytes.Equal(w.Data, original.Data) {
+		t.Error("Expected audio data to round-trip")
+	}
+
+	if w.Duration() != 100*time.Millisecond {
+		t.Errorf("Expected duration 100ms, got %v", w.Duration())
+	}
+
+	if _, err := ParseWAV(bytes.NewReader([]byte("not a wav file"))); err == nil {
+		t.Error("Expected error for invalid file")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	session := &client.TalkbackSession{Codec: "pcmu", SamplingRate: 8000, BitsPerSample: 8}
+
+	tests := []struct {
+		name    string
+		wav     *WAV
+		session *client.TalkbackSession
+		wantErr bool
+	}{
+		{name: "Matching mu-law audio", wav: testWAV(FormatULaw, 8000, 8, time.Second), session: session},
+		{name: "Wrong format", wav: testWAV(FormatPCM, 8000, 8, time.Second), session: session, wantErr: true},
+		{name: "Wrong sample rate", wav: testWAV(FormatULaw, 16000, 8, time.Second), session: session, wantErr: true},
+		{name: "Wrong bit depth", wav: testWAV(FormatPCM, 16000, 8, time.Second), session: &client.TalkbackSession{Codec: "pcm", SamplingRate: 16000, BitsPerSample: 16}, wantErr: true},
+		{name: "Stereo audio", wav: &WAV{Format: FormatULaw, Channels: 2, SampleRate: 8000, BitsPerSample: 8, Data: []byte{1, 2}}, session: session, wantErr: true},
+		{name: "Unsupported codec", wav: testWAV(FormatPCM, 24000, 16, time.Second), session: &client.TalkbackSession{Codec: "opus", SamplingRate: 24000}, wantErr: true},
+		{name: "Empty audio", wav: testWAV(FormatULaw, 8000, 8, 0), session: session, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.session, tt.wav)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUnsupportedCodec(t *testing.T) {
+	err := Validate(&client.TalkbackSession{Codec: "opus", SamplingRate: 48000}, testWAV(FormatPCM, 48000, 16, time.Second))
+	if err == nil || !strings.Contains(err.Error(), `"opus"`) || !strings.Contains(err.Error(), "pcmu, pcma and pcm/l16") {
+		t.Errorf("Expected an unsupported codec error naming opus, got %v", err)
+	}
+}
+
+func TestValidateAudio(t *testing.T) {
+	if err := ValidateAudio(testWAV(FormatULaw, 16000, 8, time.Second)); err != nil {
+		t.Errorf("ValidateAudio() error = %v", err)
+	}
+
+	for name, w := range map[string]*WAV{
+		"stereo":      {Format: FormatPCM, Channels: 2, SampleRate: 16000, BitsPerSample: 16, Data: []byte{1, 2, 3, 4}},
+		"empty":       testWAV(FormatPCM, 16000, 16, 0),
+		"unsupported": testWAV(3, 16000, 32, time.Second),
+	} {
+		if err := ValidateAudio(w); err == nil {
+			t.Errorf("Expected an error for %s audio", name)
+		}
+	}
+}
+
+func TestStreamRTP(t *testing.T) {
+	FrameDuration = 5 * time.Millisecond
+	defer func() { FrameDuration = 20 * time.Millisecond }()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	session := &client.TalkbackSession{
+		URL:           "rtp://" + conn.LocalAddr().String(),
+		Codec:         "pcm",
+		SamplingRate:  16000,
+		BitsPerSample: 16,
+	}
+	w := testWAV(FormatPCM, 16000, 16, 20*time.Millisecond)
+
+	if err := Stream(context.Background(), session, w); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	// 20ms of audio in 5ms frames is four packets of 80 samples each
+	var received []byte
+	var timestamps []uint32
+	buf := make([]byte, 2048)
+	for i := 0; i < 4; i++ {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Failed to read packet %d: %v", i, err)
+		}
+
+		if buf[0] != 0x80 || buf[1]&0x7F != 96 {
+			t.Errorf("Unexpected RTP header: % x", buf[:2])
+		}
+		if i == 0 && buf[1]&0x80 == 0 {
+			t.Error("Expected marker bit on first packet")
+		}
+
+		timestamps = append(timestamps, binary.BigEndian.Uint32(buf[4:8]))
+		received = append(received, buf[12:n]...)
+	}
+
+	if timestamps[1]-timestamps[0] != 80 {
+		t.Errorf("Expected timestamp step of 80 samples, got %d", timestamps[1]-timestamps[0])
+	}
+
+	if !bytes.Equal(received, swap16(w.Data)) {
+		t.Error("Expected big-endian audio payload")
+	}
+}
+
+func TestStreamHTTP(t *testing.T) {
+	FrameDuration = 5 * time.Millisecond
+	defer func() { FrameDuration = 20 * time.Millisecond }()
+
+	w := testWAV(FormatULaw, 8000, 8, 20*time.Millisecond)
+
+	var got *WAV
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "audio/wav" {
+			t.Errorf("Expected audio/wav upload, got '%s'", r.Header.Get("Content-Type"))
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		parsed, err := ParseWAV(bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Failed to parse upload: %v", err)
+		}
+		got = parsed
+	}))
+	defer server.Close()
+
+	session := &client.TalkbackSession{URL: server.URL, Codec: "pcmu", SamplingRate: 8000, BitsPerSample: 8}
+	if err := Stream(context.Background(), session, w); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	if got == nil || !bytes.Equal(got.Data, w.Data) {
+		t.Error("Expected the receiver to get the full audio")
+	}
+}
+
+func TestStreamUnsupportedScheme(t *testing.T) {
+	session := &client.TalkbackSession{URL: "srt://192.168.1.20:7004", Codec: "pcmu", SamplingRate: 8000, BitsPerSample: 8}
+	if err := Stream(context.Background(), session, testWAV(FormatULaw, 8000, 8, 20*time.Millisecond)); err == nil {
+		t.Error("Expected error for unsupported URL scheme")
+	}
+}
@@ -0,0 +1,130 @@
+package talkback
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WAV audio format tags
+const (
+	FormatPCM  uint16 = 1
+	FormatALaw uint16 = 6
+	FormatULaw uint16 = 7
+)
+
+// WAV holds the format and sample data of a WAV file
+type WAV struct {
+	Format        uint16
+	Channels      uint16
+	SampleRate    uint32
+	BitsPerSample uint16
+	Data          []byte
+}
+
+// FrameSize returns the number of bytes per sample frame
+func (w *WAV) FrameSize() int {
+	return int(w.Channels) * int(w.BitsPerSample) / 8
+}
+
+// Duration returns the playback length of the audio
+func (w *WAV) Duration() time.Duration {
+	bytesPerSecond := int64(w.SampleRate) * int64(w.FrameSize())
+	if bytesPerSecond == 0 {
+		return 0
+	}
+	return time.Duration(int64(len(w.Data)) * int64(time.Second) / bytesPerSecond)
+}
+
+// ParseWAV reads a RIFF/WAVE file
+func ParseWAV(r io.Reader) (*WAV, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read WAV header: %w", err)
+	}
+
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+
+	w := &WAV{}
+	haveFormat := false
+
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to read WAV chunk: %w", err)
+		}
+
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid WAV format chunk")
+			}
+			fmtData := make([]byte, size)
+			if _, err := io.ReadFull(r, fmtData); err != nil {
+				return nil, fmt.Errorf("failed to read WAV format: %w", err)
+			}
+			w.Format = binary.LittleEndian.Uint16(fmtData[0:2])
+			w.Channels = binary.LittleEndian.Uint16(fmtData[2:4])
+			w.SampleRate = binary.LittleEndian.Uint32(fmtData[4:8])
+			w.BitsPerSample = binary.LittleEndian.Uint16(fmtData[14:16])
+			haveFormat = true
+
+		case "data":
+			if !haveFormat {
+				return nil, fmt.Errorf("WAV data chunk before format chunk")
+			}
+			w.Data = make([]byte, size)
+			n, err := io.ReadFull(r, w.Data)
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("failed to read WAV data: %w", err)
+			}
+			// Some encoders write a placeholder size; keep what is there
+			w.Data = w.Data[:n]
+			return w, nil
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return nil, fmt.Errorf("failed to skip WAV chunk %q: %w", id, err)
+			}
+			continue
+		}
+
+		// Chunks are padded to an even size
+		if size%2 == 1 {
+			io.CopyN(io.Discard, r, 1)
+		}
+	}
+
+	return nil, fmt.Errorf("WAV file has no data chunk")
+}
+
+// header returns a RIFF/WAVE header describing the audio
+func (w *WAV) header() []byte {
+	blockAlign := uint16(w.FrameSize())
+	byteRate := w.SampleRate * uint32(blockAlign)
+
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+len(w.Data)))
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], w.Format)
+	binary.LittleEndian.PutUint16(header[22:24], w.Channels)
+	binary.LittleEndian.PutUint32(header[24:28], w.SampleRate)
+	binary.LittleEndian.PutUint32(header[28:32], byteRate)
+	binary.LittleEndian.PutUint16(header[32:34], blockAlign)
+	binary.LittleEndian.PutUint16(header[34:36], w.BitsPerSample)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(len(w.Data)))
+	return header
+}
@@ -0,0 +1,218 @@
+package tour
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/logger"
+)
+
+// Step is a liveview and how long to show it
+type Step struct {
+	Liveview string
+	Dwell    time.Duration
+}
+
+// ParseSteps parses a comma-separated list of liveviews. Each may override
+// the default interval with =<duration>, e.g. "All Cameras,Driveway=45s".
+func ParseSteps(spec string, interval time.Duration) ([]Step, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid interval: %s (must be positive)", interval)
+	}
+
+	var steps []Step
+	for _, part := range strings.Split(spec, ",") {
+		name, dwellArg, hasDwell := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid liveview list: %s (expected format: <liveview>[=<duration>],...)", spec)
+		}
+
+		step := Step{Liveview: name, Dwell: interval}
+		if hasDwell {
+			dwell, err := time.ParseDuration(strings.TrimSpace(dwellArg))
+			if err != nil || dwell <= 0 {
+				return nil, fmt.Errorf("invalid dwell time for %s: %s", name, dwellArg)
+			}
+			step.Dwell = dwell
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// stop is a step whose liveview has been resolved
+type stop struct {
+	ID    string
+	Name  string
+	Dwell time.Duration
+}
+
+// Tour cycles a viewer through liveviews
+type Tour struct {
+	client   *client.Client
+	viewport client.Viewport
+	stops    []stop
+}
+
+// New resolves the viewport and every liveview before the tour starts. All
+// unknown names are reported together.
+func New(c *client.Client, viewport string, steps []Step) (*Tour, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("a tour needs at least one liveview")
+	}
+
+	resolver := actions.NewResolver(c)
+	var errs []error
+
+	vp, err := resolver.Viewport(viewport)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	t := &Tour{client: c}
+	for _, step := range steps {
+		lv, err := resolver.Liveview(step.Liveview)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t.stops = append(t.stops, stop{ID: lv.ID, Name: lv.Name, Dwell: step.Dwell})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	t.viewport = *vp
+	return t, nil
+}
+
+// Viewport returns the viewer being toured
+func (t *Tour) Viewport() client.Viewport {
+	return t.viewport
+}
+
+// Options controls how a tour runs
+type Options struct {
+	// Shuffle visits the liveviews in a random order on each cycle
+	Shuffle bool
+	// Cycles stops the tour after this many passes; 0 runs until cancelled
+	Cycles int
+	// Pause pauses the tour when it receives true and resumes it on false.
+	// A resumed tour moves straight on to the next liveview.
+	Pause <-chan bool
+	// OnSwitch, if set, is called after each switch attempt
+	OnSwitch func(liveview string, err error)
+	// Rand is used for shuffling; a time-seeded source is used if nil
+	Rand *rand.Rand
+}
+
+// Run switches the viewer through the liveviews until ctx is cancelled or
+// the requested cycles finish, then switches it back to the liveview it was
+// showing when the tour started. Failed switches are reported and skipped so
+// a brief outage does not end the tour.
+func (t *Tour) Run(ctx context.Context, opts Options) error {
+	log := logger.Get()
+
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	defer t.restore()
+
+	order := make([]int, len(t.stops))
+	for i := range order {
+		order[i] = i
+	}
+
+	last := -1
+	for cycle := 0; opts.Cycles == 0 || cycle < opts.Cycles; cycle++ {
+		if opts.Shuffle {
+			shuffle(rng, order, last)
+		}
+
+		for _, i := range order {
+			s := t.stops[i]
+			last = i
+
+			err := t.client.SwitchViewport(t.viewport.ID, s.ID)
+			if err != nil {
+				log.Warnw("Tour switch failed", "viewport", t.viewport.Name, "liveview", s.Name, "error", err)
+			}
+			if opts.OnSwitch != nil {
+				opts.OnSwitch(s.Name, err)
+			}
+
+			if !wait(ctx, s.Dwell, opts.Pause) {
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+// restore switches the viewer back to its original liveview
+func (t *Tour) restore() {
+	log := logger.Get()
+
+	if t.viewport.Liveview == "" {
+		return
+	}
+
+	if err := t.client.SwitchViewport(t.viewport.ID, t.viewport.Liveview); err != nil {
+		log.Warnw("Failed to restore original liveview", "viewport", t.viewport.Name, "error", err)
+		return
+	}
+	log.Infow("Restored original liveview", "viewport", t.viewport.Name, "liveviewID", t.viewport.Liveview)
+}
+
+// wait waits for d, stopping the clock while paused. It returns false if
+// ctx is cancelled.
+func wait(ctx context.Context, d time.Duration, pause <-chan bool) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		case paused := <-pause:
+			if !paused {
+				continue
+			}
+			timer.Stop()
+			logger.Get().Info("Tour paused")
+			for paused {
+				select {
+				case <-ctx.Done():
+					return false
+				case paused = <-pause:
+				}
+			}
+			logger.Get().Info("Tour resumed")
+			return true
+		}
+	}
+}
+
+// shuffle reorders indices randomly, avoiding showing the previous cycle's
+// last liveview twice in a row
+func shuffle(rng *rand.Rand, order []int, last int) {
+	rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	if len(order) > 1 && order[0] == last {
+		k := 1 + rng.Intn(len(order)-1)
+		order[0], order[k] = order[k], order[0]
+	}
+}
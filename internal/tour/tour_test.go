@@ -0,0 +1,159 @@
+package tour
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+// newTestServer serves a viewer showing lv1 and records the liveview of
+// every switch
+func newTestServer(t *testing.T, switches *[]string, mu *sync.Mutex) *protecttest.Server {
+	server := protecttest.New(t)
+	server.Set("viewers", `[{"id":"vp1","name":"Lobby","liveview":"lv1"}]`)
+	server.Set("liveviews", `[{"id":"lv1","name":"All Cameras"},{"id":"lv2","name":"Driveway"},{"id":"lv3","name":"Garage"}]`)
+	server.Handle("PATCH viewers/vp1", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		*switches = append(*switches, body["liveview"])
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	})
+	return server
+}
+
+func TestParseSteps(t *testing.T) {
+	steps, err := ParseSteps("All Cameras, Driveway=45s ,Garage", 20*time.Second)
+	if err != nil {
+		t.Fatalf("ParseSteps() error = %v", err)
+	}
+
+	want := []Step{
+		{Liveview: "All Cameras", Dwell: 20 * time.Second},
+		{Liveview: "Driveway", Dwell: 45 * time.Second},
+		{Liveview: "Garage", Dwell: 20 * time.Second},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("ParseSteps() = %+v, want %+v", steps, want)
+	}
+
+	for _, spec := range []string{"A,,B", "A=soon", "A=-5s", ""} {
+		if _, err := ParseSteps(spec, time.Second); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+
+	if _, err := ParseSteps("A", 0); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+}
+
+func TestNew(t *testing.T) {
+	var mu sync.Mutex
+	var switches []string
+	server := newTestServer(t, &switches, &mu)
+	c := server.APIClient()
+
+	_, err := New(c, "Attic", []Step{{Liveview: "Driveway"}, {Liveview: "Pool"}})
+	if err == nil {
+		t.Fatal("Expected error for unknown names")
+	}
+	for _, want := range []string{"viewport not found: Attic", "liveview not found: Pool"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain '%s', got '%v'", want, err)
+		}
+	}
+}
+
+func TestRunCycles(t *testing.T) {
+	var mu sync.Mutex
+	var switches []string
+	server := newTestServer(t, &switches, &mu)
+	c := server.APIClient()
+
+	tour, err := New(c, "Lobby", []Step{{Liveview: "Driveway", Dwell: time.Millisecond}, {Liveview: "Garage", Dwell: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var shown []string
+	err = tour.Run(context.Background(), Options{
+		Cycles:   2,
+		OnSwitch: func(liveview string, err error) { shown = append(shown, liveview) },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Two cycles, then back to the original liveview
+	if strings.Join(switches, ",") != "lv2,lv3,lv2,lv3,lv1" {
+		t.Errorf("Unexpected switches: %v", switches)
+	}
+	if strings.Join(shown, ",") != "Driveway,Garage,Driveway,Garage" {
+		t.Errorf("Unexpected OnSwitch calls: %v", shown)
+	}
+}
+
+func TestRunPauseAndCancel(t *testing.T) {
+	var mu sync.Mutex
+	var switches []string
+	server := newTestServer(t, &switches, &mu)
+	c := server.APIClient()
+
+	tour, err := New(c, "Lobby", []Step{{Liveview: "Driveway", Dwell: time.Hour}, {Liveview: "Garage", Dwell: time.Hour}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pause := make(chan bool)
+	switched := make(chan string, 4)
+	done := make(chan error)
+	go func() {
+		done <- tour.Run(ctx, Options{Pause: pause, OnSwitch: func(liveview string, err error) { switched <- liveview }})
+	}()
+
+	if got := <-switched; got != "Driveway" {
+		t.Fatalf("Expected first switch to Driveway, got %s", got)
+	}
+
+	// Resuming moves straight on to the next liveview
+	pause <- true
+	pause <- false
+	if got := <-switched; got != "Garage" {
+		t.Fatalf("Expected resume to switch to Garage, got %s", got)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(switches, ",") != "lv2,lv3,lv1" {
+		t.Errorf("Expected original liveview restored, got %v", switches)
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	order := []int{0, 1, 2, 3}
+
+	for i := 0; i < 50; i++ {
+		last := order[len(order)-1]
+		shuffle(rng, order, last)
+		if order[0] == last {
+			t.Fatalf("Shuffle repeated liveview %d", last)
+		}
+	}
+}
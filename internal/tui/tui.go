@@ -1,11 +1,17 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/methridge/protect/internal/actions"
 	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/inventory"
+	"github.com/methridge/protect/internal/logger"
 )
 
 // Screen represents different screens in the TUI
@@ -17,16 +23,20 @@ const (
 	ScreenCameras
 	ScreenLiveviews
 	ScreenPresets
+	ScreenScenes
 )
 
 // Model represents the TUI application state
 type Model struct {
 	client           *client.Client
+	inventory        *inventory.Inventory
+	liveviewNames    map[string]string
 	screen           Screen
 	cursor           int
 	viewports        []client.Viewport
 	cameras          []client.PTZCamera
 	liveviews        []client.Liveview
+	scenes           []config.Scene
 	selectedViewport *client.Viewport
 	selectedCamera   *client.PTZCamera
 	message          string
@@ -91,7 +101,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc", "backspace":
 			// Go back to previous screen
 			switch m.screen {
-			case ScreenViewports, ScreenCameras:
+			case ScreenViewports, ScreenCameras, ScreenScenes:
 				m.screen = ScreenMainMenu
 				m.cursor = 0
 				m.message = ""
@@ -121,7 +131,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "down", "j":
 			switch m.screen {
 			case ScreenMainMenu:
-				if m.cursor < 1 {
+				if m.cursor < len(m.mainMenuOptions())-1 {
 					m.cursor++
 				}
 			case ScreenViewports:
@@ -140,6 +150,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.cursor < 10 { // -1 through 9
 					m.cursor++
 				}
+			case ScreenScenes:
+				if m.cursor < len(m.scenes)-1 {
+					m.cursor++
+				}
 			}
 
 		case "enter", " ":
@@ -149,11 +163,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case viewportsLoadedMsg:
 		m.viewports = msg.viewports
 		m.err = msg.err
+		if msg.liveviewNames != nil {
+			m.liveviewNames = msg.liveviewNames
+		}
 		if m.err == nil {
 			m.screen = ScreenViewports
 			m.cursor = 0
 		}
 
+	case inventoryChangedMsg:
+		// Keep the viewport list current without reloading it from the API
+		if m.inventory != nil && m.inventory.Loaded() {
+			snap := m.inventory.Snapshot()
+			m.liveviewNames = liveviewNames(snap.Liveviews)
+			if m.screen == ScreenViewports {
+				m.viewports = snap.Viewers
+				if m.cursor >= len(m.viewports) && m.cursor > 0 {
+					m.cursor = len(m.viewports) - 1
+				}
+			}
+		}
+
 	case camerasLoadedMsg:
 		m.cameras = msg.cameras
 		m.err = msg.err
@@ -166,6 +196,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.liveviews = msg.liveviews
 		m.err = msg.err
 		if m.err == nil {
+			m.liveviewNames = liveviewNames(msg.liveviews)
 			m.screen = ScreenLiveviews
 			m.cursor = 0
 		}
@@ -197,6 +228,8 @@ func (m Model) View() string {
 		s = m.viewLiveviews()
 	case ScreenPresets:
 		s = m.viewPresets()
+	case ScreenScenes:
+		s = m.viewScenes()
 	}
 
 	// Add message or error
@@ -213,12 +246,7 @@ func (m Model) viewMainMenu() string {
 	s := titleStyle.Render("UniFi Protect Control") + "\n\n"
 	s += "Select an option:\n\n"
 
-	options := []string{
-		"Manage Viewports",
-		"Control PTZ Cameras",
-	}
-
-	for i, option := range options {
+	for i, option := range m.mainMenuOptions() {
 		cursor := " "
 		if m.cursor == i {
 			cursor = ">"
@@ -232,6 +260,19 @@ func (m Model) viewMainMenu() string {
 	return s
 }
 
+// mainMenuOptions returns the main menu entries; scenes are only offered
+// when some are configured
+func (m Model) mainMenuOptions() []string {
+	options := []string{
+		"Manage Viewports",
+		"Control PTZ Cameras",
+	}
+	if len(m.scenes) > 0 {
+		options = append(options, "Apply Scene")
+	}
+	return options
+}
+
 func (m Model) viewViewports() string {
 	s := titleStyle.Render("Viewports") + "\n\n"
 
@@ -239,12 +280,17 @@ func (m Model) viewViewports() string {
 		s += "No viewports found\n"
 	} else {
 		for i, vp := range m.viewports {
+			label := vp.Name
+			if name, ok := m.liveviewNames[vp.Liveview]; ok {
+				label = fmt.Sprintf("%s (%s)", vp.Name, name)
+			}
+
 			cursor := " "
 			if m.cursor == i {
 				cursor = ">"
-				s += selectedStyle.Render(fmt.Sprintf("%s %s", cursor, vp.Name)) + "\n"
+				s += selectedStyle.Render(fmt.Sprintf("%s %s", cursor, label)) + "\n"
 			} else {
-				s += normalStyle.Render(fmt.Sprintf("%s %s", cursor, vp.Name)) + "\n"
+				s += normalStyle.Render(fmt.Sprintf("%s %s", cursor, label)) + "\n"
 			}
 		}
 	}
@@ -326,16 +372,41 @@ func (m Model) viewPresets() string {
 	return s
 }
 
+func (m Model) viewScenes() string {
+	s := titleStyle.Render("Scenes") + "\n\n"
+
+	for i, scene := range m.scenes {
+		label := fmt.Sprintf("%s (%d switches, %d PTZ moves)", scene.Name, len(scene.Switches), len(scene.PTZ))
+
+		cursor := " "
+		if m.cursor == i {
+			cursor = ">"
+			s += selectedStyle.Render(fmt.Sprintf("%s %s", cursor, label)) + "\n"
+		} else {
+			s += normalStyle.Render(fmt.Sprintf("%s %s", cursor, label)) + "\n"
+		}
+	}
+
+	s += "\n" + helpStyle.Render("↑/↓: navigate • enter: apply • esc: back • q: quit")
+	return s
+}
+
 func (m Model) handleSelection() (tea.Model, tea.Cmd) {
 	switch m.screen {
 	case ScreenMainMenu:
 		switch m.cursor {
 		case 0:
 			// Load viewports
-			return m, loadViewports(m.client)
+			return m, loadViewports(m.client, m.inventory)
 		case 1:
 			// Load cameras
 			return m, loadCameras(m.client)
+		case 2:
+			// Scenes come from the configuration, so there is nothing to load
+			m.screen = ScreenScenes
+			m.cursor = 0
+			m.message = ""
+			m.err = nil
 		}
 
 	case ScreenViewports:
@@ -362,6 +433,11 @@ func (m Model) handleSelection() (tea.Model, tea.Cmd) {
 			preset := m.cursor - 1 // cursor 0 = -1, cursor 1 = 0, etc.
 			return m, movePTZCamera(m.client, m.selectedCamera.ID, preset, m.selectedCamera.Name)
 		}
+
+	case ScreenScenes:
+		if m.cursor < len(m.scenes) {
+			return m, applyScene(m.client, m.scenes[m.cursor])
+		}
 	}
 
 	return m, nil
@@ -369,10 +445,14 @@ func (m Model) handleSelection() (tea.Model, tea.Cmd) {
 
 // Messages
 type viewportsLoadedMsg struct {
-	viewports []client.Viewport
-	err       error
+	viewports     []client.Viewport
+	liveviewNames map[string]string
+	err           error
 }
 
+// inventoryChangedMsg is sent when the live device inventory changes
+type inventoryChangedMsg struct{}
+
 type camerasLoadedMsg struct {
 	cameras []client.PTZCamera
 	err     error
@@ -389,8 +469,13 @@ type switchResultMsg struct {
 }
 
 // Commands
-func loadViewports(c *client.Client) tea.Cmd {
+func loadViewports(c *client.Client, inv *inventory.Inventory) tea.Cmd {
 	return func() tea.Msg {
+		if inv != nil && inv.Loaded() {
+			snap := inv.Snapshot()
+			return viewportsLoadedMsg{viewports: snap.Viewers, liveviewNames: liveviewNames(snap.Liveviews)}
+		}
+
 		viewports, err := c.ListViewports()
 		return viewportsLoadedMsg{viewports: viewports, err: err}
 	}
@@ -417,7 +502,7 @@ func switchViewport(c *client.Client, viewportID, liveviewID, viewportName, live
 			return switchResultMsg{err: err}
 		}
 		return switchResultMsg{
-			message: fmt.Sprintf("✓ Switched %s to %s", viewportName, liveviewName),
+			message: resultMessage(c, fmt.Sprintf("✓ Switched %s to %s", viewportName, liveviewName)),
 		}
 	}
 }
@@ -433,14 +518,82 @@ func movePTZCamera(c *client.Client, cameraID string, preset int, cameraName str
 			presetLabel = "home position"
 		}
 		return switchResultMsg{
-			message: fmt.Sprintf("✓ Moved %s to %s", cameraName, presetLabel),
+			message: resultMessage(c, fmt.Sprintf("✓ Moved %s to %s", cameraName, presetLabel)),
 		}
 	}
 }
 
-// Run starts the TUI application
-func Run(c *client.Client) error {
-	p := tea.NewProgram(NewModel(c))
+func applyScene(c *client.Client, scene config.Scene) tea.Cmd {
+	return func() tea.Msg {
+		acts, err := actions.FromScene(scene)
+		if err != nil {
+			return switchResultMsg{err: err}
+		}
+
+		targets, err := actions.NewResolver(c).Resolve(acts)
+		if err != nil {
+			return switchResultMsg{err: err}
+		}
+
+		results := actions.Execute(context.Background(), c, targets, actions.DefaultWorkers)
+		if failed := actions.Failed(results); failed > 0 {
+			return switchResultMsg{err: fmt.Errorf("%d of %d actions in scene %s failed", failed, len(results), scene.Name)}
+		}
+
+		return switchResultMsg{
+			message: resultMessage(c, fmt.Sprintf("✓ Applied scene %s (%d actions)", scene.Name, len(results))),
+		}
+	}
+}
+
+// resultMessage marks a success message when nothing was actually changed
+func resultMessage(c *client.Client, message string) string {
+	if c.DryRun {
+		return message + " (dry run)"
+	}
+	return message
+}
+
+// liveviewNames maps liveview IDs to names
+func liveviewNames(liveviews []client.Liveview) map[string]string {
+	names := make(map[string]string, len(liveviews))
+	for _, lv := range liveviews {
+		names[lv.ID] = lv.Name
+	}
+	return names
+}
+
+// Run starts the TUI application, offering the given scenes
+func Run(c *client.Client, scenes []config.Scene) error {
+	log := logger.Get()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Printed dry-run requests would corrupt the screen
+	if c.DryRun {
+		c.DryRunOutput = io.Discard
+	}
+
+	model := NewModel(c)
+	model.scenes = scenes
+	model.inventory = inventory.New()
+	p := tea.NewProgram(model)
+
+	// Mirror device changes so viewport assignments stay current
+	unregister := model.inventory.OnChange(func(change client.DeviceChange) {
+		if change.Action == client.DeviceResync || change.ModelKey == inventory.KindViewer {
+			p.Send(inventoryChangedMsg{})
+		}
+	})
+	defer unregister()
+
+	go func() {
+		if err := model.inventory.Run(ctx, c, nil); err != nil && ctx.Err() == nil {
+			log.Warnw("Live inventory unavailable", "error", err)
+		}
+	}()
+
 	_, err := p.Run()
 	return err
 }
@@ -1,10 +1,16 @@
 package tui
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/config"
+	"github.com/methridge/protect/internal/inventory"
 )
 
 func TestNewModel(t *testing.T) {
@@ -442,3 +448,128 @@ func TestHandleSelectionPresets(t *testing.T) {
 		})
 	}
 }
+
+func TestInventoryChangedMsg(t *testing.T) {
+	responses := map[string]string{
+		"/proxy/protect/integration/v1/viewers":   `[{"id":"vp1","name":"Lobby","liveview":"lv1"}]`,
+		"/proxy/protect/integration/v1/cameras":   `[]`,
+		"/proxy/protect/integration/v1/lights":    `[]`,
+		"/proxy/protect/integration/v1/sensors":   `[]`,
+		"/proxy/protect/integration/v1/liveviews": `[{"id":"lv1","name":"Entrance"},{"id":"lv2","name":"Parking"}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.URL, "test-token")
+	model := NewModel(c)
+	model.inventory = inventory.New()
+	model.screen = ScreenViewports
+	model.viewports = []client.Viewport{{ID: "vp1", Name: "Lobby", Liveview: "lv1"}}
+
+	// Changes are ignored until the inventory has been loaded
+	updatedModel, _ := model.Update(inventoryChangedMsg{})
+	if m := updatedModel.(Model); m.liveviewNames != nil {
+		t.Error("Expected no liveview names before the inventory is loaded")
+	}
+
+	if err := model.inventory.Load(c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	model.inventory.Apply(client.DeviceChange{
+		Action: client.DeviceUpdate,
+		ID:     "vp1",
+		Fields: map[string]json.RawMessage{"liveview": []byte(`"lv2"`)},
+	})
+
+	updatedModel, _ = model.Update(inventoryChangedMsg{})
+	m := updatedModel.(Model)
+
+	if m.viewports[0].Liveview != "lv2" {
+		t.Errorf("Expected Lobby to show lv2, got %+v", m.viewports[0])
+	}
+
+	if view := m.View(); !strings.Contains(view, "Lobby (Parking)") {
+		t.Errorf("Expected view to show updated liveview, got '%s'", view)
+	}
+}
+
+func TestViewportsShowCurrentLiveview(t *testing.T) {
+	c := client.NewClient("https://test.example.com", "test-token")
+	model := NewModel(c)
+
+	msg := viewportsLoadedMsg{
+		viewports:     []client.Viewport{{ID: "vp1", Name: "Lobby", Liveview: "lv1"}},
+		liveviewNames: map[string]string{"lv1": "Entrance"},
+	}
+	updatedModel, _ := model.Update(msg)
+	m := updatedModel.(Model)
+
+	if view := m.View(); !strings.Contains(view, "Lobby (Entrance)") {
+		t.Errorf("Expected view to show current liveview, got '%s'", view)
+	}
+}
+
+func TestScenesMenu(t *testing.T) {
+	var moves []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/proxy/protect/integration/v1/cameras":
+			w.Write([]byte(`[{"id":"cam1","name":"Front Door"}]`))
+		default:
+			moves = append(moves, r.Method+" "+r.URL.Path)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.URL, "test-token")
+	model := NewModel(c)
+
+	if strings.Contains(model.View(), "Apply Scene") {
+		t.Error("Expected no scene entry without configured scenes")
+	}
+
+	model.scenes = []config.Scene{{Name: "Night", PTZ: []config.ScenePTZ{{Camera: "Front Door", Preset: "home"}}}}
+	if !strings.Contains(model.View(), "Apply Scene") {
+		t.Error("Expected scene entry on the main menu")
+	}
+
+	model.cursor = 1
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m := updatedModel.(Model)
+	if m.cursor != 2 {
+		t.Fatalf("Expected cursor to reach the scene entry, got %d", m.cursor)
+	}
+
+	updatedModel, _ = m.handleSelection()
+	m = updatedModel.(Model)
+	if m.screen != ScreenScenes {
+		t.Fatalf("Expected scenes screen, got %v", m.screen)
+	}
+
+	if view := m.View(); !strings.Contains(view, "Night (0 switches, 1 PTZ moves)") {
+		t.Errorf("Expected scene in view, got '%s'", view)
+	}
+
+	_, cmd := m.handleSelection()
+	if cmd == nil {
+		t.Fatal("Expected command to apply the scene")
+	}
+
+	result, ok := cmd().(switchResultMsg)
+	if !ok || result.err != nil {
+		t.Fatalf("Expected successful scene result, got %+v", result)
+	}
+
+	if len(moves) != 1 || moves[0] != "POST /proxy/protect/integration/v1/cameras/cam1/ptz/goto/-1" {
+		t.Errorf("Unexpected requests: %v", moves)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updatedModel.(Model).screen != ScreenMainMenu {
+		t.Error("Expected esc to return to the main menu")
+	}
+}
@@ -0,0 +1,102 @@
+package where
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/methridge/protect/internal/actions"
+	"github.com/methridge/protect/internal/client"
+)
+
+// Placement is a liveview that shows a camera and the viewers showing it
+type Placement struct {
+	Liveview client.Liveview
+	// Slots are the 1-based positions of the camera in the layout
+	Slots []int
+	// Cameras is the number of distinct cameras in the liveview
+	Cameras int
+	// Dedicated is true when the camera has a slot to itself rather than
+	// sharing a cycling slot
+	Dedicated bool
+	Viewers   []client.Viewport
+}
+
+// Result is every place a camera is displayed, best match first
+type Result struct {
+	Camera     client.PTZCamera
+	Placements []Placement
+}
+
+// Find looks up the liveviews containing camera, by name or ID, and the
+// viewers currently showing them
+func Find(c *client.Client, camera string) (*Result, error) {
+	cam, err := actions.NewResolver(c).Camera(camera)
+	if err != nil {
+		return nil, err
+	}
+
+	liveviews, err := c.ListCameras()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list liveviews: %w", err)
+	}
+
+	viewports, err := c.ListViewports()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list viewports: %w", err)
+	}
+
+	result := &Result{Camera: *cam}
+	for _, lv := range liveviews {
+		p := Placement{Liveview: lv}
+		distinct := make(map[string]bool)
+		for i, slot := range lv.Slots {
+			for _, id := range slot.Cameras {
+				distinct[id] = true
+				if id == cam.ID {
+					p.Slots = append(p.Slots, i+1)
+					if len(slot.Cameras) == 1 {
+						p.Dedicated = true
+					}
+				}
+			}
+		}
+		if len(p.Slots) == 0 {
+			continue
+		}
+		p.Cameras = len(distinct)
+
+		for _, vp := range viewports {
+			if vp.Liveview == lv.ID {
+				p.Viewers = append(p.Viewers, vp)
+			}
+		}
+		result.Placements = append(result.Placements, p)
+	}
+
+	sort.SliceStable(result.Placements, func(i, j int) bool {
+		return better(result.Placements[i], result.Placements[j])
+	})
+
+	return result, nil
+}
+
+// better ranks placements: a slot of its own first, then the fewest cameras,
+// so single-camera layouts come before grids
+func better(a, b Placement) bool {
+	if a.Dedicated != b.Dedicated {
+		return a.Dedicated
+	}
+	if a.Cameras != b.Cameras {
+		return a.Cameras < b.Cameras
+	}
+	return len(a.Liveview.Slots) < len(b.Liveview.Slots)
+}
+
+// Best returns the liveview that shows the camera most prominently, or nil
+// if no liveview contains it
+func (r *Result) Best() *client.Liveview {
+	if len(r.Placements) == 0 {
+		return nil
+	}
+	return &r.Placements[0].Liveview
+}
@@ -0,0 +1,72 @@
+package where
+
+import (
+	"testing"
+
+	"github.com/methridge/protect/internal/client"
+	"github.com/methridge/protect/internal/protecttest"
+)
+
+func newServer(t *testing.T) *client.Client {
+	t.Helper()
+
+	server := protecttest.New(t)
+	server.Set("cameras", `[{"id":"cam1","name":"Front Door"},{"id":"cam2","name":"Driveway"},{"id":"cam3","name":"Garage"}]`)
+	server.Set("liveviews", `[
+		{"id":"lv1","name":"All Cameras","layout":4,"slots":[{"cameras":["cam2"]},{"cameras":["cam1"]},{"cameras":["cam3"]},{"cameras":[]}]},
+		{"id":"lv2","name":"Cycle","layout":1,"slots":[{"cameras":["cam2","cam1"]}]},
+		{"id":"lv3","name":"Door","layout":1,"slots":[{"cameras":["cam1"]}]},
+		{"id":"lv4","name":"Yard","layout":1,"slots":[{"cameras":["cam3"]}]}
+	]`)
+	server.Set("viewers", `[{"id":"vp1","name":"Tower","liveview":"lv1"},{"id":"vp2","name":"Lobby","liveview":"lv4"},{"id":"vp3","name":"Office","liveview":"lv1"}]`)
+	return server.APIClient()
+}
+
+func TestFind(t *testing.T) {
+	c := newServer(t)
+
+	result, err := Find(c, "Front Door")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	var names []string
+	for _, p := range result.Placements {
+		names = append(names, p.Liveview.Name)
+	}
+	want := []string{"Door", "All Cameras", "Cycle"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] || names[2] != want[2] {
+		t.Fatalf("Placements = %v, want %v", names, want)
+	}
+
+	grid := result.Placements[1]
+	if len(grid.Slots) != 1 || grid.Slots[0] != 2 || grid.Cameras != 3 || !grid.Dedicated {
+		t.Errorf("Unexpected grid placement: %+v", grid)
+	}
+	if len(grid.Viewers) != 2 || grid.Viewers[0].Name != "Tower" || grid.Viewers[1].Name != "Office" {
+		t.Errorf("Expected Tower and Office on the grid, got %+v", grid.Viewers)
+	}
+	if result.Placements[2].Dedicated {
+		t.Error("Expected the cycling slot not to be dedicated")
+	}
+
+	if best := result.Best(); best == nil || best.ID != "lv3" {
+		t.Errorf("Best() = %+v, want lv3", best)
+	}
+}
+
+func TestFindByID(t *testing.T) {
+	c := newServer(t)
+
+	result, err := Find(c, "cam3")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if result.Camera.Name != "Garage" || len(result.Placements) != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if _, err := Find(c, "Porch"); err == nil {
+		t.Error("Expected an error for an unknown camera")
+	}
+}